@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+// annotatedHeader is prepended to annotated YAML output.
+const annotatedHeader = `# tv4p-road-tool config (annotated)
+#
+# Safe to edit: names, parts lists, colors, crossroad connections and defaults.
+# Internal fields (id, type, tv4p_*) come from Terrain Builder: keep them as-is
+# for stable re-patching, or remove them to let the tool allocate new ones.
+# Comments are added to the first occurrence of each documented key only.
+`
+
+// fieldAnnotation is a comment attached to a YAML key.
+type fieldAnnotation struct {
+	Key     string // YAML key (e.g. color_custom)
+	Comment string // human guidance
+}
+
+// fieldAnnotations documents config keys for level designers.
+var fieldAnnotations = []fieldAnnotation{
+	{Key: "road_types", Comment: "Road Types window entries (order matters for crossroads)"},
+	{Key: "crossroad_types", Comment: "crossroad definitions (omit to keep crossroads in the file)"},
+	{Key: "name", Comment: "display name; road type names are referenced by crossroads"},
+	{Key: "id", Comment: "internal TB entry ID: do not edit, remove to allocate a new one"},
+	{Key: "type", Comment: "internal TB entry type: do not edit"},
+	{Key: "object_file", Comment: "p3d path relative to the game root (P:\\)"},
+	{Key: "model", Comment: "crossroad p3d path as stored by TB (absolute P:\\ path)"},
+	{Key: "key_parts_custom", Comment: "false = TB standard color, key_parts_color is ignored"},
+	{Key: "normal_parts_custom", Comment: "false = TB standard color, normal_parts_color is ignored"},
+	{Key: "color_custom", Comment: "false = TB standard color, color is ignored"},
+	{Key: "connections", Comment: "A/B = through road, C (and D for kr_x_) = branch road type names"},
+	{Key: "shape", Comment: "t or x junction, written instead of the kr_t_/kr_x_ name guess"},
+	{Key: "default", Comment: "road type this crossroad is the default for (one per road type)"},
+	{Key: "world", Comment: "world detected from the name (--group-by-world): config-only"},
+	{Key: "checksum", Comment: "xxhash of the rest (verify-config): any edit invalidates it"},
+	{Key: "tv4p_def", Comment: "raw TB data for lossless round-trip: do not edit"},
+	{Key: "tv4p_link", Comment: "raw TB data for lossless round-trip: do not edit"},
+	{Key: "tv4p_blobs", Comment: "raw TB def fields 0x80-0x82, kept when tv4p_def is removed: do not edit"},
+	{Key: "tv4p_key_color", Comment: "raw TB bytes of a non-custom color: do not edit"},
+	{Key: "tv4p_normal_color", Comment: "raw TB bytes of a non-custom color: do not edit"},
+	{Key: "tv4p_flag", Comment: "raw TB 0x7D byte of a straight part: do not edit"},
+	{Key: "tv4p_offsets", Comment: "file offsets of the entry (--emit-offsets): read-only, ignored on patch"},
+}
+
+// annotateYAML adds a header and trailing comments to YAML produced by encodeConfig.
+// Keys nested inside raw `tv4p_*` subtrees are never annotated.
+// The output is intended for humans; it is still valid YAML.
+func annotateYAML(in []byte) []byte {
+	comments := map[string]string{}
+	for _, a := range fieldAnnotations {
+		comments[a.Key] = a.Comment
+	}
+
+	var out bytes.Buffer
+	out.WriteString(annotatedHeader)
+
+	done := map[string]bool{}
+	rawIndent := -1
+	lines := strings.SplitAfter(string(in), "\n")
+	for _, line := range lines {
+		key, indent := yamlLineKey(line)
+		if rawIndent >= 0 && (key == "" || indent > rawIndent) {
+			out.WriteString(line)
+			continue
+		}
+		rawIndent = -1
+
+		if strings.HasPrefix(key, "tv4p_") {
+			rawIndent = indent
+		}
+
+		comment, ok := comments[key]
+		if !ok || done[key] {
+			out.WriteString(line)
+			continue
+		}
+		done[key] = true
+
+		body := strings.TrimRight(line, "\r\n")
+		out.WriteString(body + " # " + comment + line[len(body):])
+	}
+
+	return out.Bytes()
+}
+
+// yamlLineKey returns the mapping key of a YAML line and its column.
+// List item markers (`- `) are skipped; lines without a key return "".
+func yamlLineKey(line string) (string, int) {
+	trimmed := strings.TrimLeft(line, " ")
+	indent := len(line) - len(trimmed)
+	for strings.HasPrefix(trimmed, "- ") {
+		trimmed = trimmed[2:]
+		indent += 2
+	}
+
+	idx := strings.Index(trimmed, ":")
+	if idx <= 0 {
+		return "", indent
+	}
+
+	key := trimmed[:idx]
+	if strings.ContainsAny(key, " \"'#") {
+		return "", indent
+	}
+
+	return key, indent
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/invopop/yaml"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestAnnotateYAML(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			{Name: "asf1", ID: 0x54, Type: 0x12, StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\asf1_12.p3d`, Type: 0x13}}},
+			{Name: "city", ID: 0x9C, Type: 0x12},
+		},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{
+				Name:        "kr_t_asf1_city",
+				Model:       `P:\dz\kr_t_asf1_city.p3d`,
+				Default:     "asf1",
+				Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"},
+				TV4PDef:     &tv4p.EntryRaw{Type: 0x17, ID: 0x100, Fields: []tv4p.FieldRaw{{Tag: 0x33, Type: 0x0B, Raw: "6b72"}}},
+			},
+		},
+	}
+
+	plain, err := encodeConfig(cfg, "yaml")
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	out := string(annotateYAML(plain))
+
+	if !strings.HasPrefix(out, annotatedHeader) {
+		t.Fatalf("missing header")
+	}
+	for _, key := range []string{"id", "default", "tv4p_def"} {
+		want := key + ": "
+		lines := 0
+		for _, line := range strings.Split(out, "\n") {
+			if strings.Contains(line, want) && strings.Contains(line, " # ") {
+				lines++
+			}
+		}
+		if lines != 1 {
+			t.Fatalf("key %q annotated %d times, want 1:\n%s", key, lines, out)
+		}
+	}
+
+	// "type" inside tv4p_def fields must not take the annotation.
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "type: 11") && strings.Contains(line, "#") {
+			t.Fatalf("raw field annotated: %q", line)
+		}
+	}
+
+	var back tv4p.RoadConfig
+	if err := yaml.Unmarshal([]byte(out), &back); err != nil {
+		t.Fatalf("annotated yaml does not parse: %v", err)
+	}
+	if !reflect.DeepEqual(back, cfg) {
+		t.Fatalf("annotated yaml changed data:\n got=%+v\nwant=%+v", back, cfg)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+// baselineDiff keeps only the road types and crossroads of cfg that are added
+// or changed compared to base (extract --baseline). Changed road types keep just
+// the parts whose paths are not in the same baseline list, so the result can be
+// patched with --append. IDs and tv4p_* raw fields are not compared.
+func baselineDiff(cfg tv4p.RoadConfig, base tv4p.RoadConfig) tv4p.RoadConfig {
+	baseTypes := make(map[string]tv4p.RoadType, len(base.Types))
+	for _, rt := range base.Types {
+		baseTypes[rt.Name] = rt
+	}
+
+	var out tv4p.RoadConfig
+	for _, rt := range cfg.Types {
+		old, ok := baseTypes[rt.Name]
+		if !ok {
+			out.Types = append(out.Types, rt)
+			continue
+		}
+
+		changed := rt
+		changed.StraightParts = newParts(rt.StraightParts, old.StraightParts)
+		changed.CornerParts = newParts(rt.CornerParts, old.CornerParts)
+		changed.TerminatorPart = newParts(rt.TerminatorPart, old.TerminatorPart)
+		if len(changed.StraightParts)+len(changed.CornerParts)+len(changed.TerminatorPart) > 0 ||
+			!sameRoadColors(rt, old) {
+			out.Types = append(out.Types, changed)
+		}
+	}
+
+	baseCrossroads := make(map[string]tv4p.CrossroadType, len(base.CrossroadTypes))
+	for _, cr := range base.CrossroadTypes {
+		baseCrossroads[cr.Name] = cr
+	}
+	for _, cr := range cfg.CrossroadTypes {
+		if old, ok := baseCrossroads[cr.Name]; !ok || !sameCrossroad(cr, old) {
+			out.CrossroadTypes = append(out.CrossroadTypes, cr)
+		}
+	}
+
+	return out
+}
+
+// newParts returns the parts whose paths are not in base (case/slash-insensitive).
+func newParts(parts []tv4p.RoadPart, base []tv4p.RoadPart) []tv4p.RoadPart {
+	var out []tv4p.RoadPart
+	for _, p := range parts {
+		key := partPathKey(p.Path)
+		if !slices.ContainsFunc(base, func(b tv4p.RoadPart) bool { return partPathKey(b.Path) == key }) {
+			out = append(out, p)
+		}
+	}
+
+	return out
+}
+
+// sameRoadColors compares the custom flags and, where custom, the colors of two road types.
+func sameRoadColors(a tv4p.RoadType, b tv4p.RoadType) bool {
+	return a.NormalCustom == b.NormalCustom && a.KeyCustom == b.KeyCustom &&
+		(!a.NormalCustom || a.NormalColor == b.NormalColor) &&
+		(!a.KeyCustom || a.KeyColor == b.KeyColor)
+}
+
+// sameCrossroad compares the user-editable fields of two crossroads.
+func sameCrossroad(a tv4p.CrossroadType, b tv4p.CrossroadType) bool {
+	return a.Connections == b.Connections &&
+		partPathKey(a.Model) == partPathKey(b.Model) &&
+		a.Default == b.Default &&
+		a.ColorCustom == b.ColorCustom &&
+		(!a.ColorCustom || a.Color == b.Color)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestBaselineDiff(t *testing.T) {
+	t.Parallel()
+
+	red := tv4p.Color{R: 200, G: 40, B: 40, A: 255}
+	base := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}}},
+			{Name: "city", StraightParts: []tv4p.RoadPart{{Name: "city_12", Path: `dz\roads\city_12.p3d`}}},
+			{Name: "gravel", NormalCustom: true, NormalColor: red},
+		},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{Name: "kr_t_asf1_city", Model: `dz\roads\kr_t_asf1_city.p3d`, Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}},
+			{Name: "kr_x_city_city", Model: `dz\roads\kr_x_city_city.p3d`, Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city", D: "city"}},
+		},
+	}
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			// Same parts with a different ID, path case and slashes: unchanged.
+			{Name: "asf1", ID: 7, StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: "DZ/roads/asf1_12.p3d", ID: 9}}},
+			// One new part.
+			{Name: "city", StraightParts: []tv4p.RoadPart{
+				{Name: "city_12", Path: `dz\roads\city_12.p3d`},
+				{Name: "city_25", Path: `dz\roads\city_25.p3d`},
+			}},
+			// Color change only.
+			{Name: "gravel", NormalCustom: true, NormalColor: tv4p.Color{R: 40, G: 200, B: 40, A: 255}},
+			// Added.
+			{Name: "asf2"},
+		},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{Name: "kr_t_asf1_city", Model: `DZ/roads/kr_t_asf1_city.p3d`, Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}},
+			{Name: "kr_x_city_city", Model: `dz\roads\kr_x_city_city.p3d`, Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city", D: "asf1"}},
+			{Name: "kr_t_asf2_city", Connections: tv4p.CrossroadConnections{A: "asf2", B: "asf2", C: "city"}},
+		},
+	}
+
+	got := baselineDiff(cfg, base)
+
+	var names []string
+	for _, rt := range got.Types {
+		names = append(names, rt.Name)
+	}
+	if want := []string{"city", "gravel", "asf2"}; !slices.Equal(names, want) {
+		t.Fatalf("road types=%v want %v", names, want)
+	}
+	if parts := got.Types[0].StraightParts; len(parts) != 1 || parts[0].Name != "city_25" {
+		t.Fatalf("city parts=%+v want only city_25", parts)
+	}
+
+	names = nil
+	for _, cr := range got.CrossroadTypes {
+		names = append(names, cr.Name)
+	}
+	if want := []string{"kr_x_city_city", "kr_t_asf2_city"}; !slices.Equal(names, want) {
+		t.Fatalf("crossroads=%v want %v", names, want)
+	}
+
+	if empty := baselineDiff(base, base); len(empty.Types) != 0 || len(empty.CrossroadTypes) != 0 {
+		t.Fatalf("same config: diff=%+v want empty", empty)
+	}
+}
+
+func TestExtractBaselineOptions(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{Types: []tv4p.RoadType{
+		{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}}},
+		{Name: "city", StraightParts: []tv4p.RoadPart{{Name: "city_12", Path: `dz\roads\city_12.p3d`}}},
+	}}
+	data, err := tv4p.PatchRoadTool(testTV4P(t, cfg), cfg, tv4p.ScopeRoads)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.tv4p")
+	base := filepath.Join(dir, "base.yaml")
+	files := map[string]string{
+		in: string(data),
+		// asf1 only through ${KEY}: city is the one changed road type.
+		base: "road_types:\n  - name: ${TYPE}\n    starting_parts:\n      - name: asf1_12\n        object_file: dz\\roads\\asf1_12.p3d\n",
+	}
+	for path, body := range files {
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := &extractCmd{Format: "yaml", Scope: "roads", ModelExts: []string{".p3d"}, Baseline: base, ConfigVars: []string{"TYPE=asf1"}}
+	cmd.Args.Input = in
+	cmd.Args.Output = filepath.Join(dir, "diff.yaml")
+	if err := cmd.Execute(nil); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	got, err := readConfig(cmd.Args.Output, false, nil)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(got.Types) != 1 || got.Types[0].Name != "city" {
+		t.Fatalf("diff types=%+v want only city", got.Types)
+	}
+
+	// Raw indices count the file's full road type list, not the diff.
+	cmd.RawConnections = true
+	if err := cmd.Execute(nil); err == nil || !strings.Contains(err.Error(), "--raw-connections") {
+		t.Fatalf("err=%v want --baseline with --raw-connections rejected", err)
+	}
+}
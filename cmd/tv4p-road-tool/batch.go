@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
+)
+
+// patchedSuffix is inserted before the extension of batch outputs without --in-place.
+const patchedSuffix = ".patched"
+
+// batchResult is the outcome of patching one file in batch mode.
+type batchResult struct {
+	In  string
+	Out string
+	Err error
+}
+
+// executeBatch applies one config to every tv4p file matched by --batch.
+// With --batch the first positional argument is the config file.
+func (c *patchCmd) executeBatch(perm outputPerm) error {
+	configPath := c.Args.Input
+	if configPath == "" || c.Args.Config != "" {
+		return errors.New("--batch takes exactly one argument: patch --batch GLOB CONFIG")
+	}
+
+	if c.NameTmpl != "" {
+		if c.InPlace {
+			return errors.New("--output-basename-template cannot be combined with --in-place")
+		}
+		if err := checkBasenameTemplate(c.NameTmpl); err != nil {
+			return err
+		}
+	}
+
+	files, err := batchFiles(c.Batch, c.NameTmpl)
+	if err != nil {
+		return err
+	}
+	if err := checkTemplateOutputs(files, c.NameTmpl); err != nil {
+		return err
+	}
+
+	results := runBatch(files, c.InPlace, c.NameTmpl, c.FailFast, func(in, out string) error {
+		return c.patchFile(in, configPath, out, perm)
+	})
+
+	return printBatchSummary(results, len(files))
+}
+
+// batchFiles expands the glob, skipping outputs of earlier non in-place runs:
+// <name>.patched<ext> files and, with a basename template, files that the template
+// names for another match.
+func batchFiles(pattern, tmpl string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("--batch %q: %w", pattern, err)
+	}
+
+	outputs := map[string]bool{}
+	if tmpl != "" {
+		for _, m := range matches {
+			if out := batchOutputPath(m, false, tmpl); !samePath(out, m) {
+				outputs[filepath.Clean(out)] = true
+			}
+		}
+	}
+
+	var files []string
+	for _, m := range matches {
+		if strings.HasSuffix(strings.TrimSuffix(m, filepath.Ext(m)), patchedSuffix) || outputs[filepath.Clean(m)] {
+			continue
+		}
+		files = append(files, m)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("--batch %q: no files matched", pattern)
+	}
+
+	return files, nil
+}
+
+// checkTemplateOutputs rejects a basename template that names an input as its own
+// output: that would patch every file in place without the --in-place opt-in.
+func checkTemplateOutputs(files []string, tmpl string) error {
+	if tmpl == "" {
+		return nil
+	}
+	for _, in := range files {
+		if samePath(batchOutputPath(in, false, tmpl), in) {
+			return fmt.Errorf("--output-basename-template %q writes %s over itself (use --in-place to patch in place)", tmpl, in)
+		}
+	}
+
+	return nil
+}
+
+// samePath reports whether two paths name the same file name; case-insensitive,
+// since the tool runs on Windows where TB lives.
+func samePath(a, b string) bool {
+	return strings.EqualFold(filepath.Clean(a), filepath.Clean(b))
+}
+
+// batchOutputPath returns <name>.patched<ext> next to the input, or the input itself in place.
+// A non-empty tmpl (checked by checkBasenameTemplate) names the output instead.
+func batchOutputPath(in string, inPlace bool, tmpl string) string {
+	if inPlace {
+		return in
+	}
+	if tmpl != "" {
+		return filepath.Join(filepath.Dir(in), expandBasename(tmpl, in))
+	}
+
+	ext := filepath.Ext(in)
+	return strings.TrimSuffix(in, ext) + patchedSuffix + ext
+}
+
+// basenamePlaceholder matches {...} placeholders of --output-basename-template.
+var basenamePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// checkBasenameTemplate validates an --output-basename-template: only {name}, {world}
+// and {ext} placeholders, {name} required (outputs must not collide), and a plain file
+// name without path separators or "..".
+func checkBasenameTemplate(tmpl string) error {
+	for _, m := range basenamePlaceholder.FindAllString(tmpl, -1) {
+		switch m {
+		case "{name}", "{world}", "{ext}":
+		default:
+			return fmt.Errorf("--output-basename-template %q: unknown placeholder %s (use {name}, {world}, {ext})", tmpl, m)
+		}
+	}
+
+	rest := basenamePlaceholder.ReplaceAllString(tmpl, "")
+	switch {
+	case !strings.Contains(tmpl, "{name}"):
+		return fmt.Errorf("--output-basename-template %q: {name} is required", tmpl)
+	case strings.ContainsAny(rest, "{}"):
+		return fmt.Errorf("--output-basename-template %q: unbalanced braces", tmpl)
+	case strings.ContainsAny(tmpl, `/\:`) || strings.Contains(tmpl, ".."):
+		return fmt.Errorf("--output-basename-template %q: must be a file name (no path separators or \"..\")", tmpl)
+	}
+
+	return nil
+}
+
+// expandBasename fills a checked template for an input path: {name} is the input file
+// name without extension, {ext} its extension without the dot and {world} the world
+// detected from the name (roadparts.World, empty if none).
+func expandBasename(tmpl string, in string) string {
+	base := filepath.Base(in)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+
+	return strings.NewReplacer(
+		"{name}", name,
+		"{world}", roadparts.World(name),
+		"{ext}", strings.TrimPrefix(ext, "."),
+	).Replace(tmpl)
+}
+
+// runBatch calls patch for every file and collects results.
+// Failures do not stop the run unless failFast is set.
+func runBatch(files []string, inPlace bool, tmpl string, failFast bool, patch func(in, out string) error) []batchResult {
+	results := make([]batchResult, 0, len(files))
+	for _, in := range files {
+		out := batchOutputPath(in, inPlace, tmpl)
+		err := patch(in, out)
+		results = append(results, batchResult{In: in, Out: out, Err: err})
+		if err != nil && failFast {
+			break
+		}
+	}
+
+	return results
+}
+
+// printBatchSummary prints per-file results and returns an error if any file failed.
+func printBatchSummary(results []batchResult, total int) error {
+	failed := 0
+	cliLog.Infof("\nbatch summary:")
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			cliLog.Infof("  FAIL %s: %v", r.In, r.Err)
+			continue
+		}
+		cliLog.Infof("  ok   %s -> %s", r.In, r.Out)
+	}
+
+	if skipped := total - len(results); skipped > 0 {
+		cliLog.Infof("  skipped %d file(s) after failure (--fail-fast)", skipped)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("batch: %d of %d file(s) failed", failed, total)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBatchOutputPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		inPlace bool
+		tmpl    string
+		want    string
+	}{
+		{in: "worlds/a.tv4p", want: "worlds/a.patched.tv4p"},
+		{in: "worlds/a.tv4p", inPlace: true, want: "worlds/a.tv4p"},
+		{in: "noext", want: "noext.patched"},
+		{in: "worlds/a.tv4p", tmpl: "{name}.road.{ext}", want: filepath.Join("worlds", "a.road.tv4p")},
+		{in: "worlds/sakhal_main.tv4p", tmpl: "{world}-{name}.{ext}", want: filepath.Join("worlds", "sakhal-sakhal_main.tv4p")},
+		{in: "worlds/a.tv4p", tmpl: "{world}{name}_out.{ext}", want: filepath.Join("worlds", "a_out.tv4p")},
+		{in: "noext", tmpl: "{name}.{ext}.out", want: "noext..out"},
+	}
+
+	for _, tt := range tests {
+		if got := batchOutputPath(tt.in, tt.inPlace, tt.tmpl); got != tt.want {
+			t.Fatalf("batchOutputPath(%q, %v, %q)=%q want %q", tt.in, tt.inPlace, tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestCheckBasenameTemplate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tmpl string
+		err  bool
+	}{
+		{tmpl: "{name}.road.yaml"},
+		{tmpl: "{world}_{name}.{ext}"},
+		{tmpl: "out.tv4p", err: true},
+		{tmpl: "{name}.{bogus}", err: true},
+		{tmpl: "{name}.{ext", err: true},
+		{tmpl: "{name}}.tv4p", err: true},
+		{tmpl: "../{name}.tv4p", err: true},
+		{tmpl: "out/{name}.tv4p", err: true},
+		{tmpl: `out\{name}.tv4p`, err: true},
+		{tmpl: "C:{name}.tv4p", err: true},
+		{tmpl: "{name}..tv4p", err: true},
+	}
+
+	for _, tt := range tests {
+		if err := checkBasenameTemplate(tt.tmpl); (err != nil) != tt.err {
+			t.Fatalf("checkBasenameTemplate(%q) err=%v want err=%v", tt.tmpl, err, tt.err)
+		}
+	}
+}
+
+func TestBatchFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"b.tv4p", "a.tv4p", "a.patched.tv4p", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := batchFiles(filepath.Join(dir, "*.tv4p"), "")
+	if err != nil {
+		t.Fatalf("batchFiles: %v", err)
+	}
+	want := []string{filepath.Join(dir, "a.tv4p"), filepath.Join(dir, "b.tv4p")}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("files=%v want %v", files, want)
+	}
+
+	if _, err := batchFiles(filepath.Join(dir, "*.none"), ""); err == nil {
+		t.Fatalf("expected error for empty match")
+	}
+
+	// Outputs of an earlier templated run are skipped too.
+	if err := os.WriteFile(filepath.Join(dir, "a.road.tv4p"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	files, err = batchFiles(filepath.Join(dir, "*.tv4p"), "{name}.road.{ext}")
+	if err != nil {
+		t.Fatalf("batchFiles: %v", err)
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("templated files=%v want %v", files, want)
+	}
+}
+
+func TestCheckTemplateOutputs(t *testing.T) {
+	t.Parallel()
+
+	files := []string{filepath.Join("worlds", "a.tv4p"), filepath.Join("worlds", "b.tv4p")}
+	tests := []struct {
+		tmpl string
+		err  bool
+	}{
+		{tmpl: ""},
+		{tmpl: "{name}.road.{ext}"},
+		{tmpl: "{name}.{ext}", err: true},
+		{tmpl: "{world}{name}.tv4p", err: true},
+		{tmpl: "{name}.TV4P", err: true},
+	}
+
+	for _, tt := range tests {
+		if err := checkTemplateOutputs(files, tt.tmpl); (err != nil) != tt.err {
+			t.Fatalf("checkTemplateOutputs(%q) err=%v want err=%v", tt.tmpl, err, tt.err)
+		}
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	t.Parallel()
+
+	files := []string{"a.tv4p", "bad.tv4p", "c.tv4p"}
+	patch := func(in, _ string) error {
+		if in == "bad.tv4p" {
+			return errors.New("boom")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		failFast bool
+		runs     int
+	}{
+		{name: "continue", runs: 3},
+		{name: "fail_fast", failFast: true, runs: 2},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			results := runBatch(files, false, "", tt.failFast, patch)
+			if len(results) != tt.runs {
+				t.Fatalf("runs=%d want %d", len(results), tt.runs)
+			}
+			if results[1].Err == nil {
+				t.Fatalf("expected bad.tv4p to fail")
+			}
+			if results[0].Out != "a.patched.tv4p" {
+				t.Fatalf("out=%q want %q", results[0].Out, "a.patched.tv4p")
+			}
+			if err := printBatchSummary(results, len(files)); err == nil {
+				t.Fatalf("expected aggregate error")
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+	"github.com/woozymasta/tv4p-road-tool/internal/vars"
+)
+
+// Fixed names of the bundle index and ID manifest inside a bundle.
+const (
+	bundleIndexName      = "bundle.json"
+	bundleIDManifestName = "id-manifest.json"
+)
+
+type bundleCmd struct {
+	Args struct {
+		Input  string `positional-arg-name:"IN" required:"true" description:"Input tv4p file"`
+		Config string `positional-arg-name:"CONFIG" required:"true" description:"Config file applied to it (stored verbatim)"`
+		Output string `positional-arg-name:"OUT" required:"true" description:"Output bundle (zip)"`
+	} `positional-args:"true"`
+
+	Chmod string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
+}
+
+type unbundleCmd struct {
+	Args struct {
+		Bundle string `positional-arg-name:"BUNDLE" required:"true" description:"Bundle written by the bundle command"`
+		Dir    string `positional-arg-name:"DIR" description:"Directory to extract into (default: current directory)"`
+	} `positional-args:"true"`
+
+	Force bool   `long:"force" description:"Overwrite existing files"`
+	Chmod string `long:"chmod" value-name:"MODE" description:"Octal permissions for the extracted files (default: 0600 for new files)"`
+}
+
+// bundleIndex is the bundle.json entry: which file is which, and the tool that wrote it.
+type bundleIndex struct {
+	Tool       vars.BuildInfo `json:"tool"`        // build that wrote the bundle
+	TV4P       string         `json:"tv4p"`        // tv4p file name
+	Config     string         `json:"config"`      // config file name
+	IDManifest string         `json:"id_manifest"` // tv4p.ListIDs of the tv4p file as JSON
+}
+
+// files returns the bundled file names in archive order.
+func (b bundleIndex) files() []string {
+	return []string{b.TV4P, b.Config, b.IDManifest}
+}
+
+// Execute packs the tv4p file, the config and an ID manifest into a zip bundle.
+func (c *bundleCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(c.Args.Input)
+	if err != nil {
+		return err
+	}
+	cfg, err := os.ReadFile(c.Args.Config)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := writeBundle(&buf, filepath.Base(c.Args.Input), data, filepath.Base(c.Args.Config), cfg); err != nil {
+		return err
+	}
+	if err := perm.writeFile(c.Args.Output, buf.Bytes()); err != nil {
+		return err
+	}
+
+	cliLog.Infof("bundled %s + %s -> %s", c.Args.Input, c.Args.Config, c.Args.Output)
+
+	return nil
+}
+
+// Execute extracts a bundle and checks its ID manifest against the bundled tv4p file.
+func (c *unbundleCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(c.Args.Bundle)
+	if err != nil {
+		return err
+	}
+	index, files, err := readBundle(raw)
+	if err != nil {
+		return err
+	}
+	if err := checkIDManifest(files[index.TV4P], files[index.IDManifest]); err != nil {
+		cliLog.Warnf("%v", err)
+	}
+
+	dir := c.Args.Dir
+	if dir == "" {
+		dir = "."
+	}
+	if !c.Force {
+		for _, name := range index.files() {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return fmt.Errorf("%s already exists (pass --force to overwrite)", filepath.Join(dir, name))
+			}
+		}
+	}
+
+	for _, name := range index.files() {
+		if err := perm.writeFile(filepath.Join(dir, name), files[name]); err != nil {
+			return err
+		}
+	}
+
+	cliLog.Infof("unbundled %s (tool %s) into %s: %s", c.Args.Bundle, index.Tool.Version, dir, strings.Join(index.files(), ", "))
+
+	return nil
+}
+
+// writeBundle writes a zip with bundle.json, the tv4p file, the config and the ID manifest.
+func writeBundle(w io.Writer, tv4pName string, data []byte, cfgName string, cfg []byte) error {
+	ids, err := tv4p.ListIDs(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", tv4pName, err)
+	}
+	manifest, err := json.MarshalIndent(ids, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	index := bundleIndex{Tool: vars.Info(), TV4P: tv4pName, Config: cfgName, IDManifest: bundleIDManifestName}
+	if err := index.validate(); err != nil {
+		return err
+	}
+	indexData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{bundleIndexName, indexData},
+		{tv4pName, data},
+		{cfgName, cfg},
+		{bundleIDManifestName, manifest},
+	}
+	for _, e := range entries {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(e.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// readBundle reads a bundle zip and returns its index and the indexed files by name.
+func readBundle(raw []byte) (bundleIndex, map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return bundleIndex{}, nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return bundleIndex{}, nil, err
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return bundleIndex{}, nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		files[f.Name] = data
+	}
+
+	indexData, ok := files[bundleIndexName]
+	if !ok {
+		return bundleIndex{}, nil, fmt.Errorf("not a bundle: %s is missing", bundleIndexName)
+	}
+	var index bundleIndex
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return bundleIndex{}, nil, fmt.Errorf("%s: %w", bundleIndexName, err)
+	}
+	if err := index.validate(); err != nil {
+		return bundleIndex{}, nil, err
+	}
+	for _, name := range index.files() {
+		if _, ok := files[name]; !ok {
+			return bundleIndex{}, nil, fmt.Errorf("bundle is missing %s", name)
+		}
+	}
+
+	return index, files, nil
+}
+
+// validate rejects file names that are empty, not plain base names (no directories
+// or "..", so unbundle cannot write outside DIR) or used twice.
+func (b bundleIndex) validate() error {
+	seen := map[string]bool{bundleIndexName: true}
+	for _, name := range b.files() {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\:`) {
+			return fmt.Errorf("invalid bundle file name %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("bundle file name %q is used twice", name)
+		}
+		seen[name] = true
+	}
+
+	return nil
+}
+
+// checkIDManifest compares a bundled ID manifest with the IDs of the bundled tv4p file.
+func checkIDManifest(data []byte, manifest []byte) error {
+	var want []tv4p.IDEntry
+	if err := json.Unmarshal(manifest, &want); err != nil {
+		return fmt.Errorf("%s: %w", bundleIDManifestName, err)
+	}
+	got, err := tv4p.ListIDs(data)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("bundled tv4p IDs do not match %s", bundleIDManifestName)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data := testTV4P(t, tv4p.RoadConfig{Types: []tv4p.RoadType{{
+		Name:          "asf1",
+		StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}},
+	}}})
+	cfg := []byte("road_types:\n  - name: asf1\n")
+
+	var buf bytes.Buffer
+	if err := writeBundle(&buf, "world.tv4p", data, "roads.yaml", cfg); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	index, files, err := readBundle(buf.Bytes())
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if index.TV4P != "world.tv4p" || index.Config != "roads.yaml" || index.IDManifest != bundleIDManifestName {
+		t.Fatalf("index=%+v", index)
+	}
+	if !bytes.Equal(files[index.TV4P], data) || !bytes.Equal(files[index.Config], cfg) {
+		t.Fatalf("bundled files differ from the inputs")
+	}
+	if err := checkIDManifest(files[index.TV4P], files[index.IDManifest]); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+
+	// unbundle writes the files and refuses to overwrite them without --force.
+	bundlePath := filepath.Join(t.TempDir(), "world.zip")
+	if err := os.WriteFile(bundlePath, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+	cmd := unbundleCmd{}
+	cmd.Args.Bundle = bundlePath
+	cmd.Args.Dir = t.TempDir()
+	if err := cmd.Execute(nil); err != nil {
+		t.Fatalf("unbundle: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(cmd.Args.Dir, "world.tv4p"))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("extracted tv4p: err=%v equal=%v", err, bytes.Equal(got, data))
+	}
+	if err := cmd.Execute(nil); err == nil {
+		t.Fatalf("second unbundle: want error without --force")
+	}
+	cmd.Force = true
+	if err := cmd.Execute(nil); err != nil {
+		t.Fatalf("unbundle --force: %v", err)
+	}
+}
+
+func TestBundleIndexValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		index bundleIndex
+		ok    bool
+	}{
+		{name: "plain", index: bundleIndex{TV4P: "a.tv4p", Config: "a.yaml", IDManifest: bundleIDManifestName}, ok: true},
+		{name: "traversal", index: bundleIndex{TV4P: "../a.tv4p", Config: "a.yaml", IDManifest: bundleIDManifestName}},
+		{name: "windows dir", index: bundleIndex{TV4P: `x\a.tv4p`, Config: "a.yaml", IDManifest: bundleIDManifestName}},
+		{name: "duplicate", index: bundleIndex{TV4P: "a", Config: "a", IDManifest: bundleIDManifestName}},
+		{name: "index name", index: bundleIndex{TV4P: bundleIndexName, Config: "a.yaml", IDManifest: bundleIDManifestName}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.index.validate(); (err == nil) != tt.ok {
+				t.Fatalf("err=%v want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
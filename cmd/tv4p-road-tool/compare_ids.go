@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type compareIDsCmd struct {
+	Args struct {
+		Source string `positional-arg-name:"SRC" required:"true" description:"Original tv4p file"`
+		Dest   string `positional-arg-name:"DST" required:"true" description:"Round-tripped or patched tv4p file"`
+	} `positional-args:"true"`
+
+	Format string `short:"f" long:"format" choice:"text" choice:"json" default:"text" description:"Output format"`
+}
+
+// Execute reports road type, part and crossroad IDs that differ between SRC and DST.
+// It fails when any ID changed or an entry exists in one file only.
+func (c *compareIDsCmd) Execute(_ []string) error {
+	src, err := os.ReadFile(c.Args.Source)
+	if err != nil {
+		return err
+	}
+	dst, err := os.ReadFile(c.Args.Dest)
+	if err != nil {
+		return err
+	}
+
+	report, err := tv4p.CompareIDs(src, dst)
+	if err != nil {
+		return err
+	}
+
+	if c.Format == "json" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stdout.Write(append(out, '\n')); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("compared: %d\n", report.Compared)
+		printIDChanges("changed", report.Changed)
+		printIDChanges("unmatched", report.Unmatched)
+	}
+
+	if !report.Stable() {
+		return fmt.Errorf("ids not stable: %d changed, %d unmatched", len(report.Changed), len(report.Unmatched))
+	}
+
+	return nil
+}
+
+// printIDChanges prints a titled list of ID changes (0 means absent).
+func printIDChanges(title string, changes []tv4p.IDChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	fmt.Printf("%s: %d\n", title, len(changes))
+	for _, ch := range changes {
+		fmt.Printf("  %s %s: 0x%X -> 0x%X\n", ch.Kind, ch.Name, ch.Before, ch.After)
+	}
+}
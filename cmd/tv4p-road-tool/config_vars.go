@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// configVarEnvPrefix is the environment fallback of --config-var: ${KEY} reads TV4P_VAR_KEY.
+const configVarEnvPrefix = "TV4P_VAR_"
+
+// configVarRef matches a ${KEY} reference in a config file.
+var configVarRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// configVarKey matches a valid --config-var key.
+var configVarKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// configVars substitutes ${KEY} references in the raw config text before parsing.
+type configVars struct {
+	vars       map[string]string           // --config-var values
+	allowUndef bool                        // leave undefined references literal
+	lookupEnv  func(string) (string, bool) // env fallback (os.LookupEnv)
+}
+
+// parseConfigVars parses repeatable --config-var KEY=VALUE flags (a later KEY wins).
+func parseConfigVars(list []string, allowUndef bool) (*configVars, error) {
+	cv := &configVars{vars: map[string]string{}, allowUndef: allowUndef, lookupEnv: os.LookupEnv}
+	for _, kv := range list {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !configVarKey.MatchString(key) {
+			return nil, fmt.Errorf("--config-var %q: want KEY=VALUE with KEY of letters, digits and _", kv)
+		}
+		cv.vars[key] = value
+	}
+
+	return cv, nil
+}
+
+// expand replaces every ${KEY} of raw with the --config-var value, else TV4P_VAR_KEY.
+// Undefined keys are an error (all of them are reported) unless allowUndef is set.
+// The substitution is textual: values are inserted as is, before YAML/JSON parsing.
+func (cv *configVars) expand(raw []byte) ([]byte, error) {
+	if cv == nil {
+		return raw, nil
+	}
+
+	var errs []error
+	reported := map[string]bool{}
+	out := configVarRef.ReplaceAllFunc(raw, func(ref []byte) []byte {
+		key := string(ref[2 : len(ref)-1])
+		if v, ok := cv.vars[key]; ok {
+			return []byte(v)
+		}
+		if v, ok := cv.lookupEnv(configVarEnvPrefix + key); ok {
+			return []byte(v)
+		}
+		if !cv.allowUndef && !reported[key] {
+			reported[key] = true
+			errs = append(errs, fmt.Errorf("undefined config variable ${%s} (pass --config-var %s=VALUE or set %s%s)", key, key, configVarEnvPrefix, key))
+		}
+		return ref
+	})
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
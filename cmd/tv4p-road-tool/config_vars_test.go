@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigVars(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "roads.yaml")
+	raw := `road_types:
+  - name: ${PREFIX}asf1
+    starting_parts:
+      - name: ${PREFIX}asf1_12
+        object_file: ${ROADS}\asf1_12.p3d
+crossroad_types:
+  - name: kr_t_${PREFIX}asf1_${PREFIX}asf1
+    model: ${ROOT}${ROADS}\kr_t_asf1_asf1.p3d
+    connections: {A: "${PREFIX}asf1", B: "${PREFIX}asf1", C: "${PREFIX}asf1"}
+`
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{"TV4P_VAR_ROOT": `P:\`, "TV4P_VAR_PREFIX": "ignored_"}
+	lookup := func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+
+	vars, err := parseConfigVars([]string{"PREFIX=old_", "ROADS=dz\\sakhal\\roads", "PREFIX=sk_"}, false)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	vars.lookupEnv = lookup
+
+	cfg, err := readConfig(path, false, vars)
+	if err != nil {
+		t.Fatalf("readConfig: %v", err)
+	}
+	rt := cfg.Types[0]
+	if rt.Name != "sk_asf1" || rt.StraightParts[0].Name != "sk_asf1_12" {
+		t.Fatalf("names=%q/%q want sk_ prefix from the last --config-var", rt.Name, rt.StraightParts[0].Name)
+	}
+	if got := rt.StraightParts[0].Path; got != `dz\sakhal\roads\asf1_12.p3d` {
+		t.Fatalf("part path=%q", got)
+	}
+	cr := cfg.CrossroadTypes[0]
+	if cr.Model != `P:\dz\sakhal\roads\kr_t_asf1_asf1.p3d` || cr.Connections.C != "sk_asf1" || cr.Name != "kr_t_sk_asf1_sk_asf1" {
+		t.Fatalf("crossroad=%+v", cr)
+	}
+
+	// ROOT comes only from the environment: without it the reference is undefined.
+	delete(env, "TV4P_VAR_ROOT")
+	if _, err := readConfig(path, false, vars); err == nil || !strings.Contains(err.Error(), "${ROOT}") {
+		t.Fatalf("err=%v want undefined ${ROOT}", err)
+	}
+
+	lenient, err := parseConfigVars([]string{"PREFIX=sk_", `ROADS=dz\roads`}, true)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	lenient.lookupEnv = lookup
+	cfg, err = readConfig(path, false, lenient)
+	if err != nil {
+		t.Fatalf("readConfig --allow-undef: %v", err)
+	}
+	if got := cfg.CrossroadTypes[0].Model; got != `${ROOT}dz\roads\kr_t_asf1_asf1.p3d` {
+		t.Fatalf("model=%q want literal ${ROOT}", got)
+	}
+
+	// No vars: the text is read as is.
+	if cfg, err = readConfig(path, false, nil); err != nil || cfg.Types[0].Name != "${PREFIX}asf1" {
+		t.Fatalf("nil vars: name=%q err=%v", cfg.Types[0].Name, err)
+	}
+}
+
+func TestParseConfigVars(t *testing.T) {
+	t.Parallel()
+
+	for _, kv := range []string{"NOEQ", "=v", "1KEY=v", "K-EY=v"} {
+		if _, err := parseConfigVars([]string{kv}, false); err == nil {
+			t.Fatalf("%q: want error", kv)
+		}
+	}
+
+	cv, err := parseConfigVars([]string{"KEY=a=b", "EMPTY="}, false)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cv.vars["KEY"] != "a=b" || cv.vars["EMPTY"] != "" {
+		t.Fatalf("vars=%v", cv.vars)
+	}
+}
+
+func TestReadConfigVarsInclude(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"roads.yaml": "road_types: !include types.yaml\n",
+		"types.yaml": "- name: ${PREFIX}asf1\n  starting_parts:\n    - name: ${PREFIX}asf1_12\n      object_file: ${ROADS}\\asf1_12.p3d\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "roads.yaml")
+
+	vars, err := parseConfigVars([]string{"PREFIX=sk_", `ROADS=dz\roads`}, false)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	vars.lookupEnv = func(string) (string, bool) { return "", false }
+
+	cfg, err := readConfig(path, true, vars)
+	if err != nil {
+		t.Fatalf("readConfig: %v", err)
+	}
+	rt := cfg.Types[0]
+	if rt.Name != "sk_asf1" || rt.StraightParts[0].Path != `dz\roads\asf1_12.p3d` {
+		t.Fatalf("included type=%+v want ${KEY} substituted", rt)
+	}
+
+	strict, err := parseConfigVars([]string{"PREFIX=sk_"}, false)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	strict.lookupEnv = vars.lookupEnv
+	if _, err := readConfig(path, true, strict); err == nil || !strings.Contains(err.Error(), "types.yaml") || !strings.Contains(err.Error(), "${ROADS}") {
+		t.Fatalf("err=%v want undefined ${ROADS} in types.yaml", err)
+	}
+}
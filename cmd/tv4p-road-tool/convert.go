@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type convertCmd struct {
+	Args struct {
+		Input  string `positional-arg-name:"CONFIG" required:"true" description:"Input config file (yaml/json)"`
+		Output string `positional-arg-name:"OUT" description:"Output config file (default: stdout)"`
+	} `positional-args:"true"`
+
+	Format      string `short:"f" long:"format" choice:"yaml" choice:"json" default:"yaml" description:"Output format"`
+	Prefix      string `long:"prefix" description:"Prepend to road type names (references and crossroad names follow)"`
+	Suffix      string `long:"suffix" description:"Append to road type names (references and crossroad names follow)"`
+	RenameParts bool   `long:"rename-parts" description:"With --prefix/--suffix, also rename parts named after their road type"`
+	DedupeParts bool   `long:"dedupe-parts" description:"Remove parts with the same path (case/slash-insensitive) within each part list, keeping the first"`
+
+	Chmod string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
+}
+
+// Execute rewrites a config in the given format, applying the naming transforms.
+func (c *convertCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := readConfig(c.Args.Input, false, nil)
+	if err != nil {
+		return err
+	}
+
+	out, err := convertConfig(cfg, c.Format, namespaceOptions{Prefix: c.Prefix, Suffix: c.Suffix, Parts: c.RenameParts}, c.DedupeParts)
+	if err != nil {
+		return err
+	}
+
+	if c.Args.Output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	return perm.writeFile(c.Args.Output, out)
+}
+
+// convertConfig namespaces the road types of cfg (see namespaceRoadTypes),
+// optionally drops duplicate parts and encodes it.
+func convertConfig(cfg tv4p.RoadConfig, format string, ns namespaceOptions, dedupe bool) ([]byte, error) {
+	namespaceRoadTypes(&cfg, ns)
+	if dedupe {
+		logDedupe(dedupeParts(&cfg))
+	}
+
+	return encodeConfig(cfg, format)
+}
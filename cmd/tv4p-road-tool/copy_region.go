@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type copyRegionCmd struct {
+	Args struct {
+		Source string `positional-arg-name:"SRC" required:"true" description:"Source tv4p file (Road Tool config to copy)"`
+		Dest   string `positional-arg-name:"DST" required:"true" description:"Destination tv4p file"`
+		Output string `positional-arg-name:"OUT" description:"Output tv4p file (default: overwrite DST)"`
+	} `positional-args:"true"`
+
+	Chmod string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
+}
+
+// Execute copies the whole Road Tool region from SRC into DST.
+func (c *copyRegionCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.ReadFile(c.Args.Source)
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.ReadFile(c.Args.Dest)
+	if err != nil {
+		return err
+	}
+
+	out, err := tv4p.CopyRoadToolRegion(src, dst)
+	if err != nil {
+		return err
+	}
+
+	outPath := c.Args.Output
+	if outPath == "" {
+		outPath = c.Args.Dest
+	}
+
+	if err := perm.writeFile(outPath, out); err != nil {
+		return err
+	}
+
+	cliLog.Infof("copied Road Tool region %s -> %s", c.Args.Source, outPath)
+	cliLog.Infof("size delta: %+d bytes", len(out)-len(dst))
+
+	return nil
+}
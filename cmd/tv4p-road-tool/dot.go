@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+// encodeDOT encodes crossroad connectivity as an undirected Graphviz DOT graph.
+//
+// Nodes are road types; connection names that are not road types are drawn dashed.
+// Each crossroad adds edges from its through road (A/B) to its branch roads
+// (C, plus D for kr_x_ shapes), labeled with the crossroad name and shape.
+// A crossroad without branch roads links A and B directly.
+func encodeDOT(cfg tv4p.RoadConfig) []byte {
+	var b bytes.Buffer
+	b.WriteString("graph tv4p {\n")
+	b.WriteString("  node [shape=box];\n")
+
+	known := map[string]bool{}
+	for _, rt := range cfg.Types {
+		known[rt.Name] = true
+		fmt.Fprintf(&b, "  %s;\n", dotID(rt.Name))
+	}
+
+	unknown := map[string]bool{}
+	for _, cr := range cfg.CrossroadTypes {
+		shape := "T"
+		if strings.HasPrefix(cr.Name, "kr_x_") {
+			shape = "X"
+		}
+
+		c := cr.Connections
+		through := uniqueNames(c.A, c.B)
+		branches := uniqueNames(c.C)
+		if shape == "X" {
+			branches = uniqueNames(c.C, c.D)
+		}
+		if len(branches) == 0 && len(through) == 2 {
+			through, branches = through[:1], through[1:]
+		}
+
+		for _, name := range append(append([]string{}, through...), branches...) {
+			if !known[name] && !unknown[name] {
+				unknown[name] = true
+				fmt.Fprintf(&b, "  %s [style=dashed];\n", dotID(name))
+			}
+		}
+
+		label := dotID(cr.Name + " (" + shape + ")")
+		for _, from := range through {
+			for _, to := range branches {
+				fmt.Fprintf(&b, "  %s -- %s [label=%s];\n", dotID(from), dotID(to), label)
+			}
+		}
+	}
+
+	b.WriteString("}\n")
+
+	return b.Bytes()
+}
+
+// uniqueNames returns the non-empty names in order, without duplicates.
+func uniqueNames(names ...string) []string {
+	var out []string
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n != "" && !slices.Contains(out, n) {
+			out = append(out, n)
+		}
+	}
+
+	return out
+}
+
+// dotID quotes a DOT identifier, escaping backslashes and double quotes.
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
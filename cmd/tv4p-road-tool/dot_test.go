@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestEncodeDOT(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{{Name: "asf1"}, {Name: "city"}},
+		CrossroadTypes: []tv4p.CrossroadType{
+			// T shape: D is ignored even if set.
+			{Name: "kr_t_asf1_city", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city", D: "asf1"}},
+			{Name: "kr_x_city_city", Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city", D: "city"}},
+			{Name: "kr_x_asf1_gr", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city", D: `gr "1"`}},
+			{Name: "odd", Connections: tv4p.CrossroadConnections{A: "asf1", B: "city"}},
+			{Name: "kr_t_empty"},
+		},
+	}
+
+	want := `graph tv4p {
+  node [shape=box];
+  "asf1";
+  "city";
+  "asf1" -- "city" [label="kr_t_asf1_city (T)"];
+  "city" -- "city" [label="kr_x_city_city (X)"];
+  "gr \"1\"" [style=dashed];
+  "asf1" -- "city" [label="kr_x_asf1_gr (X)"];
+  "asf1" -- "gr \"1\"" [label="kr_x_asf1_gr (X)"];
+  "asf1" -- "city" [label="odd (T)"];
+}
+`
+	if got := string(encodeDOT(cfg)); got != want {
+		t.Fatalf("dot=\n%s\nwant\n%s", got, want)
+	}
+}
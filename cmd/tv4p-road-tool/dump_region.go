@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type dumpRegionCmd struct {
+	Args struct {
+		Input  string `positional-arg-name:"FILE" required:"true" description:"Input tv4p file"`
+		Output string `positional-arg-name:"OUT" required:"true" description:"Output file for the raw region bytes"`
+	} `positional-args:"true"`
+
+	WithContext int    `long:"with-context" value-name:"N" description:"Also write up to N bytes before and after the region"`
+	Chmod       string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
+}
+
+// Execute writes the raw Road Tool region bytes of FILE to OUT.
+func (c *dumpRegionCmd) Execute(_ []string) error {
+	if c.WithContext < 0 {
+		return errors.New("--with-context must not be negative")
+	}
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(c.Args.Input)
+	if err != nil {
+		return err
+	}
+
+	out, from, start, end, err := dumpRegion(data, c.WithContext)
+	if err != nil {
+		return withFileHead(err, inspectInput(data))
+	}
+
+	if err := perm.writeFile(c.Args.Output, out); err != nil {
+		return err
+	}
+	cliLog.Infof("dumped Road Tool region 0x%X-0x%X of %s -> %s (%d bytes from file offset 0x%X, region at +0x%X)",
+		start, end, c.Args.Input, c.Args.Output, len(out), from, start-from)
+
+	return nil
+}
+
+// dumpRegion returns the Road Tool region of data with up to context bytes on each
+// side (clamped to the file), the file offset of the first returned byte and the
+// region span.
+func dumpRegion(data []byte, context int) (out []byte, from, start, end int, err error) {
+	start, end, err = tv4p.RoadToolRegion(data)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	from = max(start-context, 0)
+	to := min(end+context, len(data))
+
+	return data[from:to], from, start, end, nil
+}
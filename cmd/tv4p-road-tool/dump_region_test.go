@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestDumpRegion(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{Types: []tv4p.RoadType{
+		{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}}},
+	}}
+	data := testTV4P(t, cfg)
+	start, end, err := tv4p.RoadToolRegion(data)
+	if err != nil {
+		t.Fatalf("RoadToolRegion: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		context  int
+		from, to int
+	}{
+		{name: "region", context: 0, from: start, to: end},
+		{name: "context", context: 2, from: start - 2, to: min(end+2, len(data))},
+		{name: "clamped", context: len(data), from: 0, to: len(data)},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, from, gotStart, gotEnd, err := dumpRegion(data, tt.context)
+			if err != nil {
+				t.Fatalf("dumpRegion: %v", err)
+			}
+			if from != tt.from || gotStart != start || gotEnd != end {
+				t.Fatalf("from=%d span=%d-%d want %d, %d-%d", from, gotStart, gotEnd, tt.from, start, end)
+			}
+			if !bytes.Equal(out, data[tt.from:tt.to]) {
+				t.Fatalf("bytes=%d want data[%d:%d]", len(out), tt.from, tt.to)
+			}
+			if out[start-from] != 0x88 {
+				t.Fatalf("region tag=0x%02X want 0x88", out[start-from])
+			}
+		})
+	}
+
+	if _, _, _, _, err := dumpRegion([]byte("not a tv4p"), 0); err == nil {
+		t.Fatalf("dumpRegion on junk: want error")
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type exampleCmd struct {
+	Args struct {
+		Output string `positional-arg-name:"OUT" description:"Output config file (default: stdout)"`
+	} `positional-args:"true"`
+
+	Format string `short:"f" long:"format" choice:"yaml" choice:"json" default:"yaml" description:"Output format (yaml is annotated with comments)"`
+	Chmod  string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
+}
+
+// Execute writes a small example config built from the config types.
+func (c *exampleCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
+	out, err := encodeConfig(exampleConfig(), c.Format)
+	if err != nil {
+		return err
+	}
+	if c.Format == "yaml" {
+		out = annotateYAML(out)
+	}
+
+	if c.Args.Output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	return perm.writeFile(c.Args.Output, out)
+}
+
+// exampleConfig returns a minimal complete config: one road type with straight,
+// corner and terminator parts and one T crossroad that is its default.
+// Paths follow what generate writes for the vanilla DayZ road parts.
+func exampleConfig() tv4p.RoadConfig {
+	const parts = `dz\structures\roads\parts\`
+	normal, key, _ := roadparts.Palette("asf1")
+
+	part := func(name string, kind roadparts.Kind) tv4p.RoadPart {
+		return tv4p.RoadPart{Name: name, Path: parts + name + ".p3d", Type: partTypeFromKind(kind)}
+	}
+
+	return tv4p.RoadConfig{
+		Types: []tv4p.RoadType{{
+			Name: "asf1",
+			Type: 0x12,
+			StraightParts: []tv4p.RoadPart{
+				part("asf1_12", roadparts.Straight),
+				part("asf1_25", roadparts.Straight),
+			},
+			CornerParts: []tv4p.RoadPart{
+				part("asf1_7 100", roadparts.Corner),
+			},
+			TerminatorPart: []tv4p.RoadPart{
+				part("asf1_6konec", roadparts.Terminator),
+			},
+			NormalColor:  normal,
+			KeyColor:     key,
+			NormalCustom: true,
+			KeyCustom:    true,
+		}},
+		CrossroadTypes: []tv4p.CrossroadType{{
+			Name:        "kr_t_asf1_asf1",
+			Model:       `P:\` + parts + "kr_t_asf1_asf1.p3d",
+			Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf1"},
+			Default:     "asf1",
+			Color:       roadparts.DarkenColor(normal, 0.75),
+			ColorCustom: true,
+		}},
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/invopop/yaml"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestExampleConfig(t *testing.T) {
+	t.Parallel()
+
+	want := exampleConfig()
+	if err := tv4p.ValidateCrossroadRefs(want.CrossroadTypes, want.Types); err != nil {
+		t.Fatalf("refs: %v", err)
+	}
+	if err := tv4p.ValidateCrossroads(want.CrossroadTypes, want.Types); err != nil {
+		t.Fatalf("crossroads: %v", err)
+	}
+	if err := validatePartNames(want.Types); err != nil {
+		t.Fatalf("part names: %v", err)
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		out, err := encodeConfig(want, format)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", format, err)
+		}
+		if format == "yaml" {
+			out = annotateYAML(out)
+		}
+
+		var got tv4p.RoadConfig
+		if err := yaml.Unmarshal(out, &got); err != nil {
+			t.Fatalf("%s: decode: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: decoded=%+v want %+v", format, got, want)
+		}
+	}
+
+	// The example patches into a file with road types and crossroad lists.
+	data := testTV4P(t, tv4p.RoadConfig{Types: []tv4p.RoadType{{Name: "city"}}})
+	out, err := tv4p.PatchRoadTool(data, want, tv4p.ScopeAll)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	cfg, err := tv4p.ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("re-parse: %v", err)
+	}
+	if len(cfg.Types) != 1 || cfg.Types[0].Name != "asf1" || len(cfg.CrossroadTypes) != 1 {
+		t.Fatalf("patched config=%+v want asf1 and one crossroad", cfg)
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
@@ -13,39 +15,206 @@ type extractCmd struct {
 		Output string `positional-arg-name:"OUT" description:"Output config file (default: stdout)"`
 	} `positional-args:"true"`
 
-	Format   string `short:"f" long:"format" choice:"yaml" choice:"json" default:"yaml" description:"Output format"`
+	Format   string `short:"f" long:"format" choice:"yaml" choice:"json" choice:"prom" choice:"dot" default:"yaml" description:"Output format (prom: Prometheus metrics, dot: Graphviz crossroad graph)"`
 	Scope    string `short:"s" long:"scope" choice:"all" choice:"roads" choice:"crossroads" default:"all" description:"What to extract: roads, crossroads, or all"`
 	Portable bool   `short:"p" long:"portable" description:"Export portable config: no IDs/types, no tv4p raw fields"`
+
+	CanonicalConnections bool   `long:"canonical-connections" description:"Order symmetric crossroad connections (A<=B, X: C<=D) for stable diffs"`
+	Annotated            bool   `long:"annotated" description:"Add human guidance comments to YAML output (yaml format only)"`
+	NearOffset           int    `long:"near-offset" description:"Prefer the Road Tool block closest to this byte offset"`
+	Nested               bool   `long:"nested" description:"Resolve one level of parent list nesting around the Road Tool block"`
+	EmitRaw              bool   `long:"emit-raw" description:"Also dump full raw road type entries (tv4p_raw) for lossless round-trip"`
+	StripIDs             bool   `long:"strip-ids" description:"Zero all road type/part/crossroad IDs so patch allocates new ones"`
+	IncludeIDs           bool   `long:"include-ids" description:"Always emit id fields, even when zero"`
+	RepairCounts         bool   `long:"repair-counts" description:"Fix crossroad list (0x89/0x8A) header counts that disagree with their entries"`
+	GroupByWorld         bool   `long:"group-by-world" description:"Tag road types with the world detected from their name (world: sakhal/enoch)"`
+	Baseline             string `long:"baseline" value-name:"FILE" description:"Emit only road types/crossroads added or changed compared to this config (override for patch --append)"`
+	RawConnections       bool   `long:"raw-connections" description:"Emit crossroad A/B/C/D as raw road type indices (connection_indices) instead of names"`
+	WithChecksum         bool   `long:"with-checksum" description:"With --portable, add a checksum of the content (check it with verify-config)"`
+	LowerModelPaths      bool   `long:"lower-model-paths" description:"Lowercase crossroad model paths for stable diffs (drive letter kept; TB ignores path case)"`
+	OnlyWithCrossroads   bool   `long:"only-roads-with-crossroads" description:"Keep only road types used by some crossroad's connections (QA of crossroad coverage)"`
+	GameRoot             string `short:"g" long:"game-root" value-name:"DIR" description:"Check part models under DIR and mark ODOL ones needs_mlod (the flag is not stored in tv4p)"`
+	EmitOffsets          bool   `long:"emit-offsets" description:"Add the file offsets of road type, part and crossroad entries (tv4p_offsets, ignored on patch)"`
+	Hash                 bool   `long:"hash" description:"Print a fingerprint (xxhash) of the semantic config instead of the config: IDs, offsets and order ignored"`
+
+	ModelExts []string `long:"p3d-ext" value-name:"EXT" default:".p3d" description:"Model file extension that marks part paths (repeatable, case-insensitive, e.g. .p3de)"`
+
+	YAMLAdvanced bool     `long:"yaml-advanced" description:"Pre-process the --baseline config with yaml.v3: anchors, merge keys, !include, x- keys"`
+	AllowUndef   bool     `long:"allow-undef" description:"Leave undefined ${KEY} --baseline config references literal instead of failing"`
+	ConfigVars   []string `long:"config-var" value-name:"KEY=VALUE" description:"Replace ${KEY} in the --baseline config text with VALUE (repeatable; fallback: env TV4P_VAR_KEY)"`
+
+	Gzip  bool   `long:"gzip" description:"Write the config gzip-compressed (read back transparently)"`
+	Chmod string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
 }
 
 // Execute extracts the road types config from the input tv4p file.
 func (c *extractCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
 	format := strings.ToLower(c.Format)
 	if format == "" {
 		format = "yaml"
 	}
+	if c.Annotated && format != "yaml" {
+		return errors.New("--annotated requires --format yaml")
+	}
+	if (c.EmitRaw || c.EmitOffsets) && c.Portable {
+		return errors.New("--emit-raw/--emit-offsets cannot be combined with --portable")
+	}
+	if c.StripIDs && c.IncludeIDs {
+		return errors.New("--strip-ids and --include-ids are mutually exclusive")
+	}
+	if (c.StripIDs || c.IncludeIDs) && c.Portable {
+		return errors.New("--strip-ids/--include-ids cannot be combined with --portable (it has no IDs)")
+	}
+	if c.RawConnections && (c.Portable || c.CanonicalConnections) {
+		return errors.New("--raw-connections cannot be combined with --portable or --canonical-connections")
+	}
+	if c.WithChecksum && (!c.Portable || (format != "yaml" && format != "json")) {
+		return errors.New("--with-checksum requires --portable with yaml or json format")
+	}
 
-	data, err := os.ReadFile(c.Args.Input)
+	if c.OnlyWithCrossroads && c.RawConnections {
+		return errors.New("--only-roads-with-crossroads cannot be combined with --raw-connections (indices would point past the kept road types)")
+	}
+	if c.Hash && (c.Annotated || c.WithChecksum || c.Gzip || (format != "yaml" && format != "json")) {
+		return errors.New("--hash prints only the fingerprint: it cannot be combined with --annotated, --with-checksum, --gzip or --format prom/dot")
+	}
+	if c.Baseline != "" && c.EmitRaw {
+		return errors.New("--baseline cannot be combined with --emit-raw")
+	}
+	if c.Baseline != "" && c.RawConnections {
+		return errors.New("--baseline cannot be combined with --raw-connections (indices would point past the kept road types)")
+	}
+	vars, err := parseConfigVars(c.ConfigVars, c.AllowUndef)
 	if err != nil {
 		return err
 	}
 
-	cfg, err := tv4p.ParseRoadToolConfig(data)
+	exts, err := parseModelExts(c.ModelExts)
 	if err != nil {
 		return err
 	}
 
-	scope := tv4p.Scope(c.Scope)
-	var outCfg any
-	if c.Portable {
-		outCfg = filterPortableByScope(tv4p.ToPortableConfig(cfg), scope)
-	} else {
-		outCfg = filterConfigByScope(cfg, scope)
+	data, err := os.ReadFile(c.Args.Input)
+	if err != nil {
+		return err
+	}
+
+	info := inspectInput(data)
+	loc := tv4p.LocateOptions{NearOffset: c.NearOffset, Nested: c.Nested, ModelExts: exts}
+	if c.RepairCounts {
+		if data, err = repairListCounts(data, loc); err != nil {
+			return withFileHead(err, info)
+		}
 	}
 
-	out, err := encodeConfig(outCfg, format)
+	cfg, err := tv4p.ParseRoadToolConfigWith(data, loc)
 	if err != nil {
-		return err
+		return withCountHint(withFileHead(err, info))
+	}
+	// Offsets and raw entries are matched by position: attach them before anything
+	// filters or reorders.
+	if c.EmitOffsets {
+		if err := tv4p.AttachOffsets(&cfg, data, loc); err != nil {
+			return err
+		}
+	}
+	if c.EmitRaw {
+		block, err := tv4p.ParseRoadTypesWith(data, loc)
+		if err != nil {
+			return err
+		}
+		if err := tv4p.AttachRoadTypeRaw(&cfg, block); err != nil {
+			return err
+		}
+	}
+	if c.OnlyWithCrossroads {
+		onlyRoadsWithCrossroads(&cfg)
+	}
+	if c.GameRoot != "" {
+		marked, missing := markNeedsMLOD(&cfg, c.GameRoot)
+		cliLog.Infof("needs_mlod: %d ODOL part(s) marked", marked)
+		if missing > 0 {
+			cliLog.Warnf("needs_mlod: %d part model(s) not readable under %s", missing, c.GameRoot)
+		}
+	}
+
+	if c.Baseline != "" {
+		base, err := readConfig(c.Baseline, c.YAMLAdvanced, vars)
+		if err != nil {
+			return err
+		}
+		cfg = baselineDiff(cfg, base)
+		cliLog.Infof("changed vs baseline: %d road type(s), %d crossroad(s)", len(cfg.Types), len(cfg.CrossroadTypes))
+	}
+
+	if c.CanonicalConnections {
+		canonicalizeConnections(cfg.CrossroadTypes)
+	}
+	if c.RawConnections {
+		if err := tv4p.UseRawConnections(&cfg); err != nil {
+			return err
+		}
+	}
+	if c.StripIDs {
+		stripIDs(&cfg)
+	}
+	if c.LowerModelPaths {
+		lowerModelPaths(cfg.CrossroadTypes)
+	}
+	if c.GroupByWorld {
+		tagWorlds(&cfg)
+	}
+
+	scope := tv4p.Scope(c.Scope)
+	var out []byte
+	switch {
+	case c.Hash:
+		sum, err := configFingerprint(cfg, scope)
+		if err != nil {
+			return err
+		}
+		out = []byte(sum + "\n")
+	case format == "prom":
+		out = encodeMetrics(cfg, filepath.Base(c.Args.Input), scope)
+	case format == "dot":
+		out = encodeDOT(cfg)
+	default:
+		var outCfg any
+		if c.Portable {
+			pc := tv4p.ToPortableConfig(cfg)
+			if c.WithChecksum {
+				if pc, err = withPortableChecksum(pc, scope); err != nil {
+					return err
+				}
+			}
+			outCfg = filterPortableByScope(pc, scope)
+		} else {
+			outCfg = filterConfigByScope(cfg, scope)
+			if c.IncludeIDs {
+				if outCfg, err = withZeroIDs(outCfg); err != nil {
+					return err
+				}
+			}
+		}
+
+		out, err = encodeConfig(outCfg, format)
+		if err != nil {
+			return err
+		}
+		if c.Annotated {
+			out = annotateYAML(out)
+		}
+	}
+
+	if c.Gzip {
+		if out, err = gzipConfig(out); err != nil {
+			return err
+		}
 	}
 
 	if c.Args.Output == "" {
@@ -53,5 +222,17 @@ func (c *extractCmd) Execute(_ []string) error {
 		return err
 	}
 
-	return os.WriteFile(c.Args.Output, out, 0o600)
+	return perm.writeFile(c.Args.Output, out)
+}
+
+// configFingerprint returns tv4p.Fingerprint of the lists inside scope (extract --hash).
+func configFingerprint(cfg tv4p.RoadConfig, scope tv4p.Scope) (string, error) {
+	switch scope {
+	case tv4p.ScopeRoads:
+		cfg.CrossroadTypes = nil
+	case tv4p.ScopeCrossroad:
+		cfg.Types = nil
+	}
+
+	return tv4p.Fingerprint(cfg)
 }
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+// testTV4P builds a minimal tv4p payload holding the given road types: the
+// 0x18/0x3E offset fields and empty 0x88/0x89/0x8A lists, patched with cfg.
+func testTV4P(t *testing.T, cfg tv4p.RoadConfig) []byte {
+	t.Helper()
+
+	empty := []byte{
+		0x18, 0x00, 0x0D, 0x00, 0x10, 0x00, 0x00,
+		0x3E, 0x00, 0x0D, 0x00, 0x20, 0x00, 0x00,
+		0x88, 0x00, 0x0C, 4, 0, 0, 0, 0, 0, 0, 0,
+		0x89, 0x00, 0x0C, 4, 0, 0, 0, 0, 0, 0, 0,
+		0x3F, 0x00, 0x0D, 0x00, 0x30, 0x00, 0x00,
+		0x19, 0x00, 0x20, 0x00, 0x00, 0x00,
+		0x8A, 0x00, 0x0C, 4, 0, 0, 0, 0, 0, 0, 0,
+		0xFE, 0xFE, 0xFE, 0xFE,
+	}
+	data, err := tv4p.PatchRoadTool(empty, cfg, tv4p.ScopeRoads)
+	if err != nil {
+		t.Fatalf("build tv4p: %v", err)
+	}
+
+	return data
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/woozymasta/tv4p-road-tool/internal/p3d"
 	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
@@ -22,61 +27,298 @@ type generateCmd struct {
 		Output string `positional-arg-name:"OUT" description:"Output config file (default: stdout)"`
 	} `positional-args:"true"`
 
-	Paths   []string `short:"p" long:"path" default:"DZ/structures/roads/Parts" default:"DZ/structures_bliss/roads/Parts" default:"DZ/structures_sakhal/roads/parts" description:"Search path (repeatable)"`
-	NoOgol  bool     `long:"no-odol-check" description:"Disable ODOL/MLOD header check"`
-	Verbose bool     `short:"v" long:"verbose" description:"Verbose per-file output"`
+	Paths     []string `short:"p" long:"path" description:"Search path (repeatable; default: DayZ roads parts dirs)"`
+	PathsFile string   `long:"paths-file" description:"File with search paths, one per line (used when --path is not given)"`
+	ModelExts []string `long:"p3d-ext" value-name:"EXT" default:".p3d" description:"Model file extension to scan (repeatable, case-insensitive, e.g. .p3de)"`
+	NoOgol    bool     `long:"no-odol-check" description:"Disable ODOL/MLOD header check"`
+	InclODOL  bool     `long:"include-odol" description:"Keep ODOL road parts in the config, marked needs_mlod: true"`
+	RejectCR  bool     `long:"reject-non-mlod-crossroads" description:"Drop and list crossroads that are not MLOD, even with --no-odol-check"`
+	Dedupe    bool     `long:"dedupe-parts" description:"Remove parts with the same path (case/slash-insensitive) within each part list, keeping the first"`
+	SynthTerm bool     `long:"synth-terminators" description:"Add a terminator made from a straight part (<type>_12 preferred) to road types without one"`
+
+	CanonicalConnections bool `long:"canonical-connections" description:"Order symmetric crossroad connections (A<=B, X: C<=D) for stable diffs"`
+	Annotated            bool `long:"annotated" description:"Add human guidance comments to YAML output (yaml format only)"`
+	GroupByWorld         bool `long:"group-by-world" description:"Tag road types with the world detected from their name (world: sakhal/enoch)"`
+
+	CrossroadOrder   string  `long:"crossroad-order" choice:"name" choice:"by-road" default:"name" description:"Crossroad output order: name, or by-road (after their primary road type's position: A, else the first known of B/C/D; then T before X, then name)"`
+	PreferShape      string  `long:"prefer-shape" choice:"t" choice:"x" default:"t" description:"Crossroad shape preferred for defaults: t (kr_t_*) or x (kr_x_*)"`
+	CrossroadWeights string  `long:"crossroad-weights" value-name:"A,B,C,D" default:"1,1,1,1" description:"Weights of the A/B/C/D road colors in the crossroad color mix"`
+	SkipUnresolvable bool    `long:"skip-unresolvable-crossroad-colors" description:"Leave crossroads without any known road type at the standard TB color instead of magenta"`
+	CompleteDefaults bool    `long:"require-complete-defaults" description:"Fail when a road type ends up without a default crossroad (reported either way)"`
+	ColorDistance    float64 `long:"color-distance" value-name:"N" default:"40" description:"Re-hash auto colors closer than N (RGB distance) to rule colors or each other (0 disables)"`
+	PaletteMode      string  `long:"palette-mode" choice:"clamp" choice:"hsv" default:"clamp" description:"Auto color generator for unknown road types: clamp (muted) or hsv (bright, distinct hues)"`
+	ColorMin         int     `long:"color-min" value-name:"N" default:"40" description:"Lowest channel value (0-255) of clamp palette colors"`
+	ColorMax         int     `long:"color-max" value-name:"N" default:"220" description:"Highest channel value (0-255) of clamp palette colors"`
+
+	Timeout  time.Duration `long:"timeout" value-name:"DURATION" description:"Abort the disk scan after DURATION (e.g. 30s, 5m; 0 = no limit)"`
+	Progress bool          `long:"progress" description:"Print a running file count (every 1000 files or second) and the total to stderr during the disk scan"`
+	Template string        `long:"template" value-name:"FILE" description:"YAML mapping road type names to normal_color, key_color and default_crossroad overrides"`
+	Report   string        `long:"report" value-name:"FILE" description:"Write scan counters and rejected files as JSON to FILE"`
+	Gzip     bool          `long:"gzip" description:"Write the config gzip-compressed (read back transparently)"`
+	Chmod    string        `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
 }
 
 // Execute generates the road types config from the disk.
 func (c *generateCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
 	format := strings.ToLower(c.Format)
 	if format == "" {
 		format = "yaml"
 	}
+	if c.Annotated && format != "yaml" {
+		return errors.New("--annotated requires --format yaml")
+	}
+
+	searchPaths, err := searchPathList(c.Paths, c.PathsFile, os.Getenv(searchPathsEnv))
+	if err != nil {
+		return err
+	}
+
+	weights, err := parseCrossroadWeights(c.CrossroadWeights)
+	if err != nil {
+		return err
+	}
 
-	paths := resolvePaths(c.GameRoot, c.Paths)
+	exts, err := parseModelExts(c.ModelExts)
+	if err != nil {
+		return err
+	}
+
+	bounds, err := parseColorBounds(c.ColorMin, c.ColorMax)
+	if err != nil {
+		return err
+	}
+
+	var tmpl generateTemplate
+	if c.Template != "" {
+		if tmpl, err = readTemplate(c.Template); err != nil {
+			return err
+		}
+	}
+
+	paths := resolvePaths(c.GameRoot, searchPaths)
 	if len(paths) == 0 {
 		return errors.New("no valid search paths")
 	}
 
-	cfg, err := generateConfig(paths, c.GameRoot, c.NoOgol, c.Verbose)
+	ctx := context.Background()
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var progress *scanProgress
+	if c.Progress {
+		progress = newScanProgress(os.Stderr)
+	}
+
+	cfg, report, err := generateConfig(ctx, paths, generateOptions{
+		GameRoot:      c.GameRoot,
+		ModelExts:     exts,
+		NoOdol:        c.NoOgol,
+		IncludeODOL:   c.InclODOL,
+		RejectODOLCR:  c.RejectCR,
+		SynthTerm:     c.SynthTerm,
+		SkipUnresolv:  c.SkipUnresolvable,
+		Palette:       roadparts.PaletteMode(c.PaletteMode),
+		ColorBounds:   bounds,
+		PreferShape:   tv4p.CrossroadShape(c.PreferShape),
+		ByRoadOrder:   c.CrossroadOrder == "by-road",
+		Weights:       weights,
+		ColorDistance: c.ColorDistance,
+		Template:      tmpl,
+		Progress:      progress,
+	})
 	if err != nil {
 		return err
 	}
 
 	scope := tv4p.Scope(c.Scope)
+	if scope.IncludesCrossroads() {
+		if err := checkCompleteDefaults(cfg, c.CompleteDefaults); err != nil {
+			return err
+		}
+	}
+
+	if c.Report != "" {
+		js, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := perm.writeFile(c.Report, append(js, '\n')); err != nil {
+			return err
+		}
+	}
+
+	if c.Dedupe {
+		logDedupe(dedupeParts(&cfg))
+	}
+	if c.CanonicalConnections {
+		canonicalizeConnections(cfg.CrossroadTypes)
+	}
+	if c.GroupByWorld {
+		tagWorlds(&cfg)
+	}
+
 	outCfg := filterConfigByScope(cfg, scope)
 	out, err := encodeConfig(outCfg, format)
 	if err != nil {
 		return err
 	}
+	if c.Annotated {
+		out = annotateYAML(out)
+	}
+
+	if c.Gzip {
+		if out, err = gzipConfig(out); err != nil {
+			return err
+		}
+	}
 
 	if c.Args.Output == "" {
 		_, err = os.Stdout.Write(out)
 		return err
 	}
 
-	return os.WriteFile(c.Args.Output, out, 0o600)
+	return perm.writeFile(c.Args.Output, out)
+}
+
+// searchPathsEnv supplies default search paths when neither --path nor --paths-file is given.
+const searchPathsEnv = "TV4P_SEARCH_PATHS"
+
+// defaultSearchPaths are the built-in DayZ road parts directories (relative to the game root).
+var defaultSearchPaths = []string{
+	"DZ/structures/roads/Parts",
+	"DZ/structures_bliss/roads/Parts",
+	"DZ/structures_sakhal/roads/parts",
+}
+
+// searchPathList picks the search paths by precedence:
+// explicit --path flags > --paths-file > TV4P_SEARCH_PATHS > built-in defaults.
+func searchPathList(flagPaths []string, pathsFile string, env string) ([]string, error) {
+	if len(flagPaths) > 0 {
+		return flagPaths, nil
+	}
+
+	if pathsFile != "" {
+		data, err := os.ReadFile(pathsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		var out []string
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			out = append(out, line)
+		}
+		if len(out) == 0 {
+			return nil, fmt.Errorf("no search paths in %s", pathsFile)
+		}
+
+		return out, nil
+	}
+
+	if paths := splitSearchPaths(env); len(paths) > 0 {
+		return paths, nil
+	}
+
+	return defaultSearchPaths, nil
+}
+
+// splitSearchPaths splits a path list on ';' and the OS list separator (':' on Unix).
+// Windows drive letters are safe there because the OS separator is ';'.
+func splitSearchPaths(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ';' || r == os.PathListSeparator
+	})
+
+	var out []string
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+
+	return out
+}
+
+// generateOptions controls how generateConfig builds the config.
+type generateOptions struct {
+	GameRoot      string                // game root for relative object paths
+	ModelExts     []string              // lowercase model extensions to scan (empty: tv4p.DefaultModelExts)
+	Palette       roadparts.PaletteMode // auto color generator
+	ColorBounds   roadparts.ColorBounds // clamp palette channel limits (zero value: 40..220)
+	PreferShape   tv4p.CrossroadShape   // shape preferred for crossroad defaults
+	ByRoadOrder   bool                  // order crossroads by their A/B road type (--crossroad-order by-road)
+	Weights       crossroadWeights      // A/B/C/D weights for crossroad colors (zero value: 1,1,1,1)
+	ColorDistance float64               // min RGB distance of hashed road type colors (0: plain hashing)
+	NoOdol        bool                  // skip the ODOL/MLOD header check
+	IncludeODOL   bool                  // keep ODOL road parts, marked needs_mlod
+	RejectODOLCR  bool                  // drop and list non-MLOD crossroads regardless of NoOdol
+	SynthTerm     bool                  // synthesize missing terminators from straight parts
+	SkipUnresolv  bool                  // keep the standard color for crossroads without known road types
+	Template      generateTemplate      // color/default overrides applied after the scan
+	Progress      *scanProgress         // periodic file count (nil: off)
+}
+
+// generateReport holds the generate scan counters (written by --report).
+type generateReport struct {
+	TotalFiles     int              `json:"total_files"`       // files seen in search paths
+	FilesP3D       int              `json:"files_p3d"`         // .p3d files
+	FilesMLOD      int              `json:"files_mlod"`        // MLOD models
+	FilesODOL      int              `json:"files_odol"`        // ODOL (binarized) models
+	NameRejects    int              `json:"name_rejects"`      // file names not parsed as road parts
+	KindRejects    int              `json:"kind_rejects"`      // unknown part kinds or crossroad names
+	CrossroadFiles int              `json:"crossroad_files"`   // crossroad models
+	Added          int              `json:"added"`             // road parts added
+	Types          int              `json:"types"`             // road types generated
+	SynthTerm      int              `json:"synth_terminators"` // terminators synthesized by --synth-terminators
+	Rejected       []generateReject `json:"rejected"`          // skipped .p3d files
+
+	CrossroadsNotMLOD []string `json:"crossroads_not_mlod,omitempty"` // crossroads dropped by --reject-non-mlod-crossroads
+}
+
+// generateReject is a .p3d file skipped by generate.
+type generateReject struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// reject logs and records a skipped file.
+func (r *generateReport) reject(path, reason string) {
+	cliLog.Debugf("skip: %s (%s)", path, reason)
+	r.Rejected = append(r.Rejected, generateReject{Path: path, Reason: reason})
 }
 
 // generateConfig generates the road types config from the disk.
-func generateConfig(paths []string, gameRoot string, noOgol bool, verbose bool) (tv4p.RoadConfig, error) {
+// The scan stops between files once ctx is done; the error then tells how many
+// files were processed. A single blocked file system call cannot be interrupted.
+func generateConfig(ctx context.Context, paths []string, opts generateOptions) (tv4p.RoadConfig, generateReport, error) {
 	types := map[string]*tv4p.RoadType{}
 	crossroads := map[string]*tv4p.CrossroadType{}
-	root := cleanAbs(gameRoot)
+	root := cleanAbs(opts.GameRoot)
+	exts := opts.ModelExts
+	if len(exts) == 0 {
+		exts = tv4p.DefaultModelExts
+	}
 
-	var (
-		totalFiles, filesP3D, filesMLOD, filesODOL            int
-		filesNameReject, filesKindReject, filesCrossroadAdded int
-		filesAdded                                            int
-	)
+	report := generateReport{Rejected: []generateReject{}}
 
 	for _, p := range paths {
 		err := filepath.WalkDir(p, func(path string, d os.DirEntry, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
 			if err != nil {
-				if verbose {
-					fmt.Fprintf(os.Stderr, "skip: %s (walk error)\n", path)
-				}
+				cliLog.Debugf("skip: %s (walk error)", path)
 				return nil
 			}
 
@@ -84,38 +326,46 @@ func generateConfig(paths []string, gameRoot string, noOgol bool, verbose bool)
 				return nil
 			}
 
-			totalFiles++
-			if strings.ToLower(filepath.Ext(d.Name())) != ".p3d" {
+			report.TotalFiles++
+			opts.Progress.tick()
+			if !slices.Contains(exts, strings.ToLower(filepath.Ext(d.Name()))) {
 				return nil
 			}
 
-			filesP3D++
-			if !noOgol {
-				ok, kind, err := p3d.IsMLOD(path)
+			report.FilesP3D++
+			needsMLOD := false
+			kind := ""
+			if !opts.NoOdol {
+				var ok bool
+				ok, kind, err = p3d.IsMLOD(path)
 				if err != nil {
-					if verbose {
-						fmt.Fprintf(os.Stderr, "skip: %s (header read error)\n", path)
-					}
+					report.reject(path, "header read error")
 					return nil
 				}
 
 				switch kind {
 				case "MLOD":
-					filesMLOD++
+					report.FilesMLOD++
 				case "ODOL":
-					filesODOL++
+					report.FilesODOL++
 				}
 
+				if !ok && kind == "ODOL" && opts.IncludeODOL {
+					// Keep the part for a complete structure, flagged for MLOD conversion.
+					needsMLOD = true
+					ok = true
+				}
 				if !ok {
-					if verbose {
-						switch kind {
-						case "ODOL":
-							fmt.Fprintf(os.Stderr, "skip: %s (ODOL)\n", path)
-						case "UNKNOWN":
-							fmt.Fprintf(os.Stderr, "skip: %s (unknown header)\n", path)
-						default:
-							fmt.Fprintf(os.Stderr, "skip: %s (not MLOD)\n", path)
-						}
+					if opts.RejectODOLCR && isCrossroadFile(path) {
+						report.CrossroadsNotMLOD = append(report.CrossroadsNotMLOD, path)
+					}
+					switch kind {
+					case "ODOL":
+						report.reject(path, "ODOL")
+					case "UNKNOWN":
+						report.reject(path, "unknown header")
+					default:
+						report.reject(path, "not MLOD")
 					}
 					return nil
 				}
@@ -123,28 +373,39 @@ func generateConfig(paths []string, gameRoot string, noOgol bool, verbose bool)
 
 			parsed, ok := roadparts.ParseFile(path)
 			if !ok {
-				filesNameReject++
-				if verbose {
-					fmt.Fprintf(os.Stderr, "skip: %s (name reject)\n", path)
-				}
+				report.NameRejects++
+				report.reject(path, "name reject")
 				return nil
 			}
 
 			if parsed.Kind == roadparts.Unknown {
-				filesKindReject++
-				if verbose {
-					fmt.Fprintf(os.Stderr, "skip: %s (kind unknown)\n", path)
-				}
+				report.KindRejects++
+				report.reject(path, "kind unknown")
 				return nil
 			}
 
 			if parsed.Kind == roadparts.Crossroad {
+				if opts.RejectODOLCR {
+					if opts.NoOdol {
+						if _, kind, err = p3d.IsMLOD(path); err != nil {
+							kind = "UNKNOWN"
+						}
+					}
+					if kind != "MLOD" {
+						report.CrossroadsNotMLOD = append(report.CrossroadsNotMLOD, path)
+						report.reject(path, "crossroad not MLOD")
+						return nil
+					}
+				}
+				if needsMLOD {
+					// --include-odol covers road parts only; crossroads have no needs_mlod flag.
+					report.reject(path, "ODOL")
+					return nil
+				}
 				crName, ok := roadparts.ParseCrossroadBase(parsed.Name)
 				if !ok {
-					filesKindReject++
-					if verbose {
-						fmt.Fprintf(os.Stderr, "skip: %s (crossroad name reject)\n", path)
-					}
+					report.KindRejects++
+					report.reject(path, "crossroad name reject")
 					return nil
 				}
 
@@ -172,89 +433,51 @@ func generateConfig(paths []string, gameRoot string, noOgol bool, verbose bool)
 					crossroads[name] = cr
 				}
 
-				filesCrossroadAdded++
-				if verbose {
-					fmt.Fprintf(os.Stderr, "add: %s (crossroad)\n", path)
-				}
+				report.CrossroadFiles++
+				cliLog.Debugf("add: %s (crossroad)", path)
 				return nil
 			}
 
-			rt := types[parsed.TypeName]
-			if rt == nil {
-				rt = &tv4p.RoadType{
-					Name:         parsed.TypeName,
-					Type:         0x12,
-					KeyCustom:    false,
-					NormalCustom: false,
-				}
-				applyRoadPalette(rt)
-				types[parsed.TypeName] = rt
-			}
-
-			objPath := toObjectFile(path, root)
-			part := tv4p.RoadPart{
-				Name: parsed.Name,
-				Path: objPath,
-				Type: partTypeFromKind(parsed.Kind),
-			}
-
-			switch parsed.Kind {
-			case roadparts.Straight:
-				rt.StraightParts = append(rt.StraightParts, part)
-				filesAdded++
-				if verbose {
-					fmt.Fprintf(os.Stderr, "add: %s (straight -> %s)\n", path, rt.Name)
-				}
-
-			case roadparts.Corner:
-				rt.CornerParts = append(rt.CornerParts, part)
-				filesAdded++
-				if verbose {
-					fmt.Fprintf(os.Stderr, "add: %s (corner -> %s)\n", path, rt.Name)
-				}
-
-			case roadparts.Terminator:
-				rt.TerminatorPart = append(rt.TerminatorPart, part)
-				filesAdded++
-				if verbose {
-					fmt.Fprintf(os.Stderr, "add: %s (terminator -> %s)\n", path, rt.Name)
-				}
-
-			case roadparts.Crosswalk:
-				part.Type = 0x13
-				rt.StraightParts = append(rt.StraightParts, part)
-				filesAdded++
-				if verbose {
-					fmt.Fprintf(os.Stderr, "add: %s (crosswalk -> %s)\n", path, rt.Name)
-				}
+			if addRoadPart(types, parsed, toObjectFile(path, root), needsMLOD, opts.Palette, opts.ColorBounds) {
+				report.Added++
 			}
 
 			return nil
 		})
 
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return tv4p.RoadConfig{}, generateReport{}, fmt.Errorf("scan aborted after %d file(s): %w", report.TotalFiles, err)
+		}
 		if err != nil {
-			return tv4p.RoadConfig{}, err
+			return tv4p.RoadConfig{}, generateReport{}, err
 		}
 	}
 
-	var list []tv4p.RoadType
-	for _, rt := range types {
-		sort.Slice(rt.StraightParts, func(i, j int) bool { return rt.StraightParts[i].Name < rt.StraightParts[j].Name })
-		sort.Slice(rt.CornerParts, func(i, j int) bool { return rt.CornerParts[i].Name < rt.CornerParts[j].Name })
-		sort.Slice(rt.TerminatorPart, func(i, j int) bool { return rt.TerminatorPart[i].Name < rt.TerminatorPart[j].Name })
-		list = append(list, *rt)
+	opts.Progress.done()
+
+	list := sortedRoadTypes(types)
+	report.Types = len(list)
+	if opts.SynthTerm {
+		report.SynthTerm = synthTerminators(list)
 	}
-	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
 
 	// Now that we have the final road types list (and therefore palette decisions),
 	// compute crossroad colors from their A/B/C(/D) connections.
-	roadTypeNames := map[string]struct{}{}
+	applyDistinctPalette(list, opts.Palette, opts.ColorDistance, opts.ColorBounds)
+	applyRoadTemplate(list, opts.Template)
+	roadTypeColors := map[string]tv4p.Color{}
 	for _, rt := range list {
-		roadTypeNames[rt.Name] = struct{}{}
+		roadTypeColors[rt.Name] = rt.NormalColor
 	}
 	for _, cr := range crossroads {
-		colors := crossroadConnectionColors(cr.Connections, roadTypeNames)
+		colors := crossroadConnectionColors(cr.Connections, roadTypeColors, opts.Weights)
 		if len(colors) == 0 {
+			if opts.SkipUnresolv {
+				// Standard TB sentinel: visually neutral instead of the magenta fallback.
+				cr.ColorCustom = false
+				cr.Color = tv4p.Color{}
+				continue
+			}
 			// Fallback UI color if nothing is resolvable.
 			cr.Color = tv4p.Color{R: 255, G: 0, B: 255, A: 255}
 			continue
@@ -272,29 +495,43 @@ func generateConfig(paths []string, gameRoot string, noOgol bool, verbose bool)
 	sort.Slice(crossList, func(i, j int) bool { return crossList[i].Name < crossList[j].Name })
 
 	// Mark defaults explicitly (can be edited in YAML later).
-	assignCrossroadDefaults(list, crossList)
+	assignCrossroadDefaults(list, crossList, opts.PreferShape)
+	applyTemplate(list, crossList, opts.Template)
+	// Reorder last: defaults above are picked from the name-sorted list.
+	if opts.ByRoadOrder {
+		sortCrossroadsByRoad(crossList, list)
+	}
+
+	cliLog.Debugf("summary: files=%d p3d=%d mlod=%d odol=%d name_reject=%d kind_reject=%d crossroad=%d added=%d types=%d",
+		report.TotalFiles, report.FilesP3D, report.FilesMLOD, report.FilesODOL, report.NameRejects, report.KindRejects,
+		report.CrossroadFiles, report.Added, report.Types)
 
-	if verbose {
-		fmt.Fprintf(os.Stderr, "summary: files=%d p3d=%d mlod=%d odol=%d name_reject=%d kind_reject=%d crossroad=%d added=%d types=%d\n",
-			totalFiles, filesP3D, filesMLOD, filesODOL, filesNameReject, filesKindReject, filesCrossroadAdded, filesAdded, len(list))
+	if n := len(report.CrossroadsNotMLOD); n > 0 {
+		cliLog.Warnf("%d crossroad(s) dropped, not MLOD (--reject-non-mlod-crossroads):\n  %s",
+			n, strings.Join(report.CrossroadsNotMLOD, "\n  "))
 	}
 
-	if filesP3D > 0 && filesMLOD == 0 {
-		fmt.Fprint(os.Stderr, `WARNING: no MLOD road models found.
+	if report.FilesP3D > 0 && report.FilesMLOD == 0 {
+		cliLog.Warnf(`no MLOD road models found.
 Terrain Builder needs MLOD models to read sizes/metadata for Road Tool.
 
 Download MLOD roads from: https://github.com/BohemiaInteractive/DayZ-Misc
 
 Then copy the road models into your game root (e.g. DZ/structures/roads/Parts).
-By default the game ships ODOL (binarized) models, which are not suitable here.
-`)
+By default the game ships ODOL (binarized) models, which are not suitable here.`)
 	}
 
-	return tv4p.RoadConfig{Types: list, CrossroadTypes: crossList}, nil
+	return tv4p.RoadConfig{Types: list, CrossroadTypes: crossList}, report, nil
+}
+
+// isCrossroadFile reports whether the file name parses as a crossroad model.
+func isCrossroadFile(path string) bool {
+	parsed, ok := roadparts.ParseFile(path)
+	return ok && parsed.Kind == roadparts.Crossroad
 }
 
 // assignCrossroadDefaults assigns the default crossroad for each road type.
-func assignCrossroadDefaults(roadTypes []tv4p.RoadType, crossroads []tv4p.CrossroadType) {
+func assignCrossroadDefaults(roadTypes []tv4p.RoadType, crossroads []tv4p.CrossroadType, prefer tv4p.CrossroadShape) {
 	// Ensure there is at most one default per road type.
 	// If a crossroad already has Default set (rare in generator), keep it.
 	seen := map[string]struct{}{}
@@ -312,12 +549,7 @@ func assignCrossroadDefaults(roadTypes []tv4p.RoadType, crossroads []tv4p.Crossr
 		c := strings.ToLower(cr.Connections.C)
 		d := strings.ToLower(cr.Connections.D)
 
-		shape := 0
-		if strings.HasPrefix(cr.Name, "kr_t_") {
-			shape = 2
-		} else if strings.HasPrefix(cr.Name, "kr_x_") {
-			shape = 1
-		}
+		shape := tv4p.CrossroadShapeScore(cr, prefer)
 
 		if abA == want && abB == want {
 			return 100 + shape
@@ -341,6 +573,7 @@ func assignCrossroadDefaults(roadTypes []tv4p.RoadType, crossroads []tv4p.Crossr
 			continue
 		}
 
+		// Ties are broken by name so the choice does not depend on input order.
 		best := -1
 		bestScore := -1
 		for i := range crossroads {
@@ -348,7 +581,7 @@ func assignCrossroadDefaults(roadTypes []tv4p.RoadType, crossroads []tv4p.Crossr
 				continue
 			}
 			s := score(crossroads[i], want)
-			if s > bestScore {
+			if s > bestScore || (s == bestScore && best >= 0 && crossroads[i].Name < crossroads[best].Name) {
 				bestScore = s
 				best = i
 			}
@@ -360,36 +593,228 @@ func assignCrossroadDefaults(roadTypes []tv4p.RoadType, crossroads []tv4p.Crossr
 	}
 }
 
+// checkCompleteDefaults reports road types left without a default crossroad
+// (tv4p.RoadTypesWithoutDefault) and fails with require (--require-complete-defaults).
+func checkCompleteDefaults(cfg tv4p.RoadConfig, require bool) error {
+	missing := tv4p.RoadTypesWithoutDefault(cfg.Types, cfg.CrossroadTypes)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	list := strings.Join(missing, ", ")
+	if require {
+		return fmt.Errorf("%d road type(s) without a default crossroad: %s", len(missing), list)
+	}
+	cliLog.Infof("road types without a default crossroad: %s", list)
+
+	return nil
+}
+
+// crossroadWeights are the A/B/C/D side weights of the crossroad color mix.
+type crossroadWeights [4]int
+
+// defaultCrossroadWeights adds every side once; through roads (A == B) thus count twice.
+var defaultCrossroadWeights = crossroadWeights{1, 1, 1, 1}
+
+// parseCrossroadWeights parses the --crossroad-weights value "a,b,c,d".
+func parseCrossroadWeights(s string) (crossroadWeights, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return crossroadWeights{}, fmt.Errorf("crossroad weights %q: want 4 comma-separated values a,b,c,d", s)
+	}
+
+	var w crossroadWeights
+	total := 0
+	for i, p := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || v < 0 {
+			return crossroadWeights{}, fmt.Errorf("crossroad weights %q: %q is not a non-negative integer", s, p)
+		}
+		w[i] = v
+		total += v
+	}
+	if total == 0 {
+		return crossroadWeights{}, fmt.Errorf("crossroad weights %q: at least one weight must be positive", s)
+	}
+
+	return w, nil
+}
+
 // crossroadConnectionColors computes the colors for a crossroad based on its connections.
-func crossroadConnectionColors(c tv4p.CrossroadConnections, known map[string]struct{}) []tv4p.Color {
+// Each side color is repeated by its weight, since MixColors weights by duplicates.
+// known maps road type names to their normal color; other names are not mixed in.
+func crossroadConnectionColors(c tv4p.CrossroadConnections, known map[string]tv4p.Color, weights crossroadWeights) []tv4p.Color {
+	if weights == (crossroadWeights{}) {
+		weights = defaultCrossroadWeights
+	}
+
 	var out []tv4p.Color
 
-	add := func(name string) {
+	add := func(name string, weight int) {
 		name = strings.TrimSpace(name)
 		if name == "" {
 			return
 		}
-		if _, ok := known[name]; !ok {
-			// Still allow palette hashing fallback, but keep behavior stable:
-			// do not include unknown types in the mix.
-			return
-		}
-		normal, _, ok := roadparts.Palette(name)
+		normal, ok := known[name]
 		if !ok {
+			// Keep behavior stable: do not include unknown types in the mix.
 			return
 		}
-		out = append(out, normal)
+		for range weight {
+			out = append(out, normal)
+		}
 	}
 
-	// Weighting is via duplicates, so A and B contribute twice if same type.
-	add(c.A)
-	add(c.B)
-	add(c.C)
-	add(c.D)
+	// With default weights A and B contribute twice if same type.
+	add(c.A, weights[0])
+	add(c.B, weights[1])
+	add(c.C, weights[2])
+	add(c.D, weights[3])
 
 	return out
 }
 
+// addRoadPart adds a parsed part to its road type, creating the type (with palette colors)
+// on first use. needsMLOD marks a part found as ODOL (--include-odol).
+// It returns false for kinds that are not road parts (crossroads, unknown).
+func addRoadPart(types map[string]*tv4p.RoadType, parsed roadparts.Parsed, objPath string, needsMLOD bool, palette roadparts.PaletteMode, bounds roadparts.ColorBounds) bool {
+	switch parsed.Kind {
+	case roadparts.Straight, roadparts.Corner, roadparts.Terminator, roadparts.Crosswalk:
+	default:
+		return false
+	}
+
+	rt := types[parsed.TypeName]
+	if rt == nil {
+		rt = &tv4p.RoadType{
+			Name:         parsed.TypeName,
+			Type:         0x12,
+			KeyCustom:    false,
+			NormalCustom: false,
+		}
+		applyRoadPalette(rt, palette, bounds)
+		types[parsed.TypeName] = rt
+	}
+
+	part := tv4p.RoadPart{
+		Name:      parsed.Name,
+		Path:      objPath,
+		Type:      partTypeFromKind(parsed.Kind),
+		NeedsMLOD: needsMLOD,
+	}
+
+	switch parsed.Kind {
+	case roadparts.Straight:
+		rt.StraightParts = append(rt.StraightParts, part)
+		cliLog.Debugf("add: %s (straight -> %s)", objPath, rt.Name)
+
+	case roadparts.Corner:
+		rt.CornerParts = append(rt.CornerParts, part)
+		cliLog.Debugf("add: %s (corner -> %s)", objPath, rt.Name)
+
+	case roadparts.Terminator:
+		rt.TerminatorPart = append(rt.TerminatorPart, part)
+		cliLog.Debugf("add: %s (terminator -> %s)", objPath, rt.Name)
+
+	case roadparts.Crosswalk:
+		part.Type = 0x13
+		rt.StraightParts = append(rt.StraightParts, part)
+		cliLog.Debugf("add: %s (crosswalk -> %s)", objPath, rt.Name)
+	}
+
+	return true
+}
+
+// synthTerminators adds a terminator to every road type that has none (best-effort).
+//
+// The terminator reuses the model of a straight part: `<type>_12` when present,
+// otherwise the straight part with the smallest name. It is named after that part
+// with a `konec` suffix (`asf1_12konec`), like TB terminators (`asf1_6konec`).
+// Road types without straight parts are reported as warnings.
+// It returns the number of terminators added.
+func synthTerminators(list []tv4p.RoadType) int {
+	added := 0
+	for i := range list {
+		rt := &list[i]
+		if len(rt.TerminatorPart) > 0 {
+			continue
+		}
+
+		var src *tv4p.RoadPart
+		for j := range rt.StraightParts {
+			p := &rt.StraightParts[j]
+			if strings.EqualFold(p.Name, rt.Name+"_12") {
+				src = p
+				break
+			}
+			if src == nil || p.Name < src.Name {
+				src = p
+			}
+		}
+		if src == nil {
+			cliLog.Warnf("road type %q has no terminator and no straight part to derive one from", rt.Name)
+			continue
+		}
+
+		rt.TerminatorPart = []tv4p.RoadPart{{
+			Name:      src.Name + "konec",
+			Path:      src.Path,
+			Type:      partTypeFromKind(roadparts.Terminator),
+			NeedsMLOD: src.NeedsMLOD,
+		}}
+		added++
+		cliLog.Debugf("synth terminator: %s -> %s (%s)", rt.Name, rt.TerminatorPart[0].Name, src.Path)
+	}
+
+	return added
+}
+
+// sortedRoadTypes returns the road types sorted by name, with parts sorted by name.
+func sortedRoadTypes(types map[string]*tv4p.RoadType) []tv4p.RoadType {
+	var list []tv4p.RoadType
+	for _, rt := range types {
+		sort.Slice(rt.StraightParts, func(i, j int) bool { return rt.StraightParts[i].Name < rt.StraightParts[j].Name })
+		sort.Slice(rt.CornerParts, func(i, j int) bool { return rt.CornerParts[i].Name < rt.CornerParts[j].Name })
+		sort.Slice(rt.TerminatorPart, func(i, j int) bool { return rt.TerminatorPart[i].Name < rt.TerminatorPart[j].Name })
+		list = append(list, *rt)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+// sortCrossroadsByRoad orders crossroads by the position of their primary road type in
+// roadTypes, then T before X shapes, then by name, so each road type's crossroads
+// follow one another in road type order. The primary road type is A, or the first of
+// B, C, D that is in the list when A is not (kr_t_zzz_asf1 follows asf1).
+// Crossroads without any listed road type go last.
+func sortCrossroadsByRoad(crossroads []tv4p.CrossroadType, roadTypes []tv4p.RoadType) {
+	pos := map[string]int{}
+	for i, rt := range roadTypes {
+		pos[strings.ToLower(rt.Name)] = i
+	}
+	roadPos := func(cr tv4p.CrossroadType) int {
+		c := cr.Connections
+		for _, name := range []string{c.A, c.B, c.C, c.D} {
+			if i, ok := pos[strings.ToLower(name)]; ok {
+				return i
+			}
+		}
+		return len(roadTypes)
+	}
+
+	sort.SliceStable(crossroads, func(i, j int) bool {
+		a, b := crossroads[i], crossroads[j]
+		if pa, pb := roadPos(a), roadPos(b); pa != pb {
+			return pa < pb
+		}
+		if sa, sb := tv4p.CrossroadShapeScore(a, tv4p.ShapeT), tv4p.CrossroadShapeScore(b, tv4p.ShapeT); sa != sb {
+			return sa > sb
+		}
+		return a.Name < b.Name
+	})
+}
+
 // partTypeFromKind converts the road part kind to the type.
 func partTypeFromKind(kind roadparts.Kind) uint16 {
 	switch kind {
@@ -408,8 +833,7 @@ func partTypeFromKind(kind roadparts.Kind) uint16 {
 func toObjectFile(path string, gameRoot string) string {
 	abs := cleanAbs(path)
 	if gameRoot != "" {
-		rel, err := filepath.Rel(gameRoot, abs)
-		if err == nil && !strings.HasPrefix(rel, "..") {
+		if rel, ok := relPath(gameRoot, abs); ok {
 			return strings.ToLower(toBackslashes(rel))
 		}
 	}
@@ -422,17 +846,47 @@ func toObjectFile(path string, gameRoot string) string {
 func toCrossroadModelPath(path string, gameRoot string) string {
 	abs := cleanAbs(path)
 	if gameRoot != "" {
-		rel, err := filepath.Rel(gameRoot, abs)
-		if err == nil && !strings.HasPrefix(rel, "..") {
-			return toBackslashes(filepath.Join(gameRoot, rel))
+		if rel, ok := relPath(gameRoot, abs); ok {
+			return toBackslashes(joinPath(cleanAbs(gameRoot), rel))
 		}
 	}
 
 	return toBackslashes(abs)
 }
 
+// parseColorBounds checks --color-min/--color-max: both within 0-255 and min below max.
+func parseColorBounds(lo, hi int) (roadparts.ColorBounds, error) {
+	if lo < 0 || lo > 255 || hi < 0 || hi > 255 {
+		return roadparts.ColorBounds{}, fmt.Errorf("--color-min/--color-max: %d..%d is outside 0-255", lo, hi)
+	}
+	bounds := roadparts.ColorBounds{Min: byte(lo), Max: byte(hi)}
+	if err := bounds.Validate(); err != nil {
+		return roadparts.ColorBounds{}, fmt.Errorf("--color-min/--color-max: %w", err)
+	}
+
+	return bounds, nil
+}
+
+// applyDistinctPalette re-assigns the palette colors of generated road types so hashed
+// colors keep minDist (RGB distance) from rule colors and from each other
+// (roadparts.PaletteDistinct). Names are assigned in list order.
+func applyDistinctPalette(list []tv4p.RoadType, mode roadparts.PaletteMode, minDist float64, bounds roadparts.ColorBounds) {
+	if minDist <= 0 {
+		return
+	}
+
+	names := make([]string, len(list))
+	for i := range list {
+		names[i] = list[i].Name
+	}
+	for i, c := range roadparts.PaletteDistinct(names, mode, minDist, bounds) {
+		list[i].NormalColor = c.Normal
+		list[i].KeyColor = c.Key
+	}
+}
+
 // applyRoadPalette applies the road palette to the road type.
-func applyRoadPalette(rt *tv4p.RoadType) {
+func applyRoadPalette(rt *tv4p.RoadType, mode roadparts.PaletteMode, bounds roadparts.ColorBounds) {
 	if rt == nil {
 		return
 	}
@@ -441,7 +895,7 @@ func applyRoadPalette(rt *tv4p.RoadType) {
 		return
 	}
 
-	normal, key, ok := roadparts.Palette(rt.Name)
+	normal, key, ok := roadparts.PaletteWithBounds(rt.Name, mode, bounds)
 	if !ok {
 		return
 	}
@@ -0,0 +1,652 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestAssignCrossroadDefaultsTieBreak(t *testing.T) {
+	t.Parallel()
+
+	roadTypes := []tv4p.RoadType{{Name: "city"}}
+	a := tv4p.CrossroadType{Name: "kr_x_city_city", Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city", D: "city"}}
+	b := tv4p.CrossroadType{Name: "kr_x_city_asf1", Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "asf1", D: "asf1"}}
+
+	for _, order := range [][]tv4p.CrossroadType{{a, b}, {b, a}} {
+		list := append([]tv4p.CrossroadType(nil), order...)
+		assignCrossroadDefaults(roadTypes, list, tv4p.ShapeT)
+
+		got := ""
+		for _, cr := range list {
+			if cr.Default == "city" {
+				got = cr.Name
+			}
+		}
+		if got != "kr_x_city_asf1" {
+			t.Fatalf("default=%q want %q", got, "kr_x_city_asf1")
+		}
+	}
+}
+
+func TestAssignCrossroadDefaultsPreferShape(t *testing.T) {
+	t.Parallel()
+
+	roadTypes := []tv4p.RoadType{{Name: "city"}}
+	base := []tv4p.CrossroadType{
+		{Name: "kr_t_city_city", Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city"}},
+		{Name: "kr_x_city_city", Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city", D: "city"}},
+	}
+
+	for prefer, want := range map[tv4p.CrossroadShape]string{tv4p.ShapeT: "kr_t_city_city", tv4p.ShapeX: "kr_x_city_city"} {
+		list := append([]tv4p.CrossroadType(nil), base...)
+		assignCrossroadDefaults(roadTypes, list, prefer)
+
+		got := ""
+		for _, cr := range list {
+			if cr.Default == "city" {
+				got = cr.Name
+			}
+		}
+		if got != want {
+			t.Fatalf("prefer=%q default=%q want %q", prefer, got, want)
+		}
+	}
+}
+
+func TestCheckCompleteDefaults(t *testing.T) {
+	t.Parallel()
+
+	// asf1 is assigned first and takes the only crossroad city is connected to.
+	roadTypes := []tv4p.RoadType{{Name: "asf1"}, {Name: "city"}}
+	crossroads := []tv4p.CrossroadType{
+		{Name: "kr_t_asf1_city", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}},
+	}
+	assignCrossroadDefaults(roadTypes, crossroads, tv4p.ShapeT)
+	cfg := tv4p.RoadConfig{Types: roadTypes, CrossroadTypes: crossroads}
+
+	if err := checkCompleteDefaults(cfg, false); err != nil {
+		t.Fatalf("report only: %v", err)
+	}
+	err := checkCompleteDefaults(cfg, true)
+	if err == nil || !strings.Contains(err.Error(), "city") || strings.Contains(err.Error(), "asf1") {
+		t.Fatalf("err=%v want city only", err)
+	}
+
+	cfg.CrossroadTypes = append(cfg.CrossroadTypes, tv4p.CrossroadType{
+		Name:        "kr_x_city_city",
+		Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city", D: "city"},
+	})
+	assignCrossroadDefaults(cfg.Types, cfg.CrossroadTypes, tv4p.ShapeT)
+	if err := checkCompleteDefaults(cfg, true); err != nil {
+		t.Fatalf("complete defaults: %v", err)
+	}
+}
+
+func TestParseCrossroadWeights(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want crossroadWeights
+		err  bool
+	}{
+		{in: "1,1,1,1", want: crossroadWeights{1, 1, 1, 1}},
+		{in: " 2, 2 ,1,0", want: crossroadWeights{2, 2, 1, 0}},
+		{in: "1,1,1", err: true},
+		{in: "1,1,x,1", err: true},
+		{in: "1,-1,1,1", err: true},
+		{in: "0,0,0,0", err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseCrossroadWeights(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want error %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Fatalf("weights=%v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrossroadConnectionColorsWeights(t *testing.T) {
+	t.Parallel()
+
+	known := map[string]tv4p.Color{}
+	for _, name := range []string{"asf1", "city"} {
+		known[name], _, _ = roadparts.PaletteWith(name, roadparts.PaletteClamp)
+	}
+	conns := tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}
+	mix := func(w crossroadWeights) tv4p.Color {
+		return roadparts.MixColors(crossroadConnectionColors(conns, known, w)...)
+	}
+
+	def := mix(crossroadWeights{})
+	if got := mix(defaultCrossroadWeights); got != def {
+		t.Fatalf("explicit default=%v want %v", got, def)
+	}
+
+	city, _, _ := roadparts.PaletteWith("city", roadparts.PaletteClamp)
+	if got := mix(crossroadWeights{0, 0, 1, 0}); got != city {
+		t.Fatalf("branch only=%v want %v", got, city)
+	}
+
+	branchHeavy := mix(crossroadWeights{1, 1, 6, 1})
+	if branchHeavy == def {
+		t.Fatalf("weights did not change the mix: %v", def)
+	}
+	if colorDist(branchHeavy, city) >= colorDist(def, city) {
+		t.Fatalf("branch weight did not shift %v towards %v (default %v)", branchHeavy, city, def)
+	}
+}
+
+// colorDist is the squared RGB distance of two colors.
+func colorDist(a, b tv4p.Color) int {
+	dr, dg, db := int(a.R)-int(b.R), int(a.G)-int(b.G), int(a.B)-int(b.B)
+	return dr*dr + dg*dg + db*db
+}
+
+func TestSearchPathList(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "paths.txt")
+	if err := os.WriteFile(file, []byte("# mod roads\r\nmod/roads\n\n  other/roads  \n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, []byte("# nothing\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		flags []string
+		file  string
+		env   string
+		want  []string
+		err   bool
+	}{
+		{name: "flags_win", flags: []string{"a"}, file: file, env: "b", want: []string{"a"}},
+		{name: "file_over_env", file: file, env: "b", want: []string{"mod/roads", "other/roads"}},
+		{name: "env", env: "x/roads;y/roads", want: []string{"x/roads", "y/roads"}},
+		{name: "env_blank", env: " ; ", want: defaultSearchPaths},
+		{name: "defaults", want: defaultSearchPaths},
+		{name: "empty_file", file: empty, err: true},
+		{name: "missing_file", file: filepath.Join(dir, "missing.txt"), err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := searchPathList(tt.flags, tt.file, tt.env)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want err=%v", err, tt.err)
+			}
+			if !tt.err && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got=%v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSearchPaths(t *testing.T) {
+	t.Parallel()
+
+	// ';' always separates; the OS separator (':' on Unix) does too.
+	got := splitSearchPaths("a;b" + string(os.PathListSeparator) + "c;;")
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got=%v want %v", got, want)
+	}
+}
+
+func TestGenerateConfigReport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"asf1_12.p3d":        "MLOD",
+		"asf1_6konec.p3d":    "MLOD",
+		"kr_t_asf1_asf1.p3d": "MLOD",
+		"asf1_25.p3d":        "ODOL",
+		"readme.txt":         "text",
+	}
+	for name, hdr := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(hdr), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	cfg, report, err := generateConfig(context.Background(), []string{dir}, generateOptions{})
+	if err != nil {
+		t.Fatalf("generateConfig: %v", err)
+	}
+
+	want := generateReport{
+		TotalFiles:     5,
+		FilesP3D:       4,
+		FilesMLOD:      3,
+		FilesODOL:      1,
+		CrossroadFiles: 1,
+		Added:          2,
+		Types:          len(cfg.Types),
+		Rejected:       []generateReject{{Path: filepath.Join(dir, "asf1_25.p3d"), Reason: "ODOL"}},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("report=%+v want %+v", report, want)
+	}
+	if report.Types != 1 {
+		t.Fatalf("types=%d want 1", report.Types)
+	}
+}
+
+func TestGenerateConfigIncludeODOL(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, hdr := range map[string]string{
+		"asf1_12.p3d":        "MLOD",
+		"asf1_25.p3d":        "ODOL",
+		"kr_t_asf1_asf1.p3d": "ODOL",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(hdr), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	cfg, report, err := generateConfig(context.Background(), []string{dir}, generateOptions{IncludeODOL: true})
+	if err != nil {
+		t.Fatalf("generateConfig: %v", err)
+	}
+	if len(cfg.Types) != 1 || len(cfg.Types[0].StraightParts) != 2 {
+		t.Fatalf("types=%+v want asf1 with 2 straight parts", cfg.Types)
+	}
+
+	needs := map[string]bool{}
+	for _, p := range cfg.Types[0].StraightParts {
+		needs[p.Name] = p.NeedsMLOD
+	}
+	if !needs["asf1_25"] || needs["asf1_12"] {
+		t.Fatalf("needs_mlod=%v want only asf1_25", needs)
+	}
+	if len(cfg.CrossroadTypes) != 0 || len(report.Rejected) != 1 {
+		t.Fatalf("crossroads=%d rejected=%+v want ODOL crossroad rejected", len(cfg.CrossroadTypes), report.Rejected)
+	}
+}
+
+func TestGenerateConfigRejectNonMLODCrossroads(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, hdr := range map[string]string{
+		"asf1_12.p3d":        "MLOD",
+		"asf1_25.p3d":        "ODOL",
+		"kr_t_asf1_asf1.p3d": "ODOL",
+		"kr_x_asf1_asf1.p3d": "MLOD",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(hdr), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	odolCR := filepath.Join(dir, "kr_t_asf1_asf1.p3d")
+
+	tests := []struct {
+		name      string
+		opts      generateOptions
+		straights int
+	}{
+		{name: "no-odol-check", opts: generateOptions{NoOdol: true, RejectODOLCR: true}, straights: 2},
+		{name: "odol-check", opts: generateOptions{RejectODOLCR: true}, straights: 1},
+		{name: "include-odol", opts: generateOptions{IncludeODOL: true, RejectODOLCR: true}, straights: 2},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, report, err := generateConfig(context.Background(), []string{dir}, tt.opts)
+			if err != nil {
+				t.Fatalf("generateConfig: %v", err)
+			}
+			if len(cfg.Types) != 1 || len(cfg.Types[0].StraightParts) != tt.straights {
+				t.Fatalf("types=%+v want asf1 with %d straight parts", cfg.Types, tt.straights)
+			}
+			if len(cfg.CrossroadTypes) != 1 || cfg.CrossroadTypes[0].Name != "kr_x_asf1_asf1" {
+				t.Fatalf("crossroads=%+v want only kr_x_asf1_asf1", cfg.CrossroadTypes)
+			}
+			if len(report.CrossroadsNotMLOD) != 1 || report.CrossroadsNotMLOD[0] != odolCR {
+				t.Fatalf("crossroads_not_mlod=%v want [%s]", report.CrossroadsNotMLOD, odolCR)
+			}
+		})
+	}
+}
+
+func TestGenerateConfigCrossroadOrderByRoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{
+		"asf1_12.p3d", "city_12.p3d",
+		"kr_x_asf1_city.p3d", "kr_t_city_asf1.p3d", "kr_t_asf1_city.p3d", "kr_t_zzz_asf1.p3d", "kr_t_asf1_asf1.p3d",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("MLOD"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		byRoad bool
+		want   []string
+	}{
+		{name: "name", want: []string{"kr_t_asf1_asf1", "kr_t_asf1_city", "kr_t_city_asf1", "kr_t_zzz_asf1", "kr_x_asf1_city"}},
+		{name: "by-road", byRoad: true, want: []string{"kr_t_asf1_asf1", "kr_t_asf1_city", "kr_t_zzz_asf1", "kr_x_asf1_city", "kr_t_city_asf1"}},
+	}
+
+	defaults := map[string]map[string]string{}
+	for _, tt := range tests {
+		cfg, _, err := generateConfig(context.Background(), []string{dir}, generateOptions{ByRoadOrder: tt.byRoad})
+		if err != nil {
+			t.Fatalf("%s: generateConfig: %v", tt.name, err)
+		}
+		var got []string
+		defaults[tt.name] = map[string]string{}
+		for _, cr := range cfg.CrossroadTypes {
+			got = append(got, cr.Name)
+			defaults[tt.name][cr.Name] = cr.Default
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("%s: order=%v want %v", tt.name, got, tt.want)
+		}
+	}
+	if !maps.Equal(defaults["name"], defaults["by-road"]) {
+		t.Fatalf("defaults differ: %v vs %v", defaults["name"], defaults["by-road"])
+	}
+}
+
+func TestGenerateConfigModelExts(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"asf1_12.P3DE", "asf1_25.p3d", "kr_t_asf1_asf1.p3de", "asf1_6konec.p3dx"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("MLOD"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		exts      []string
+		straight  []string
+		crossroad int
+	}{
+		{name: "default", exts: nil, straight: []string{"asf1_25"}},
+		{name: "p3de only", exts: []string{".p3de"}, straight: []string{"asf1_12"}, crossroad: 1},
+		{name: "both", exts: []string{".p3d", ".p3de"}, straight: []string{"asf1_12", "asf1_25"}, crossroad: 1},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, report, err := generateConfig(context.Background(), []string{dir}, generateOptions{ModelExts: tt.exts})
+			if err != nil {
+				t.Fatalf("generateConfig: %v", err)
+			}
+			if len(cfg.Types) != 1 {
+				t.Fatalf("types=%+v want asf1", cfg.Types)
+			}
+
+			var got []string
+			for _, p := range cfg.Types[0].StraightParts {
+				got = append(got, p.Name)
+			}
+			if !reflect.DeepEqual(got, tt.straight) {
+				t.Fatalf("straight=%v want %v", got, tt.straight)
+			}
+			if len(cfg.CrossroadTypes) != tt.crossroad {
+				t.Fatalf("crossroads=%d want %d", len(cfg.CrossroadTypes), tt.crossroad)
+			}
+			if len(cfg.Types[0].TerminatorPart) != 0 || report.TotalFiles != 4 {
+				t.Fatalf("terminators=%d total=%d want .p3dx skipped", len(cfg.Types[0].TerminatorPart), report.TotalFiles)
+			}
+		})
+	}
+}
+
+func TestParseModelExts(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseModelExts([]string{".p3d", "P3DE", " .P3D "})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if want := []string{".p3d", ".p3de"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("exts=%v want %v", got, want)
+	}
+	if _, err := parseModelExts([]string{"."}); err == nil {
+		t.Fatalf("expected error for empty extension")
+	}
+}
+
+func TestCleanAbsWindowsPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "p:", want: `P:\`},
+		{in: "P:/", want: `P:\`},
+		{in: `P:\dz\roads\..\data\`, want: `P:\dz\data`},
+		{in: `\\server\share`, want: `\\server\share\`},
+		{in: `\\server\share\dz\\roads\.\x.p3d`, want: `\\server\share\dz\roads\x.p3d`},
+		{in: `\\server\share\..\..\dz`, want: `\\server\share\dz`},
+		{in: `\\?\P:\dz\roads`, want: `P:\dz\roads`},
+		{in: `\\?\UNC\server\share\dz`, want: `\\server\share\dz`},
+		{in: `\\.\C:\work`, want: `C:\work`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			if got := cleanAbs(tt.in); got != tt.want {
+				t.Fatalf("cleanAbs=%q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToObjectFileWindowsRoots(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		path  string
+		root  string
+		want  string
+		model string
+	}{
+		{name: "drive", path: `P:\DZ\Roads\X.p3d`, root: "P:", want: `dz\roads\x.p3d`, model: `P:\DZ\Roads\X.p3d`},
+		{name: "long drive", path: `\\?\P:\dz\x.p3d`, root: `P:\`, want: `dz\x.p3d`, model: `P:\dz\x.p3d`},
+		{name: "unc", path: `\\server\share\dz\roads\x.p3d`, root: `\\server\share`, want: `dz\roads\x.p3d`, model: `\\server\share\dz\roads\x.p3d`},
+		{name: "unc subdir root", path: `\\Server\Share\Mods\dz\x.p3d`, root: `\\server\share\mods`, want: `dz\x.p3d`, model: `\\server\share\mods\dz\x.p3d`},
+		{name: "long unc", path: `\\?\UNC\server\share\dz\x.p3d`, root: `\\server\share`, want: `dz\x.p3d`, model: `\\server\share\dz\x.p3d`},
+		{name: "unc outside root", path: `\\server\other\dz\x.p3d`, root: `\\server\share`, want: `\\server\other\dz\x.p3d`, model: `\\server\other\dz\x.p3d`},
+		{name: "sibling prefix", path: `P:\dz2\x.p3d`, root: `P:\dz`, want: `p:\dz2\x.p3d`, model: `P:\dz2\x.p3d`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := toObjectFile(tt.path, cleanAbs(tt.root)); got != tt.want {
+				t.Fatalf("toObjectFile=%q want %q", got, tt.want)
+			}
+			if got := toCrossroadModelPath(tt.path, cleanAbs(tt.root)); got != tt.model {
+				t.Fatalf("toCrossroadModelPath=%q want %q", got, tt.model)
+			}
+		})
+	}
+}
+
+func TestResolvePathsWindowsRoots(t *testing.T) {
+	t.Parallel()
+
+	got := resolvePaths(`\\server\share`, []string{`dz\roads`, `\\?\P:\other`, `\\nas\data\x`})
+	want := []string{`\\server\share\dz\roads`, `P:\other`, `\\nas\data\x`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths=%q want %q", got, want)
+	}
+}
+
+func TestSynthTerminators(t *testing.T) {
+	t.Parallel()
+
+	list := []tv4p.RoadType{
+		{Name: "asf1", StraightParts: []tv4p.RoadPart{
+			{Name: "asf1_10 100", Path: `dz\roads\asf1_10 100.p3d`},
+			{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`, NeedsMLOD: true},
+		}},
+		{Name: "city", StraightParts: []tv4p.RoadPart{
+			{Name: "city_25", Path: `dz\roads\city_25.p3d`},
+			{Name: "city_10 75", Path: `dz\roads\city_10 75.p3d`},
+		}},
+		{Name: "gravel", TerminatorPart: []tv4p.RoadPart{{Name: "gravel_6konec", Path: `dz\roads\gravel_6konec.p3d`}}},
+		{Name: "empty"},
+	}
+
+	if got := synthTerminators(list); got != 2 {
+		t.Fatalf("added=%d want 2", got)
+	}
+
+	want := map[string]tv4p.RoadPart{
+		"asf1":   {Name: "asf1_12konec", Path: `dz\roads\asf1_12.p3d`, Type: partTypeFromKind(roadparts.Terminator), NeedsMLOD: true},
+		"city":   {Name: "city_10 75konec", Path: `dz\roads\city_10 75.p3d`, Type: partTypeFromKind(roadparts.Terminator)},
+		"gravel": {Name: "gravel_6konec", Path: `dz\roads\gravel_6konec.p3d`},
+	}
+	for _, rt := range list {
+		w, ok := want[rt.Name]
+		if !ok {
+			if len(rt.TerminatorPart) != 0 {
+				t.Fatalf("%s: terminators=%+v want none", rt.Name, rt.TerminatorPart)
+			}
+			continue
+		}
+		if len(rt.TerminatorPart) != 1 || rt.TerminatorPart[0] != w {
+			t.Fatalf("%s: terminators=%+v want %+v", rt.Name, rt.TerminatorPart, w)
+		}
+	}
+}
+
+func TestGenerateConfigTimeout(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "asf1_12.p3d"), []byte("MLOD"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, _, err := generateConfig(ctx, []string{dir}, generateOptions{})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "after 0 file(s)") {
+		t.Fatalf("err=%v want deadline exceeded after 0 file(s)", err)
+	}
+}
+
+func TestGenerateConfigSkipUnresolvableColors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"asf1_12.p3d", "kr_t_asf1_asf1.p3d", "kr_t_asf9_asf9.p3d"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("MLOD"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	magenta := tv4p.Color{R: 255, G: 0, B: 255, A: 255}
+	tests := []struct {
+		name   string
+		skip   bool
+		custom bool
+		color  tv4p.Color
+	}{
+		{name: "magenta", custom: true, color: magenta},
+		{name: "skip", skip: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _, err := generateConfig(context.Background(), []string{dir}, generateOptions{SkipUnresolv: tt.skip})
+			if err != nil {
+				t.Fatalf("generateConfig: %v", err)
+			}
+			if len(cfg.CrossroadTypes) != 2 {
+				t.Fatalf("crossroads=%+v want 2", cfg.CrossroadTypes)
+			}
+
+			resolved, unresolved := cfg.CrossroadTypes[0], cfg.CrossroadTypes[1]
+			if !resolved.ColorCustom || resolved.Color == magenta {
+				t.Fatalf("%s: custom=%v color=%v want mixed road color", resolved.Name, resolved.ColorCustom, resolved.Color)
+			}
+			if unresolved.ColorCustom != tt.custom || unresolved.Color != tt.color {
+				t.Fatalf("%s: custom=%v color=%v want custom=%v color=%v",
+					unresolved.Name, unresolved.ColorCustom, unresolved.Color, tt.custom, tt.color)
+			}
+		})
+	}
+}
+
+func TestParseColorBounds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		lo, hi int
+		err    bool
+	}{
+		{lo: 40, hi: 220},
+		{lo: 0, hi: 255},
+		{lo: 120, hi: 120, err: true},
+		{lo: 200, hi: 100, err: true},
+		{lo: -1, hi: 200, err: true},
+		{lo: 0, hi: 256, err: true},
+	}
+
+	for _, tt := range tests {
+		b, err := parseColorBounds(tt.lo, tt.hi)
+		if (err != nil) != tt.err {
+			t.Fatalf("parseColorBounds(%d, %d) err=%v want err=%v", tt.lo, tt.hi, err, tt.err)
+		}
+		if !tt.err && (int(b.Min) != tt.lo || int(b.Max) != tt.hi) {
+			t.Fatalf("bounds=%+v want %d..%d", b, tt.lo, tt.hi)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// growthLimit holds the --warn-growth thresholds. Zero values are disabled.
+type growthLimit struct {
+	bytes   int64   // absolute growth in bytes
+	percent float64 // growth relative to the input size
+}
+
+// parseGrowthLimit parses a comma-separated --warn-growth value: byte sizes
+// (4096, 512KB, 1MB) and/or a percentage (50%). "0" or "off" disables the warning.
+func parseGrowthLimit(s string) (growthLimit, error) {
+	var g growthLimit
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" || strings.EqualFold(s, "off") {
+		return g, nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if p, ok := strings.CutSuffix(part, "%"); ok {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil || v <= 0 {
+				return growthLimit{}, fmt.Errorf("invalid --warn-growth %q: bad percentage %q", s, part)
+			}
+			g.percent = v
+			continue
+		}
+
+		mult := int64(1)
+		num := strings.ToUpper(part)
+		for _, u := range []struct {
+			suffix string
+			mult   int64
+		}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+			if n, ok := strings.CutSuffix(num, u.suffix); ok {
+				num, mult = n, u.mult
+				break
+			}
+		}
+		v, err := strconv.ParseInt(strings.TrimSpace(num), 10, 64)
+		if err != nil || v <= 0 {
+			return growthLimit{}, fmt.Errorf("invalid --warn-growth %q: bad size %q", s, part)
+		}
+		g.bytes = v * mult
+	}
+
+	return g, nil
+}
+
+// exceeded reports whether growing from before to after bytes passes any threshold.
+func (g growthLimit) exceeded(before, after int) bool {
+	delta := int64(after) - int64(before)
+	if delta <= 0 {
+		return false
+	}
+	if g.bytes > 0 && delta > g.bytes {
+		return true
+	}
+
+	return g.percent > 0 && before > 0 && float64(delta)*100/float64(before) > g.percent
+}
+
+// warnGrowth warns when a patch grew the file past the --warn-growth thresholds.
+func (g growthLimit) warnGrowth(path string, before, after int) {
+	if !g.exceeded(before, after) {
+		return
+	}
+
+	pct := ""
+	if before > 0 {
+		pct = fmt.Sprintf(", +%.0f%%", float64(after-before)*100/float64(before))
+	}
+	cliLog.Warnf("%s grew from %d to %d bytes (+%d%s); check the config for duplicated parts",
+		path, before, after, after-before, pct)
+}
@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+func TestParseGrowthLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want growthLimit
+		err  bool
+	}{
+		{in: "", want: growthLimit{}},
+		{in: "off", want: growthLimit{}},
+		{in: "0", want: growthLimit{}},
+		{in: "1MB,50%", want: growthLimit{bytes: 1 << 20, percent: 50}},
+		{in: "512kb", want: growthLimit{bytes: 512 << 10}},
+		{in: "4096", want: growthLimit{bytes: 4096}},
+		{in: "12.5%", want: growthLimit{percent: 12.5}},
+		{in: "1XB", err: true},
+		{in: "-5%", err: true},
+		{in: "1MB,", err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseGrowthLimit(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want error %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Fatalf("limit=%+v want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrowthLimitExceeded(t *testing.T) {
+	t.Parallel()
+
+	g := growthLimit{bytes: 1000, percent: 50}
+	tests := []struct {
+		name          string
+		limit         growthLimit
+		before, after int
+		want          bool
+	}{
+		{name: "shrink", limit: g, before: 10000, after: 100, want: false},
+		{name: "small", limit: g, before: 10000, after: 10500, want: false},
+		{name: "bytes", limit: g, before: 10000, after: 11001, want: true},
+		{name: "percent", limit: g, before: 100, after: 151, want: true},
+		{name: "percent edge", limit: g, before: 100, after: 150, want: false},
+		{name: "disabled", before: 1, after: 1 << 30, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.limit.exceeded(tt.before, tt.after); got != tt.want {
+				t.Fatalf("exceeded=%v want %v", got, tt.want)
+			}
+		})
+	}
+}
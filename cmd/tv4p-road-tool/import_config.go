@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type importConfigCmd struct {
+	Args struct {
+		Input  string `positional-arg-name:"CPP" required:"true" description:"Addon config.cpp/model.cfg/.hpp listing road part models"`
+		Output string `positional-arg-name:"OUT" description:"Output config file (default: stdout)"`
+	} `positional-args:"true"`
+
+	Format      string `short:"f" long:"format" choice:"yaml" choice:"json" default:"yaml" description:"Output format"`
+	PaletteMode string `long:"palette-mode" choice:"clamp" choice:"hsv" default:"clamp" description:"Auto color generator for unknown road types: clamp (muted) or hsv (bright, distinct hues)"`
+
+	Chmod string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
+}
+
+// p3dTokenRe matches quoted .p3d paths (may contain spaces, e.g. "asf1_10 25.p3d")
+// or bare path tokens without spaces.
+var p3dTokenRe = regexp.MustCompile(`(?i)"([^"\r\n]*?\.p3d)"|([\w\\/:.\-]+\.p3d)\b`)
+
+// Execute builds a road types config from the .p3d paths referenced in an addon config.
+func (c *importConfigCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.ReadFile(c.Args.Input)
+	if err != nil {
+		return err
+	}
+
+	cfg := importConfigParts(string(src), roadparts.PaletteMode(c.PaletteMode))
+	if len(cfg.Types) == 0 {
+		return errors.New("no road part .p3d paths found")
+	}
+
+	out, err := encodeConfig(cfg, c.Format)
+	if err != nil {
+		return err
+	}
+
+	if c.Args.Output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	return perm.writeFile(c.Args.Output, out)
+}
+
+// importConfigParts scrapes .p3d path tokens from config source text and groups the
+// ones that parse as road parts into road types.
+//
+// This is best-effort text scraping, not a config parser: comments, macros and
+// inheritance are not evaluated, and crossroads or unrecognized names are skipped.
+func importConfigParts(src string, palette roadparts.PaletteMode) tv4p.RoadConfig {
+	types := map[string]*tv4p.RoadType{}
+	seen := map[string]struct{}{}
+
+	for _, m := range p3dTokenRe.FindAllStringSubmatch(src, -1) {
+		raw := m[1]
+		if raw == "" {
+			raw = m[2]
+		}
+
+		clean := strings.TrimLeft(toBackslashes(strings.TrimSpace(raw)), `\`)
+		objPath := strings.ToLower(clean)
+		if _, ok := seen[objPath]; ok {
+			continue
+		}
+		seen[objPath] = struct{}{}
+
+		base := clean[strings.LastIndex(clean, `\`)+1:]
+		parsed, ok := roadparts.ParseBase(base[:len(base)-len(".p3d")])
+		if !ok || !addRoadPart(types, parsed, objPath, false, palette, roadparts.DefaultColorBounds) {
+			cliLog.Debugf("skip: %s (not a road part)", objPath)
+		}
+	}
+
+	return tv4p.RoadConfig{Types: sortedRoadTypes(types)}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
+)
+
+func TestImportConfigParts(t *testing.T) {
+	t.Parallel()
+
+	src := `class CfgVehicles
+{
+	class Land_Asf1_6: HouseNoDestruct
+	{
+		model = "\DZ\structures\roads\Parts\asf1_6.p3d";
+	};
+	class Land_Asf1_10_25 { model = "\DZ\structures\roads\Parts\asf1_10 25.p3d"; };
+	class Land_Asf1_End { model = "dz/structures/roads/parts/asf1_6konec.p3d"; };
+	class Land_Dup { model = "\dz\structures\roads\parts\ASF1_6.p3d"; };
+	class Land_Cross { model = "\DZ\structures\roads\Parts\kr_t_asf1_asf2.p3d"; };
+	class Land_Other { model = "\DZ\structures\misc\barrel.p3d"; };
+};
+// bare token: DZ\structures\roads\Parts\city_12.p3d
+`
+
+	cfg := importConfigParts(src, roadparts.PaletteClamp)
+	if len(cfg.Types) != 2 {
+		t.Fatalf("types=%+v want asf1 and city", cfg.Types)
+	}
+
+	asf1 := cfg.Types[0]
+	if asf1.Name != "asf1" || len(asf1.StraightParts) != 1 || len(asf1.CornerParts) != 1 || len(asf1.TerminatorPart) != 1 {
+		t.Fatalf("asf1=%+v", asf1)
+	}
+	if got := asf1.CornerParts[0]; got.Name != "asf1_10 25" || got.Path != `dz\structures\roads\parts\asf1_10 25.p3d` || got.Type != 0x14 {
+		t.Fatalf("corner=%+v", got)
+	}
+	if got := asf1.TerminatorPart[0].Path; got != `dz\structures\roads\parts\asf1_6konec.p3d` {
+		t.Fatalf("terminator path=%q", got)
+	}
+	if got := cfg.Types[1]; got.Name != "city" || len(got.StraightParts) != 1 {
+		t.Fatalf("city=%+v", got)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type inspectCmd struct {
+	Args struct {
+		Input string `positional-arg-name:"IN" required:"true" description:"Input tv4p file"`
+	} `positional-args:"true"`
+
+	Format    string `short:"f" long:"format" choice:"text" choice:"json" default:"text" description:"Output format"`
+	Histogram bool   `long:"histogram" description:"Count entry TypeIDs and field tag/type pairs across the whole file"`
+}
+
+// typeCount is one entry TypeID row of the histogram.
+type typeCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// fieldCount is one field tag/type row of the histogram.
+type fieldCount struct {
+	Tag   string `json:"tag"`
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// histogram is the sorted --histogram output.
+type histogram struct {
+	EntryTypes []typeCount  `json:"entry_types"`
+	Fields     []fieldCount `json:"fields"`
+}
+
+// Execute prints the file header summary, or the entry/field histogram with --histogram.
+func (c *inspectCmd) Execute(_ []string) error {
+	data, err := os.ReadFile(c.Args.Input)
+	if err != nil {
+		return err
+	}
+
+	var v any
+	if c.Histogram {
+		h, err := buildHistogram(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.Args.Input, err)
+		}
+		v = h
+	} else {
+		info, err := tv4p.Inspect(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.Args.Input, err)
+		}
+		v = info
+	}
+
+	if c.Format == "json" {
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(append(out, '\n'))
+		return err
+	}
+
+	switch v := v.(type) {
+	case histogram:
+		printHistogram(v)
+	case tv4p.FileInfo:
+		printFileInfo(v)
+	}
+
+	return nil
+}
+
+// buildHistogram runs tv4p.TypeHistogram and sorts the counts by key.
+func buildHistogram(data []byte) (histogram, error) {
+	types, fields, err := tv4p.TypeHistogram(data)
+	if err != nil {
+		return histogram{}, err
+	}
+
+	h := histogram{}
+	typeKeys := make([]uint16, 0, len(types))
+	for k := range types {
+		typeKeys = append(typeKeys, k)
+	}
+	slices.Sort(typeKeys)
+	for _, k := range typeKeys {
+		h.EntryTypes = append(h.EntryTypes, typeCount{Type: fmt.Sprintf("0x%02X", k), Count: types[k]})
+	}
+
+	fieldKeys := make([][2]byte, 0, len(fields))
+	for k := range fields {
+		fieldKeys = append(fieldKeys, k)
+	}
+	slices.SortFunc(fieldKeys, func(a, b [2]byte) int {
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		return int(a[1]) - int(b[1])
+	})
+	for _, k := range fieldKeys {
+		h.Fields = append(h.Fields, fieldCount{
+			Tag:   fmt.Sprintf("0x%02X", k[0]),
+			Type:  fmt.Sprintf("0x%02X", k[1]),
+			Count: fields[k],
+		})
+	}
+
+	return h, nil
+}
+
+// printHistogram prints the histogram as text.
+func printHistogram(h histogram) {
+	fmt.Println("entry types:")
+	for _, t := range h.EntryTypes {
+		fmt.Printf("  %s: %d\n", t.Type, t.Count)
+	}
+	fmt.Println("fields (tag/type):")
+	for _, f := range h.Fields {
+		fmt.Printf("  %s/%s: %d\n", f.Tag, f.Type, f.Count)
+	}
+}
+
+// printFileInfo prints the file header summary as text.
+func printFileInfo(info tv4p.FileInfo) {
+	fmt.Printf("size: %d\n", info.Size)
+	fmt.Printf("head: %s\n", info.Head)
+	if info.Signature != "" {
+		fmt.Printf("signature: %s\n", info.Signature)
+	}
+	if info.HasVersion {
+		fmt.Printf("version: %d\n", info.Version)
+	}
+	if info.RegionError != "" {
+		fmt.Printf("region: not found (%s)\n", info.RegionError)
+		return
+	}
+	fmt.Printf("region: 0x%X-0x%X\n", info.RegionStart, info.RegionEnd)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type inspectIDsCmd struct {
+	Args struct {
+		Input string `positional-arg-name:"IN" required:"true" description:"Input tv4p file"`
+	} `positional-args:"true"`
+
+	Format string `short:"f" long:"format" choice:"text" choice:"json" default:"text" description:"Output format"`
+}
+
+// Execute prints the detected entry ID layout of the input tv4p file.
+func (c *inspectIDsCmd) Execute(_ []string) error {
+	data, err := os.ReadFile(c.Args.Input)
+	if err != nil {
+		return err
+	}
+
+	info, err := tv4p.InspectIDs(data)
+	if err != nil {
+		return err
+	}
+
+	if c.Format == "json" {
+		out, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(append(out, '\n'))
+		return err
+	}
+
+	printIDSeries("road type IDs", info.RoadTypes)
+	printIDSeries("crossroad def IDs", info.CrossroadDefs)
+
+	return nil
+}
+
+// printIDSeries prints a human-readable ID series summary.
+func printIDSeries(title string, s tv4p.IDSeries) {
+	fmt.Printf("%s:\n", title)
+	fmt.Printf("  count: %d\n", s.Count)
+	if s.Count == 0 {
+		return
+	}
+
+	fmt.Printf("  min: 0x%X\n", s.Min)
+	fmt.Printf("  max: 0x%X\n", s.Max)
+	fmt.Printf("  stride: 0x%X (expected 0x%X)\n", s.Stride, s.ExpectedStride)
+	fmt.Printf("  remainder: 0x%X\n", s.Remainder)
+	fmt.Printf("  aligned: %s\n", yesNo(s.Aligned))
+	fmt.Printf("  progression: %s\n", yesNo(s.Progression))
+}
+
+// yesNo formats a boolean as yes/no.
+func yesNo(v bool) string {
+	if v {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestBuildHistogramSorted(t *testing.T) {
+	t.Parallel()
+
+	data := testTV4P(t, tv4p.RoadConfig{Types: []tv4p.RoadType{{
+		Name:          "asf1",
+		StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}},
+	}}})
+
+	h, err := buildHistogram(data)
+	if err != nil {
+		t.Fatalf("buildHistogram: %v", err)
+	}
+	if len(h.EntryTypes) == 0 || len(h.Fields) == 0 {
+		t.Fatalf("empty histogram: %+v", h)
+	}
+	for i := 1; i < len(h.EntryTypes); i++ {
+		if h.EntryTypes[i-1].Type >= h.EntryTypes[i].Type {
+			t.Fatalf("entry types not sorted: %+v", h.EntryTypes)
+		}
+	}
+	for i := 1; i < len(h.Fields); i++ {
+		a, b := h.Fields[i-1], h.Fields[i]
+		if a.Tag > b.Tag || (a.Tag == b.Tag && a.Type >= b.Type) {
+			t.Fatalf("fields not sorted: %+v", h.Fields)
+		}
+	}
+	if h.EntryTypes[0] != (typeCount{Type: "0x12", Count: 1}) {
+		t.Fatalf("first entry type=%+v want 0x12 x1", h.EntryTypes[0])
+	}
+}
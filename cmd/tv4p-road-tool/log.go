@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// logLevel selects which messages the CLI prints.
+type logLevel int
+
+const (
+	logQuiet   logLevel = iota // errors only (returned errors are printed by the flags parser)
+	logNormal                  // stats, summaries and warnings
+	logVerbose                 // per-file details
+)
+
+// logger is a tiny leveled logger for CLI status output.
+type logger struct {
+	w     io.Writer // destination, stderr so data on stdout stays clean
+	level logLevel  // highest level printed
+
+	warnings atomic.Int64 // warnings emitted, counted even when --quiet hides them
+}
+
+// cliLog is the logger shared by all commands; main sets its level from --quiet/--verbose.
+var cliLog = &logger{w: os.Stderr, level: logNormal}
+
+// Warnings returns the number of warnings emitted so far (for --fail-on-warning).
+func (l *logger) Warnings() int64 {
+	return l.warnings.Load()
+}
+
+// Infof prints stats and summaries (hidden by --quiet).
+func (l *logger) Infof(format string, args ...any) {
+	l.printf(logNormal, format, args...)
+}
+
+// Warnf prints a warning (hidden by --quiet).
+func (l *logger) Warnf(format string, args ...any) {
+	l.warnings.Add(1)
+	l.printf(logNormal, "warning: "+format, args...)
+}
+
+// Debugf prints per-item details (shown with --verbose).
+func (l *logger) Debugf(format string, args ...any) {
+	l.printf(logVerbose, format, args...)
+}
+
+// printf prints one line when the level is enabled.
+func (l *logger) printf(level logLevel, format string, args ...any) {
+	if l.level < level {
+		return
+	}
+
+	_, _ = fmt.Fprintf(l.w, format+"\n", args...)
+}
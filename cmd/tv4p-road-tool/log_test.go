@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		level logLevel
+		want  string
+	}{
+		{name: "quiet", level: logQuiet, want: ""},
+		{name: "normal", level: logNormal, want: "info 1\nwarning: warn 2\n"},
+		{name: "verbose", level: logVerbose, want: "info 1\nwarning: warn 2\ndebug 3\n"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			l := &logger{w: &buf, level: tt.level}
+			l.Infof("info %d", 1)
+			l.Warnf("warn %d", 2)
+			l.Debugf("debug %d", 3)
+			if got := buf.String(); got != tt.want {
+				t.Fatalf("output=%q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoggerCountsQuietWarnings(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := &logger{w: &buf, level: logQuiet}
+	l.Infof("info")
+	l.Warnf("warn %d", 1)
+	l.Warnf("warn %d", 2)
+	if got := l.Warnings(); got != 2 || buf.Len() != 0 {
+		t.Fatalf("warnings=%d output=%q want 2 and no output", got, buf.String())
+	}
+}
@@ -2,22 +2,59 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/jessevdk/go-flags"
 	"github.com/woozymasta/tv4p-road-tool/internal/vars"
 )
 
 type rootCmd struct {
+	Quiet   bool `short:"q" long:"quiet" description:"Print errors only (no stats or warnings)"`
+	Verbose bool `short:"v" long:"verbose" description:"Verbose per-file output"`
+
+	FailOnWarning bool `long:"fail-on-warning" description:"Exit nonzero after the command if it emitted any warning (or TV4P_STRICT=1)"`
+
 	Version  versionCmd  `command:"version" description:"Show version information"`
 	Patch    patchCmd    `command:"patch" description:"Patch road types config into tv4p"`
 	Extract  extractCmd  `command:"extract" description:"Extract road types config from tv4p"`
 	Generate generateCmd `command:"generate" description:"Generate config from disk"`
+
+	Inspect    inspectCmd    `command:"inspect" description:"Show the file header summary or an entry type histogram (--histogram)"`
+	InspectIDs inspectIDsCmd `command:"inspect-ids" description:"Show detected entry ID stride/remainder layout"`
+	Probe      probeCmd      `command:"probe" description:"Check whether a tv4p file has a patchable Road Tool block"`
+	CompareIDs compareIDsCmd `command:"compare-ids" description:"Report entry IDs that differ between two tv4p files"`
+	Normalize  normalizeCmd  `command:"normalize" description:"Rewrite the Road Tool region in the tool's canonical byte layout (extract + patch round-trip)"`
+	CopyRegion copyRegionCmd `command:"copy-region" description:"Copy the whole Road Tool region between tv4p files"`
+	DumpRegion dumpRegionCmd `command:"dump-region" description:"Write the raw Road Tool region bytes to a file (minimal repro)"`
+	Validate   validateCmd   `command:"validate" description:"Validate a config without patching"`
+	Schema     schemaCmd     `command:"schema" description:"Print a JSON Schema for the config format"`
+	Example    exampleCmd    `command:"example" description:"Print a small annotated example config"`
+	Bundle     bundleCmd     `command:"bundle" description:"Pack a tv4p file, its config and an ID manifest into a zip"`
+	Unbundle   unbundleCmd   `command:"unbundle" description:"Extract a bundle and check its ID manifest"`
+
+	ImportConfig importConfigCmd `command:"import-config" description:"Build road types from .p3d paths in an addon config.cpp (best-effort)"`
+	Convert      convertCmd      `command:"convert" description:"Rewrite a config as yaml/json, optionally namespaced (--prefix/--suffix)"`
+	VerifyConfig verifyConfigCmd `command:"verify-config" description:"Check the checksum of a portable config (extract --portable --with-checksum)"`
 }
 
 func main() {
 	var root rootCmd
 	parser := flags.NewParser(&root, flags.Default)
+	parser.CommandHandler = func(cmd flags.Commander, args []string) error {
+		if err := root.setupLog(); err != nil {
+			return err
+		}
+		if cmd == nil {
+			return nil
+		}
+		if err := cmd.Execute(args); err != nil {
+			return err
+		}
+		return root.checkWarnings()
+	}
 	if _, err := parser.Parse(); err != nil {
 		if fe, ok := err.(*flags.Error); ok && fe.Type == flags.ErrHelp {
 			return
@@ -26,6 +63,34 @@ func main() {
 	}
 }
 
+// setupLog sets the shared logger level from the global flags.
+func (r *rootCmd) setupLog() error {
+	switch {
+	case r.Quiet && r.Verbose:
+		return errors.New("--quiet and --verbose are mutually exclusive")
+	case r.Quiet:
+		cliLog.level = logQuiet
+	case r.Verbose:
+		cliLog.level = logVerbose
+	}
+
+	return nil
+}
+
+// checkWarnings fails a successful command that emitted warnings under --fail-on-warning
+// or TV4P_STRICT (any value strconv.ParseBool accepts as true).
+func (r *rootCmd) checkWarnings() error {
+	strict := r.FailOnWarning
+	if v, err := strconv.ParseBool(os.Getenv("TV4P_STRICT")); err == nil && v {
+		strict = true
+	}
+	if n := cliLog.Warnings(); strict && n > 0 {
+		return fmt.Errorf("%d warning(s) emitted (--fail-on-warning)", n)
+	}
+
+	return nil
+}
+
 type versionCmd struct{}
 
 // Execute prints the version information.
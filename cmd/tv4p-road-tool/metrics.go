@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+// encodeMetrics encodes config statistics in the Prometheus text exposition format.
+// The output is suitable for the node_exporter textfile collector.
+func encodeMetrics(cfg tv4p.RoadConfig, file string, scope tv4p.Scope) []byte {
+	var b bytes.Buffer
+	label := `file="` + escapeLabelValue(file) + `"`
+
+	if scope.IncludesRoads() {
+		var straight, corner, terminator int
+		for _, rt := range cfg.Types {
+			straight += len(rt.StraightParts)
+			corner += len(rt.CornerParts)
+			terminator += len(rt.TerminatorPart)
+		}
+
+		writeMetricHeader(&b, "tv4p_road_types", "Number of road types in the Road Tool config.")
+		fmt.Fprintf(&b, "tv4p_road_types{%s} %d\n", label, len(cfg.Types))
+
+		writeMetricHeader(&b, "tv4p_road_parts", "Number of road parts per parts list.")
+		fmt.Fprintf(&b, "tv4p_road_parts{%s,list=\"straight\"} %d\n", label, straight)
+		fmt.Fprintf(&b, "tv4p_road_parts{%s,list=\"corner\"} %d\n", label, corner)
+		fmt.Fprintf(&b, "tv4p_road_parts{%s,list=\"terminator\"} %d\n", label, terminator)
+	}
+
+	if scope.IncludesCrossroads() {
+		writeMetricHeader(&b, "tv4p_crossroads", "Number of crossroad definitions in the Road Tool config.")
+		fmt.Fprintf(&b, "tv4p_crossroads{%s} %d\n", label, len(cfg.CrossroadTypes))
+	}
+
+	return b.Bytes()
+}
+
+// writeMetricHeader writes HELP and TYPE lines for a gauge metric.
+func writeMetricHeader(b *bytes.Buffer, name string, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+}
+
+// escapeLabelValue escapes a label value: backslash, double-quote and line feed.
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
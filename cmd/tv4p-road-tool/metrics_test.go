@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestEscapeLabelValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "myworld.tv4p", want: "myworld.tv4p"},
+		{in: `my "world".tv4p`, want: `my \"world\".tv4p`},
+		{in: `a\b`, want: `a\\b`},
+		{in: "a\nb", want: `a\nb`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			if got := escapeLabelValue(tt.in); got != tt.want {
+				t.Fatalf("got=%q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeMetrics(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			{
+				Name:          "asf1",
+				StraightParts: []tv4p.RoadPart{{Name: "asf1_6"}, {Name: "asf1_12"}},
+				CornerParts:   []tv4p.RoadPart{{Name: "asf1_7 100"}},
+			},
+			{Name: "city", TerminatorPart: []tv4p.RoadPart{{Name: "city_6konec"}}},
+		},
+		CrossroadTypes: []tv4p.CrossroadType{{Name: "kr_t_asf1_city"}},
+	}
+
+	out := string(encodeMetrics(cfg, "my.tv4p", tv4p.ScopeAll))
+	for _, want := range []string{
+		`tv4p_road_types{file="my.tv4p"} 2`,
+		`tv4p_road_parts{file="my.tv4p",list="straight"} 2`,
+		`tv4p_road_parts{file="my.tv4p",list="corner"} 1`,
+		`tv4p_road_parts{file="my.tv4p",list="terminator"} 1`,
+		`tv4p_crossroads{file="my.tv4p"} 1`,
+		`# TYPE tv4p_crossroads gauge`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	roads := string(encodeMetrics(cfg, "my.tv4p", tv4p.ScopeRoads))
+	if strings.Contains(roads, "tv4p_crossroads") {
+		t.Fatalf("scope=roads emitted crossroads metric:\n%s", roads)
+	}
+}
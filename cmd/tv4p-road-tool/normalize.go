@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type normalizeCmd struct {
+	Args struct {
+		Input  string `positional-arg-name:"FILE" required:"true" description:"Input tv4p file"`
+		Output string `positional-arg-name:"OUT" description:"Output tv4p file (default: overwrite FILE)"`
+	} `positional-args:"true"`
+
+	NearOffset int    `long:"near-offset" description:"Prefer the Road Tool block closest to this byte offset"`
+	Nested     bool   `long:"nested" description:"Resolve one level of parent list nesting around the Road Tool block"`
+	Chmod      string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
+}
+
+// Execute rewrites the Road Tool region of FILE in the tool's canonical byte layout.
+func (c *normalizeCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(c.Args.Input)
+	if err != nil {
+		return err
+	}
+
+	info := inspectInput(data)
+	out, err := normalizeTV4P(data, tv4p.LocateOptions{NearOffset: c.NearOffset, Nested: c.Nested})
+	if err != nil {
+		return withCountHint(withFileHead(err, info))
+	}
+
+	outPath := c.Args.Output
+	if outPath == "" {
+		outPath = c.Args.Input
+	}
+	if bytes.Equal(out, data) && outPath == c.Args.Input {
+		cliLog.Infof("%s is already normalized", c.Args.Input)
+		return nil
+	}
+
+	if err := perm.writeFile(outPath, out); err != nil {
+		return err
+	}
+	cliLog.Infof("normalized %s -> %s (size delta: %+d bytes)", c.Args.Input, outPath, len(out)-len(data))
+
+	return nil
+}
+
+// normalizeTV4P extracts the config of data and patches it straight back (crossroads in
+// file order), so the Road Tool lists are rebuilt by the writer: IDs are kept, fields come
+// in writer order and offsets are recomputed. The result is re-extracted and must give the
+// same config, otherwise it is rejected.
+func normalizeTV4P(data []byte, loc tv4p.LocateOptions) ([]byte, error) {
+	cfg, err := tv4p.ParseRoadToolConfigWith(data, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := tv4p.PatchRoadToolWithOptions(data, cfg, tv4p.PatchOptions{
+		Locate:         loc,
+		CrossroadOrder: tv4p.CrossroadOrderKeep,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	again, err := tv4p.ParseRoadToolConfigWith(out, loc)
+	if err != nil {
+		return nil, err
+	}
+	want, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	got, err := json.Marshal(again)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(got, want) {
+		return nil, errors.New("normalize: re-extracted config differs from the original, file left unchanged")
+	}
+
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestNormalizeTV4P(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}}},
+			{Name: "city", StraightParts: []tv4p.RoadPart{{Name: "city_12", Path: `dz\roads\city_12.p3d`}}},
+		},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{Name: "kr_t_asf1_city", Model: `P:\dz\roads\kr_t_asf1_city.p3d`, Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}},
+		},
+	}
+	canonical, err := tv4p.PatchRoadTool(testTV4P(t, cfg), cfg, tv4p.ScopeAll)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	// Write the first road type with its fields in reverse order: same config, other bytes.
+	extracted, err := tv4p.ParseRoadToolConfig(canonical)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	block, err := tv4p.ParseRoadTypes(canonical)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := tv4p.AttachRoadTypeRaw(&extracted, block); err != nil {
+		t.Fatalf("attach raw: %v", err)
+	}
+	slices.Reverse(extracted.Types[0].TV4PRaw.Fields)
+	shuffled, err := tv4p.PatchRoadToolWithOptions(canonical, extracted, tv4p.PatchOptions{CrossroadOrder: tv4p.CrossroadOrderKeep})
+	if err != nil {
+		t.Fatalf("patch shuffled: %v", err)
+	}
+	if bytes.Equal(shuffled, canonical) {
+		t.Fatalf("fixture: shuffled file equals the canonical one")
+	}
+
+	out, err := normalizeTV4P(shuffled, tv4p.LocateOptions{})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if !bytes.Equal(out, canonical) {
+		t.Fatalf("normalized output differs from the canonical layout")
+	}
+
+	again, err := normalizeTV4P(out, tv4p.LocateOptions{})
+	if err != nil {
+		t.Fatalf("normalize again: %v", err)
+	}
+	if !bytes.Equal(again, out) {
+		t.Fatalf("normalize is not idempotent")
+	}
+
+	want, err := tv4p.ParseRoadToolConfig(shuffled)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	got, err := tv4p.ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("re-extracted config changed:\n%+v\nwant:\n%+v", got, want)
+	}
+}
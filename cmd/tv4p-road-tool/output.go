@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// defaultOutputMode is used for newly created output files without --chmod.
+const defaultOutputMode os.FileMode = 0o600
+
+// outputPerm holds the --chmod permissions for output files.
+type outputPerm struct {
+	mode os.FileMode // permission bits
+	set  bool        // --chmod was given: also applied to existing files
+}
+
+// parseOutputPerm parses an octal --chmod value (e.g. 644, 0644, 0o644).
+// An empty value keeps the default: 0600 for new files, existing files untouched.
+func parseOutputPerm(s string) (outputPerm, error) {
+	if s == "" {
+		return outputPerm{mode: defaultOutputMode}, nil
+	}
+
+	digits := s
+	if len(digits) > 2 && (digits[:2] == "0o" || digits[:2] == "0O") {
+		digits = digits[2:]
+	}
+
+	v, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil || v > 0o777 {
+		return outputPerm{}, fmt.Errorf("invalid --chmod %q: want octal permissions 000-777", s)
+	}
+
+	return outputPerm{mode: os.FileMode(v), set: true}, nil
+}
+
+// writeFile atomically writes data to path with the configured permissions.
+// Without --chmod an existing file keeps its mode; new files get 0600.
+func (p outputPerm) writeFile(path string, data []byte) error {
+	return p.writeFileWith(path, data, defaultFileOps)
+}
+
+// fileOps holds the steps of an atomic write that tests replace to inject failures.
+type fileOps struct {
+	write  func(w io.Writer, data []byte) error
+	rename func(oldPath, newPath string) error
+}
+
+// defaultFileOps are the real file operations.
+var defaultFileOps = fileOps{
+	write: func(w io.Writer, data []byte) error {
+		_, err := w.Write(data)
+		return err
+	},
+	rename: os.Rename,
+}
+
+// writeFileWith writes data to a temp file next to path and renames it over path,
+// so an interrupted write leaves the original intact. When the rename crosses
+// devices (e.g. path is a bind-mounted file) it falls back to copying.
+func (p outputPerm) writeFileWith(path string, data []byte, ops fileOps) error {
+	mode := p.mode
+	if st, err := os.Stat(path); err == nil && !p.set {
+		mode = st.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	if err := ops.write(tmp, data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		return err
+	}
+
+	err = ops.rename(tmpPath, path)
+	if errors.Is(err, syscall.EXDEV) {
+		return copyOver(tmpPath, path, mode)
+	}
+
+	return err
+}
+
+// copyOver copies src over dst in place; used when a rename is not possible.
+func copyOver(src, dst string, mode os.FileMode) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, data, mode); err != nil {
+		return err
+	}
+
+	return os.Chmod(dst, mode)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func TestParseOutputPerm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		mode os.FileMode
+		set  bool
+		err  bool
+	}{
+		{in: "", mode: 0o600},
+		{in: "644", mode: 0o644, set: true},
+		{in: "0640", mode: 0o640, set: true},
+		{in: "0o664", mode: 0o664, set: true},
+		{in: "000", mode: 0, set: true},
+		{in: "0778", err: true},
+		{in: "1777", err: true},
+		{in: "rw-r--r--", err: true},
+		{in: "-644", err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseOutputPerm(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want err=%v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if got.mode != tt.mode || got.set != tt.set {
+				t.Fatalf("got=%o/%v want %o/%v", got.mode, got.set, tt.mode, tt.set)
+			}
+		})
+	}
+}
+
+func TestOutputPermWriteFile(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permissions")
+	}
+
+	path := filepath.Join(t.TempDir(), "out.yaml")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	perm, err := parseOutputPerm("644")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := perm.writeFile(path, []byte("new")); err != nil {
+		t.Fatalf("writeFile: %v", err)
+	}
+
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if st.Mode().Perm() != 0o644 {
+		t.Fatalf("mode=%o want 644", st.Mode().Perm())
+	}
+}
+
+func TestOutputPermWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ops  fileOps
+		err  bool
+		want string
+	}{
+		{
+			name: "write_failure",
+			ops: fileOps{
+				write:  func(io.Writer, []byte) error { return errors.New("disk full") },
+				rename: os.Rename,
+			},
+			err:  true,
+			want: "old",
+		},
+		{
+			name: "cross_device",
+			ops: fileOps{
+				write:  defaultFileOps.write,
+				rename: func(string, string) error { return &os.LinkError{Op: "rename", Err: syscall.EXDEV} },
+			},
+			want: "new",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "out.tv4p")
+			if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			perm, _ := parseOutputPerm("")
+			err := perm.writeFileWith(path, []byte("new"), tt.ops)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want err=%v", err, tt.err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("content=%q want %q", got, tt.want)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("readdir: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("temp file left behind: %d entries", len(entries))
+			}
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
@@ -9,119 +13,412 @@ import (
 
 type patchCmd struct {
 	Args struct {
-		Input  string `positional-arg-name:"IN" required:"true" description:"Input tv4p file"`
-		Config string `positional-arg-name:"CONFIG" required:"true" description:"Config file (yaml/json)"`
+		Input  string `positional-arg-name:"IN" description:"Input tv4p file (the config file with --batch)"`
+		Config string `positional-arg-name:"CONFIG" description:"Config file (yaml/json; the output file with --remove)"`
 		Output string `positional-arg-name:"OUT" description:"Output tv4p file (default: overwrite input)"`
 	} `positional-args:"true"`
 
 	Scope        string `short:"s" long:"scope" choice:"all" choice:"roads" choice:"crossroads" default:"all" description:"What to patch: roads, crossroads, or all"`
 	Append       bool   `short:"a" long:"append" description:"Append to existing road types instead of overwriting"`
+	MergeColors  string `long:"merge-colors" choice:"last" choice:"first" choice:"average" default:"last" description:"With --append, custom colors of road types in both file and config: last (config), first (file) or average"`
 	DefaultsOnly bool   `long:"defaults-only" description:"Write only default crossroad definitions (one per road type)."`
+	NearOffset   int    `long:"near-offset" description:"Prefer the Road Tool block closest to this byte offset"`
+	Nested       bool   `long:"nested" description:"Resolve one level of parent list nesting around the Road Tool block"`
+	LimitPerType int    `long:"limit-crossroads-per-type" value-name:"N" description:"Write at most N crossroads per road type (1 = --defaults-only)"`
+	NoDefaults   bool   `long:"no-defaults" description:"Skip default crossroad selection and keep config order (see --crossroad-order)"`
+	Prefix       string `long:"prefix" description:"Prepend to road type names (references and crossroad names follow)"`
+	Suffix       string `long:"suffix" description:"Append to road type names (references and crossroad names follow)"`
+	RenameParts  bool   `long:"rename-parts" description:"With --prefix/--suffix, also rename parts named after their road type"`
+	RepairCounts bool   `long:"repair-counts" description:"Fix crossroad list (0x89/0x8A) header counts that disagree with their entries"`
+	Lists        string `long:"lists" value-name:"LIST" default:"straight,corner,terminator" description:"Part lists to rewrite for road types already in the file (others are kept as is)"`
+	WarnGrowth   string `long:"warn-growth" value-name:"LIMIT" default:"1MB,50%" description:"Warn when the output grows by more than LIMIT (bytes, KB/MB, and/or N%; 0 disables)"`
+	YAMLAdvanced bool   `long:"yaml-advanced" description:"Pre-process the config with yaml.v3: anchors, merge keys, !include, x- keys"`
+	KeepSlashes  bool   `long:"keep-slashes" description:"Write part/model paths verbatim instead of converting / to backslashes"`
+	IDBase       uint32 `long:"id-base" value-name:"ID" description:"Start newly allocated entry IDs at ID instead of after the largest used ID (0 = auto)"`
+	DedupeParts  bool   `long:"dedupe-parts" description:"Remove parts with the same path (case/slash-insensitive) within each part list, keeping the first"`
+	ColorsOnly   bool   `long:"crossroad-colors-only" description:"Only rewrite the color and custom flag of crossroad defs already in the file, in place"`
+	NameConns    bool   `long:"connections-from-name" description:"Fill crossroads without connections from their kr_t_<ab>_<c> / kr_x_<ab>_<c>[_<d>] names"`
+	AllowUndef   bool   `long:"allow-undef" description:"Leave undefined ${KEY} config references literal instead of failing"`
+	UpdateOnly   bool   `long:"update-only" description:"Only update road types already in the file (matched by name); fail on unknown names, keep the others"`
+
+	ConfigVars []string `long:"config-var" value-name:"KEY=VALUE" description:"Replace ${KEY} in the config text with VALUE (repeatable; fallback: env TV4P_VAR_KEY)"`
+
+	Remove     []string `long:"remove" value-name:"NAME" description:"Remove road types matching NAME (case-insensitive glob, e.g. asf*) and their crossroad references from the file (repeatable): patch --remove NAME IN [OUT]"`
+	RemoveMode string   `long:"remove-mode" choice:"clear" choice:"drop" default:"clear" description:"Crossroads connected to a removed road type: clear that side (drop if none left) or drop them"`
+
+	PreferShape    string `long:"prefer-shape" choice:"t" choice:"x" default:"t" description:"Crossroad shape preferred for defaults: t (kr_t_*) or x (kr_x_*)"`
+	CrossroadOrder string `long:"crossroad-order" choice:"auto" choice:"keep" choice:"match-roads" description:"Crossroad def order: auto (match road type index), keep, or match-roads (strictly by default) (default: auto, keep with --no-defaults)"`
+
+	ModelExts []string `long:"p3d-ext" value-name:"EXT" default:".p3d" description:"Model file extension that marks part paths (repeatable, case-insensitive, e.g. .p3de)"`
+
+	Chmod string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
+
+	Batch    string `long:"batch" value-name:"GLOB" description:"Patch every tv4p matching GLOB with one config: patch --batch GLOB CONFIG"`
+	InPlace  bool   `long:"in-place" description:"With --batch, overwrite inputs instead of writing <name>.patched.tv4p"`
+	FailFast bool   `long:"fail-fast" description:"With --batch, stop at the first failed file"`
+	NameTmpl string `long:"output-basename-template" value-name:"TEMPLATE" description:"With --batch, name outputs from TEMPLATE next to each input: {name}, {world}, {ext} (e.g. {name}.road.{ext})"`
+
+	vars *configVars // parsed --config-var values, set by Execute
 }
 
 // Execute patches the road types config into the input tv4p file.
 func (c *patchCmd) Execute(_ []string) error {
-	cfg, err := readConfig(c.Args.Config)
+	perm, err := parseOutputPerm(c.Chmod)
 	if err != nil {
 		return err
 	}
+	if _, err := parseGrowthLimit(c.WarnGrowth); err != nil {
+		return err
+	}
+	if c.vars, err = parseConfigVars(c.ConfigVars, c.AllowUndef); err != nil {
+		return err
+	}
+
+	if err := c.checkColorsOnly(); err != nil {
+		return err
+	}
+	if c.UpdateOnly && tv4p.Scope(c.Scope) == tv4p.ScopeCrossroad {
+		return errors.New("--update-only requires --scope roads or all")
+	}
+
+	if len(c.Remove) > 0 {
+		return c.executeRemove(perm)
+	}
+	if c.Batch != "" {
+		return c.executeBatch(perm)
+	}
+	if c.InPlace || c.FailFast || c.NameTmpl != "" {
+		return errors.New("--in-place, --fail-fast and --output-basename-template require --batch")
+	}
+	if c.Args.Input == "" || c.Args.Config == "" {
+		return errors.New("the required arguments `IN` and `CONFIG` were not provided")
+	}
+
+	outPath := c.Args.Output
+	if outPath == "" {
+		outPath = c.Args.Input
+	}
 
-	data, err := os.ReadFile(c.Args.Input)
+	return c.patchFile(c.Args.Input, c.Args.Config, outPath, perm)
+}
+
+// executeRemove removes road types from IN using the config stored in the file itself,
+// so the second positional argument is the output file: patch --remove NAME IN [OUT].
+func (c *patchCmd) executeRemove(perm outputPerm) error {
+	switch {
+	case c.Batch != "":
+		return errors.New("--remove cannot be combined with --batch")
+	case c.Args.Input == "":
+		return errors.New("the required argument `IN` was not provided")
+	case c.Args.Output != "":
+		return errors.New("--remove takes IN [OUT]: the config is read from IN")
+	case tv4p.Scope(c.Scope) != tv4p.ScopeAll:
+		return errors.New("--remove requires --scope all")
+	case c.Append:
+		return errors.New("--remove cannot be combined with --append")
+	}
+
+	outPath := c.Args.Config
+	if outPath == "" {
+		outPath = c.Args.Input
+	}
+
+	return c.patchFile(c.Args.Input, "", outPath, perm)
+}
+
+// checkColorsOnly rejects flags that have no effect with --crossroad-colors-only.
+func (c *patchCmd) checkColorsOnly() error {
+	if !c.ColorsOnly {
+		return nil
+	}
+
+	switch {
+	case tv4p.Scope(c.Scope) == tv4p.ScopeRoads:
+		return errors.New("--crossroad-colors-only requires --scope crossroads or all")
+	case len(c.Remove) > 0 || c.Append || c.Prefix != "" || c.Suffix != "":
+		return errors.New("--crossroad-colors-only cannot be combined with --remove, --append or --prefix/--suffix")
+	case c.DefaultsOnly || c.LimitPerType > 0 || c.NoDefaults:
+		return errors.New("--crossroad-colors-only cannot be combined with crossroad selection flags")
+	}
+
+	return nil
+}
+
+// recolorCrossroads writes the crossroad colors of cfg into the defs of data in place
+// (tv4p.PatchCrossroadColors); config crossroads missing from the file are warned about.
+func recolorCrossroads(data []byte, cfg tv4p.RoadConfig, loc tv4p.LocateOptions, outPath string, perm outputPerm) error {
+	if len(cfg.CrossroadTypes) == 0 {
+		return errors.New("--crossroad-colors-only: config has no crossroad_types")
+	}
+
+	out, report, err := tv4p.PatchCrossroadColors(data, cfg.CrossroadTypes, loc)
+	if err != nil {
+		return err
+	}
+	for _, name := range report.Unmatched {
+		cliLog.Warnf("crossroad %q not found in the file: skipped", name)
+	}
+
+	if err := perm.writeFile(outPath, out); err != nil {
+		return err
+	}
+	cliLog.Infof("recolored %d crossroad def(s) in %s", len(report.Updated), outPath)
+
+	return nil
+}
+
+// removeRoadTypes loads the config from the file and removes the --remove road types.
+func (c *patchCmd) removeRoadTypes(data []byte, loc tv4p.LocateOptions) (tv4p.RoadConfig, error) {
+	cfg, err := tv4p.ParseRoadToolConfigWith(data, loc)
+	if err != nil {
+		return cfg, err
+	}
+
+	report, err := tv4p.RemoveRoadTypes(&cfg, c.Remove, tv4p.RemoveMode(c.RemoveMode))
+	if err != nil {
+		return cfg, err
+	}
+	cliLog.Infof("removed road types: %s", strings.Join(report.RoadTypes, ", "))
+	if len(report.Dropped) > 0 {
+		cliLog.Infof("dropped crossroads: %s", strings.Join(report.Dropped, ", "))
+	}
+	if report.ClearedSides > 0 {
+		cliLog.Infof("cleared crossroad sides: %d", report.ClearedSides)
+	}
+
+	return cfg, nil
+}
+
+// patchFile patches one tv4p file with the config and writes the result to outPath.
+// With --remove, configPath is empty and the config is taken from the file.
+func (c *patchCmd) patchFile(inPath, configPath, outPath string, perm outputPerm) error {
+	growth, err := parseGrowthLimit(c.WarnGrowth)
+	if err != nil {
+		return err
+	}
+
+	var cfg tv4p.RoadConfig
+	if configPath != "" {
+		if cfg, err = readConfig(configPath, c.YAMLAdvanced, c.vars); err != nil {
+			return err
+		}
+	}
+
+	data, err := os.ReadFile(inPath)
 	if err != nil {
 		return err
 	}
 
 	scope := tv4p.Scope(c.Scope)
+	exts, err := parseModelExts(c.ModelExts)
+	if err != nil {
+		return err
+	}
+	loc := tv4p.LocateOptions{NearOffset: c.NearOffset, Nested: c.Nested, ModelExts: exts}
+
+	info := inspectInput(data)
+	existing, err := tv4p.ParseRoadTypesWith(data, loc)
+	if err != nil {
+		return withFileHead(err, info)
+	}
+	if c.RepairCounts {
+		if data, err = repairListCounts(data, loc); err != nil {
+			return err
+		}
+	}
+	if c.ColorsOnly {
+		return recolorCrossroads(data, cfg, loc, outPath, perm)
+	}
+	if len(c.Remove) > 0 {
+		if cfg, err = c.removeRoadTypes(data, loc); err != nil {
+			return withCountHint(err)
+		}
+	}
+
+	// Fill before namespacing: renamed crossroad names no longer split into road type names.
+	if c.NameConns {
+		if n := tv4p.FillConnectionsFromName(cfg.CrossroadTypes); n > 0 {
+			cliLog.Debugf("filled connections of %d crossroad(s) from their names", n)
+		}
+	}
+
+	// Namespace before road types are borrowed from the file: only config types are renamed.
+	namespaceRoadTypes(&cfg, namespaceOptions{Prefix: c.Prefix, Suffix: c.Suffix, Parts: c.RenameParts})
+
+	if err := c.preservePartLists(&cfg, existing, scope); err != nil {
+		return err
+	}
+
+	if !c.KeepSlashes {
+		if n := tv4p.NormalizeSlashes(&cfg); n > 0 {
+			cliLog.Debugf("normalized forward slashes in %d path(s)", n)
+		}
+	}
 
 	// For crossroads-only configs it is convenient to omit road_types in YAML.
 	// We still need road types for validation and for human-friendly stats output.
 	if scope.IncludesCrossroads() && len(cfg.Types) == 0 {
-		existing, err := tv4p.ParseRoadTypes(data)
-		if err != nil {
-			return err
-		}
 		cfg.Types = existing.Types
 	}
 
-	// By default, only write one (default) crossroad per road type.
-	// Terrain Builder often ignores crossroad variant selection and behaves as if it uses 0x89[roadTypeIndex].
-	if scope.IncludesCrossroads() && cfg.CrossroadTypes != nil && c.DefaultsOnly {
-		cfg.CrossroadTypes = selectDefaultCrossroads(cfg.CrossroadTypes, cfg.Types)
+	order, err := c.selectCrossroads(&cfg, scope)
+	if err != nil {
+		return err
 	}
 
 	if c.Append && scope.IncludesRoads() && len(cfg.Types) > 0 {
-		cfg, err = mergeConfigWithFile(cfg, data)
+		cfg, err = mergeConfigWithFile(cfg, data, loc, c.MergeColors)
 		if err != nil {
 			return err
 		}
 	}
+	if c.DedupeParts {
+		logDedupe(dedupeParts(&cfg))
+	}
 
-	out, err := tv4p.PatchRoadTool(data, cfg, scope)
-	if err != nil {
-		return err
+	if c.IDBase != 0 {
+		if maxID := tv4p.MaxEntryID(data); c.IDBase <= maxID {
+			cliLog.Warnf("--id-base 0x%X is not above the largest used ID 0x%X: new IDs skip used ones but interleave with them", c.IDBase, maxID)
+		}
 	}
 
-	outPath := c.Args.Output
-	if outPath == "" {
-		outPath = c.Args.Input
+	// Append is merged above (before --dedupe-parts), so the library option stays off;
+	// UpdateOnly still catches road types the merge added.
+	out, err := tv4p.PatchRoadToolWithOptions(data, cfg, tv4p.PatchOptions{
+		Scope:          scope,
+		UpdateOnly:     c.UpdateOnly,
+		Locate:         loc,
+		CrossroadOrder: order,
+		PreferShape:    tv4p.CrossroadShape(c.PreferShape),
+		IDBase:         c.IDBase,
+	})
+	if err != nil {
+		return withCountHint(err)
 	}
 
-	if err := os.WriteFile(outPath, out, 0o600); err != nil {
+	if err := perm.writeFile(outPath, out); err != nil {
 		return err
 	}
 
 	printPatchStats(cfg, outPath)
+	growth.warnGrowth(outPath, len(data), len(out))
 
 	return nil
 }
 
-// selectDefaultCrossroads selects the default crossroad for each road type.
-func selectDefaultCrossroads(all []tv4p.CrossroadType, roadTypes []tv4p.RoadType) []tv4p.CrossroadType {
-	if len(all) == 0 || len(roadTypes) == 0 {
-		return all
+// preservePartLists keeps the part lists not selected by --lists as they are in the file.
+func (c *patchCmd) preservePartLists(cfg *tv4p.RoadConfig, existing *tv4p.RoadTypesBlock, scope tv4p.Scope) error {
+	keep, err := parsePartLists(c.Lists)
+	if err != nil || len(keep) == 0 {
+		return err
+	}
+	if !scope.IncludesRoads() {
+		return errors.New("--lists requires --scope roads or all")
 	}
 
-	// Build explicit defaults map: roadTypeLower -> index in all.
-	explicit := map[string]int{}
-	for i := range all {
-		d := strings.TrimSpace(all[i].Default)
-		if d == "" {
-			continue
+	n, err := tv4p.PreservePartLists(cfg, existing, keep)
+	if err != nil {
+		return err
+	}
+	cliLog.Debugf("kept %v part lists of %d road type(s) from the file", keep, n)
+
+	return nil
+}
+
+// parsePartLists parses the --lists value and returns the lists to keep (not selected).
+func parsePartLists(s string) ([]tv4p.PartList, error) {
+	all := []tv4p.PartList{tv4p.ListStraight, tv4p.ListCorner, tv4p.ListTerminator}
+	selected := map[tv4p.PartList]bool{}
+	for _, v := range strings.Split(s, ",") {
+		l := tv4p.PartList(strings.ToLower(strings.TrimSpace(v)))
+		if !slices.Contains(all, l) {
+			return nil, fmt.Errorf("invalid --lists %q: %q is not straight, corner or terminator", s, v)
 		}
-		explicit[strings.ToLower(d)] = i
+		selected[l] = true
 	}
 
-	shapeScore := func(cr tv4p.CrossroadType) int {
-		if strings.HasPrefix(cr.Name, "kr_t_") {
-			return 2
+	var keep []tv4p.PartList
+	for _, l := range all {
+		if !selected[l] {
+			keep = append(keep, l)
 		}
-		if strings.HasPrefix(cr.Name, "kr_x_") {
-			return 1
+	}
+
+	return keep, nil
+}
+
+// selectCrossroads applies the crossroad selection mode to cfg and returns the def order.
+//
+// Modes are mutually exclusive:
+//   - all (no flag): write every crossroad, reorder by road type index
+//   - --defaults-only / --limit-crossroads-per-type N: keep the best N per road type
+//   - --no-defaults: write crossroads exactly as given, in config order
+//
+// An explicit --crossroad-order overrides the order implied by the mode.
+func (c *patchCmd) selectCrossroads(cfg *tv4p.RoadConfig, scope tv4p.Scope) (tv4p.CrossroadOrder, error) {
+	modes := 0
+	for _, on := range []bool{c.DefaultsOnly, c.LimitPerType > 0, c.NoDefaults} {
+		if on {
+			modes++
 		}
-		return 0
+	}
+	if modes > 1 {
+		return "", errors.New("--defaults-only, --limit-crossroads-per-type and --no-defaults are mutually exclusive")
 	}
 
-	matchScore := func(cr tv4p.CrossroadType, want string) int {
-		want = strings.ToLower(want)
-		if strings.TrimSpace(cr.Default) != "" && strings.ToLower(strings.TrimSpace(cr.Default)) == want {
-			return 1000 + shapeScore(cr)
+	order := tv4p.CrossroadOrder(c.CrossroadOrder)
+	if order == "" {
+		order = tv4p.CrossroadOrderAuto
+		if c.NoDefaults {
+			order = tv4p.CrossroadOrderKeep
 		}
+	}
 
-		abA := strings.ToLower(cr.Connections.A)
-		abB := strings.ToLower(cr.Connections.B)
-		c := strings.ToLower(cr.Connections.C)
-		d := strings.ToLower(cr.Connections.D)
+	if !scope.IncludesCrossroads() || cfg.CrossroadTypes == nil {
+		return order, nil
+	}
 
-		if abA == want && abB == want {
-			return 100 + shapeScore(cr)
-		}
-		if abA == want || abB == want {
-			return 80 + shapeScore(cr)
+	if (c.DefaultsOnly || c.LimitPerType > 0) && hasConnectionIndices(cfg.CrossroadTypes) {
+		return "", errors.New("connection_indices cannot be combined with --defaults-only or --limit-crossroads-per-type")
+	}
+
+	// Terrain Builder often ignores crossroad variant selection and behaves as if it uses 0x89[roadTypeIndex].
+	prefer := tv4p.CrossroadShape(c.PreferShape)
+	switch {
+	case c.DefaultsOnly:
+		cfg.CrossroadTypes = selectDefaultCrossroads(cfg.CrossroadTypes, cfg.Types, prefer)
+	case c.LimitPerType > 0:
+		cfg.CrossroadTypes = limitCrossroadsPerType(cfg.CrossroadTypes, cfg.Types, c.LimitPerType, prefer)
+	}
+
+	return order, nil
+}
+
+// hasConnectionIndices reports whether any crossroad carries raw connection indices.
+func hasConnectionIndices(crossroads []tv4p.CrossroadType) bool {
+	for _, cr := range crossroads {
+		if cr.ConnectionIndices != nil {
+			return true
 		}
-		if c == want || d == want {
-			return 60 + shapeScore(cr)
+	}
+
+	return false
+}
+
+// selectDefaultCrossroads selects the default crossroad for each road type.
+func selectDefaultCrossroads(all []tv4p.CrossroadType, roadTypes []tv4p.RoadType, prefer tv4p.CrossroadShape) []tv4p.CrossroadType {
+	if len(all) == 0 || len(roadTypes) == 0 {
+		return all
+	}
+
+	// Build explicit defaults map: roadTypeLower -> index in all.
+	explicit := map[string]int{}
+	for i := range all {
+		d := strings.TrimSpace(all[i].Default)
+		if d == "" {
+			continue
 		}
-		return -1
+		explicit[strings.ToLower(d)] = i
 	}
 
 	var out []tv4p.CrossroadType
@@ -144,11 +441,12 @@ func selectDefaultCrossroads(all []tv4p.CrossroadType, roadTypes []tv4p.RoadType
 		}
 
 		// Otherwise pick the best match for this road type.
+		// Ties are broken by name so the choice does not depend on input order.
 		best := -1
 		bestScore := -1
 		for i := range all {
-			s := matchScore(all[i], want)
-			if s > bestScore {
+			s := crossroadMatchScore(all[i], want, prefer)
+			if s > bestScore || (s == bestScore && best >= 0 && all[i].Name < all[best].Name) {
 				bestScore = s
 				best = i
 			}
@@ -170,3 +468,92 @@ func selectDefaultCrossroads(all []tv4p.CrossroadType, roadTypes []tv4p.RoadType
 
 	return out
 }
+
+// limitCrossroadsPerType keeps at most n crossroads per road type.
+//
+// The first crossroad per road type is the default picked by selectDefaultCrossroads
+// (so n=1 is exactly --defaults-only). Up to n-1 further crossroads per road type are
+// added by best match score (ties by name); they follow all defaults in the output
+// so crossroad[i] stays the default of road_types[i] for TB's index fallback.
+func limitCrossroadsPerType(all []tv4p.CrossroadType, roadTypes []tv4p.RoadType, n int, prefer tv4p.CrossroadShape) []tv4p.CrossroadType {
+	if n <= 0 || len(all) == 0 || len(roadTypes) == 0 {
+		return all
+	}
+
+	out := selectDefaultCrossroads(all, roadTypes, prefer)
+	if n == 1 {
+		return out
+	}
+
+	used := map[string]struct{}{}
+	kept := map[string]int{} // roadTypeLower -> crossroads kept
+	for _, cr := range out {
+		used[strings.ToLower(cr.Name)] = struct{}{}
+		if d := strings.ToLower(strings.TrimSpace(cr.Default)); d != "" {
+			kept[d]++
+		}
+	}
+
+	var extra []tv4p.CrossroadType
+	for _, rt := range roadTypes {
+		want := strings.TrimSpace(rt.Name)
+		if want == "" {
+			continue
+		}
+		key := strings.ToLower(want)
+
+		var candidates []int
+		for i := range all {
+			if _, ok := used[strings.ToLower(all[i].Name)]; ok {
+				continue
+			}
+			if crossroadMatchScore(all[i], want, prefer) >= 0 {
+				candidates = append(candidates, i)
+			}
+		}
+		sort.SliceStable(candidates, func(a, b int) bool {
+			sa := crossroadMatchScore(all[candidates[a]], want, prefer)
+			sb := crossroadMatchScore(all[candidates[b]], want, prefer)
+			if sa != sb {
+				return sa > sb
+			}
+			return all[candidates[a]].Name < all[candidates[b]].Name
+		})
+
+		for _, i := range candidates {
+			if kept[key] >= n {
+				break
+			}
+			extra = append(extra, all[i])
+			used[strings.ToLower(all[i].Name)] = struct{}{}
+			kept[key]++
+		}
+	}
+
+	return append(out, extra...)
+}
+
+// crossroadMatchScore scores how well a crossroad fits as the default for a road type.
+// Negative means the road type is not connected at all.
+func crossroadMatchScore(cr tv4p.CrossroadType, want string, prefer tv4p.CrossroadShape) int {
+	want = strings.ToLower(want)
+	if strings.TrimSpace(cr.Default) != "" && strings.ToLower(strings.TrimSpace(cr.Default)) == want {
+		return 1000 + tv4p.CrossroadShapeScore(cr, prefer)
+	}
+
+	abA := strings.ToLower(cr.Connections.A)
+	abB := strings.ToLower(cr.Connections.B)
+	c := strings.ToLower(cr.Connections.C)
+	d := strings.ToLower(cr.Connections.D)
+
+	if abA == want && abB == want {
+		return 100 + tv4p.CrossroadShapeScore(cr, prefer)
+	}
+	if abA == want || abB == want {
+		return 80 + tv4p.CrossroadShapeScore(cr, prefer)
+	}
+	if c == want || d == want {
+		return 60 + tv4p.CrossroadShapeScore(cr, prefer)
+	}
+	return -1
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestSelectDefaultCrossroadsTieBreak(t *testing.T) {
+	t.Parallel()
+
+	roadTypes := []tv4p.RoadType{{Name: "asf1"}}
+	// Both score the same for asf1 (A==B==asf1, T shape).
+	a := tv4p.CrossroadType{Name: "kr_t_asf1_city", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}}
+	b := tv4p.CrossroadType{Name: "kr_t_asf1_asf2", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf2"}}
+
+	for _, order := range [][]tv4p.CrossroadType{{a, b}, {b, a}} {
+		out := selectDefaultCrossroads(order, roadTypes, tv4p.ShapeT)
+		if len(out) != 1 {
+			t.Fatalf("got %d crossroads want 1", len(out))
+		}
+		if out[0].Name != "kr_t_asf1_asf2" {
+			t.Fatalf("default=%q want %q", out[0].Name, "kr_t_asf1_asf2")
+		}
+	}
+}
+
+func TestSelectDefaultCrossroadsPreferShape(t *testing.T) {
+	t.Parallel()
+
+	roadTypes := []tv4p.RoadType{{Name: "asf1"}}
+	all := []tv4p.CrossroadType{
+		{Name: "kr_t_asf1_asf1", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf1"}},
+		{Name: "kr_x_asf1_asf1", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf1", D: "asf1"}},
+	}
+
+	tests := []struct {
+		prefer tv4p.CrossroadShape
+		want   string
+	}{
+		{prefer: "", want: "kr_t_asf1_asf1"},
+		{prefer: tv4p.ShapeT, want: "kr_t_asf1_asf1"},
+		{prefer: tv4p.ShapeX, want: "kr_x_asf1_asf1"},
+	}
+
+	for _, tt := range tests {
+		out := selectDefaultCrossroads(all, roadTypes, tt.prefer)
+		if len(out) != 1 || out[0].Name != tt.want {
+			t.Fatalf("prefer=%q default=%+v want %q", tt.prefer, out, tt.want)
+		}
+	}
+}
+
+func TestLimitCrossroadsPerType(t *testing.T) {
+	t.Parallel()
+
+	roadTypes := []tv4p.RoadType{{Name: "asf1"}, {Name: "city"}}
+	all := []tv4p.CrossroadType{
+		{Name: "kr_x_asf1_city", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city", D: "city"}},
+		{Name: "kr_t_asf1_city", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}},
+		{Name: "kr_t_asf1_asf2", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf2"}},
+		{Name: "kr_t_city_city", Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city"}},
+		{Name: "kr_x_city_city", Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city", D: "city"}},
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		{name: "disabled", n: 0, want: []string{"kr_x_asf1_city", "kr_t_asf1_city", "kr_t_asf1_asf2", "kr_t_city_city", "kr_x_city_city"}},
+		{name: "one", n: 1, want: []string{"kr_t_asf1_asf2", "kr_t_city_city"}},
+		{name: "two", n: 2, want: []string{"kr_t_asf1_asf2", "kr_t_city_city", "kr_t_asf1_city", "kr_x_city_city"}},
+		{name: "all", n: 10, want: []string{"kr_t_asf1_asf2", "kr_t_city_city", "kr_t_asf1_city", "kr_x_asf1_city", "kr_x_city_city"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out := limitCrossroadsPerType(all, roadTypes, tt.n, tv4p.ShapeT)
+			if len(out) != len(tt.want) {
+				t.Fatalf("got %d crossroads want %d", len(out), len(tt.want))
+			}
+			for i := range tt.want {
+				if out[i].Name != tt.want[i] {
+					t.Fatalf("out[%d]=%q want %q", i, out[i].Name, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// N=1 must match --defaults-only exactly.
+	def := selectDefaultCrossroads(all, roadTypes, tv4p.ShapeT)
+	one := limitCrossroadsPerType(all, roadTypes, 1, tv4p.ShapeT)
+	if !reflect.DeepEqual(def, one) {
+		t.Fatalf("n=1 differs from defaults-only: %+v vs %+v", one, def)
+	}
+}
+
+func TestPatchSelectCrossroads(t *testing.T) {
+	t.Parallel()
+
+	roadTypes := []tv4p.RoadType{{Name: "asf1"}, {Name: "city"}}
+	all := []tv4p.CrossroadType{
+		{Name: "kr_x_city_city", Connections: tv4p.CrossroadConnections{A: "city", B: "city", C: "city", D: "city"}},
+		{Name: "kr_t_asf1_city", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}},
+		{Name: "kr_x_asf1_city", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city", D: "city"}},
+	}
+
+	tests := []struct {
+		name  string
+		cmd   patchCmd
+		want  []string
+		order tv4p.CrossroadOrder
+		err   bool
+	}{
+		{name: "all", want: []string{"kr_x_city_city", "kr_t_asf1_city", "kr_x_asf1_city"}, order: tv4p.CrossroadOrderAuto},
+		{name: "defaults_only", cmd: patchCmd{DefaultsOnly: true}, want: []string{"kr_t_asf1_city", "kr_x_city_city"}, order: tv4p.CrossroadOrderAuto},
+		{name: "no_defaults", cmd: patchCmd{NoDefaults: true}, want: []string{"kr_x_city_city", "kr_t_asf1_city", "kr_x_asf1_city"}, order: tv4p.CrossroadOrderKeep},
+		{name: "no_defaults_auto", cmd: patchCmd{NoDefaults: true, CrossroadOrder: "auto"}, want: []string{"kr_x_city_city", "kr_t_asf1_city", "kr_x_asf1_city"}, order: tv4p.CrossroadOrderAuto},
+		{name: "defaults_and_no_defaults", cmd: patchCmd{DefaultsOnly: true, NoDefaults: true}, err: true},
+		{name: "limit_and_no_defaults", cmd: patchCmd{LimitPerType: 2, NoDefaults: true}, err: true},
+		{name: "defaults_and_limit", cmd: patchCmd{DefaultsOnly: true, LimitPerType: 2}, err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := tv4p.RoadConfig{Types: roadTypes, CrossroadTypes: append([]tv4p.CrossroadType(nil), all...)}
+			order, err := tt.cmd.selectCrossroads(&cfg, tv4p.ScopeAll)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want err=%v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if order != tt.order {
+				t.Fatalf("order=%q want %q", order, tt.order)
+			}
+			if len(cfg.CrossroadTypes) != len(tt.want) {
+				t.Fatalf("got %d crossroads want %d", len(cfg.CrossroadTypes), len(tt.want))
+			}
+			for i := range tt.want {
+				if cfg.CrossroadTypes[i].Name != tt.want[i] {
+					t.Fatalf("crossroads[%d]=%q want %q", i, cfg.CrossroadTypes[i].Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParsePartLists(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		keep []tv4p.PartList
+		err  bool
+	}{
+		{in: "straight,corner,terminator"},
+		{in: "corner", keep: []tv4p.PartList{tv4p.ListStraight, tv4p.ListTerminator}},
+		{in: " Straight , terminator", keep: []tv4p.PartList{tv4p.ListCorner}},
+		{in: "corner,corner", keep: []tv4p.PartList{tv4p.ListStraight, tv4p.ListTerminator}},
+		{in: "", err: true},
+		{in: "corners", err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.in, func(t *testing.T) {
+			t.Parallel()
+
+			keep, err := parsePartLists(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want error %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(keep, tt.keep) {
+				t.Fatalf("keep=%v want %v", keep, tt.keep)
+			}
+		})
+	}
+}
+
+func TestMergeRoadTypesColors(t *testing.T) {
+	t.Parallel()
+
+	first := tv4p.Color{R: 100, G: 40, B: 200, A: 255}
+	last := tv4p.Color{R: 200, G: 60, B: 100, A: 255}
+
+	tests := []struct {
+		policy string
+		want   tv4p.Color
+	}{
+		{policy: mergeColorsFirst, want: first},
+		{policy: mergeColorsLast, want: last},
+		{policy: mergeColorsAverage, want: tv4p.Color{R: 150, G: 50, B: 150, A: 255}},
+		{policy: "", want: last},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.policy, func(t *testing.T) {
+			t.Parallel()
+
+			existing := []tv4p.RoadType{
+				{Name: "asf1", NormalCustom: true, NormalColor: first, KeyColor: first},
+				{Name: "city"},
+			}
+			incoming := []tv4p.RoadType{
+				{Name: "asf1", NormalCustom: true, NormalColor: last, KeyCustom: true, KeyColor: last},
+				{Name: "asf2"},
+			}
+
+			got := mergeRoadTypes(existing, incoming, tt.policy)
+			if len(got) != 3 || got[2].Name != "asf2" {
+				t.Fatalf("types=%+v want asf1, city, asf2", got)
+			}
+			if !got[0].NormalCustom || got[0].NormalColor != tt.want {
+				t.Fatalf("normal=%v custom=%v want %v", got[0].NormalColor, got[0].NormalCustom, tt.want)
+			}
+			// Only the incoming key color is custom, so it wins under every policy.
+			if !got[0].KeyCustom || got[0].KeyColor != last {
+				t.Fatalf("key=%v custom=%v want %v", got[0].KeyColor, got[0].KeyCustom, last)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type probeCmd struct {
+	Args struct {
+		Input string `positional-arg-name:"FILE" required:"true" description:"tv4p file to check"`
+	} `positional-args:"true"`
+
+	Format     string `short:"f" long:"format" choice:"text" choice:"json" default:"text" description:"Output format"`
+	NearOffset int    `long:"near-offset" description:"Prefer the Road Tool block closest to this byte offset"`
+	Nested     bool   `long:"nested" description:"Resolve one level of parent list nesting around the Road Tool block"`
+}
+
+// Execute reports which Road Tool lists the file has and fails when roads are missing.
+func (c *probeCmd) Execute(_ []string) error {
+	data, err := os.ReadFile(c.Args.Input)
+	if err != nil {
+		return err
+	}
+
+	r := tv4p.Probe(data, tv4p.LocateOptions{NearOffset: c.NearOffset, Nested: c.Nested})
+	if c.Format == "json" {
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stdout.Write(append(out, '\n')); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("roads: %s\n", yesNo(r.Roads))
+		fmt.Printf("crossroad_defs: %s\n", yesNo(r.CrossroadDefs))
+		fmt.Printf("crossroad_links: %s\n", yesNo(r.CrossroadLinks))
+		if r.Roads {
+			fmt.Printf("offset: 0x%X\n", r.RoadsOffset)
+			fmt.Printf("road_types: %d\n", r.RoadTypes)
+		}
+	}
+
+	if !r.Roads {
+		return fmt.Errorf("%s: not patchable: %s", c.Args.Input, r.Error)
+	}
+
+	return nil
+}
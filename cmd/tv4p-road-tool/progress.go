@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Progress defaults for the generate scan (--progress).
+const (
+	progressEvery    = 1000        // print after this many files
+	progressInterval = time.Second // or after this much time, whichever comes first
+)
+
+// scanProgress prints a periodic file count while scanning.
+// Each report is one whole line, so it interleaves cleanly with --verbose output;
+// a nil *scanProgress is disabled.
+type scanProgress struct {
+	w        io.Writer        // destination (stderr)
+	every    int              // files between reports
+	interval time.Duration    // time between reports
+	now      func() time.Time // clock (tests)
+
+	files int       // files seen
+	start time.Time // scan start
+	last  time.Time // last report
+	since int       // files since the last report
+}
+
+// newScanProgress returns a progress reporter writing to w.
+func newScanProgress(w io.Writer) *scanProgress {
+	p := &scanProgress{w: w, every: progressEvery, interval: progressInterval, now: time.Now}
+	p.start = p.now()
+	p.last = p.start
+
+	return p
+}
+
+// tick counts one file and reports when N files or the interval have passed.
+func (p *scanProgress) tick() {
+	if p == nil {
+		return
+	}
+
+	p.files++
+	p.since++
+	if p.since < p.every && p.now().Sub(p.last) < p.interval {
+		return
+	}
+
+	p.last = p.now()
+	p.since = 0
+	_, _ = fmt.Fprintf(p.w, "progress: %d file(s) scanned\n", p.files)
+}
+
+// done prints the final total.
+func (p *scanProgress) done() {
+	if p == nil {
+		return
+	}
+
+	elapsed := p.now().Sub(p.start).Round(time.Millisecond)
+	_, _ = fmt.Fprintf(p.w, "progress: %d file(s) scanned in %s\n", p.files, elapsed)
+}
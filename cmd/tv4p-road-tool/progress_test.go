@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanProgress(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	clock := time.Unix(0, 0)
+	p := newScanProgress(&buf)
+	p.every = 3
+	p.now = func() time.Time { return clock }
+	p.start, p.last = clock, clock
+
+	for i := 0; i < 7; i++ {
+		p.tick() // reports at 3 and 6
+	}
+	clock = clock.Add(1500 * time.Millisecond)
+	p.tick() // interval elapsed: reports at 8
+	p.done()
+
+	want := []string{
+		"progress: 3 file(s) scanned",
+		"progress: 6 file(s) scanned",
+		"progress: 8 file(s) scanned",
+		"progress: 8 file(s) scanned in 1.5s",
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("lines=%q want %q", got, want)
+	}
+
+	var off *scanProgress
+	off.tick()
+	off.done()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect emitted by the schema command.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+type schemaCmd struct {
+	Args struct {
+		Output string `positional-arg-name:"OUT" description:"Output schema file (default: stdout)"`
+	} `positional-args:"true"`
+
+	Portable bool   `short:"p" long:"portable" description:"Emit the schema of the portable config (extract --portable)"`
+	Chmod    string `long:"chmod" value-name:"MODE" description:"Octal permissions for the output file (default: 0600 for new files)"`
+}
+
+// Execute writes a JSON Schema for the config format.
+func (c *schemaCmd) Execute(_ []string) error {
+	perm, err := parseOutputPerm(c.Chmod)
+	if err != nil {
+		return err
+	}
+
+	var root reflect.Type
+	title := "tv4p-road-tool config"
+	if c.Portable {
+		root = reflect.TypeOf(tv4p.PortableConfig{})
+		title = "tv4p-road-tool portable config"
+	} else {
+		root = reflect.TypeOf(tv4p.RoadConfig{})
+	}
+
+	schema, err := configSchema(root, title)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+
+	if c.Args.Output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	return perm.writeFile(c.Args.Output, out)
+}
+
+// configSchema builds a JSON Schema for a config struct from its json tags.
+// Nested structs go to $defs by type name (raw entries are recursive).
+// Field types without a schema mapping are an error.
+func configSchema(root reflect.Type, title string) (map[string]any, error) {
+	defs := map[string]any{}
+	schema, err := structSchema(root, defs)
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = title
+	if len(defs) > 0 {
+		schema["$defs"] = defs
+	}
+
+	return schema, nil
+}
+
+// structSchema describes a struct as a closed object. No property is required:
+// configs may leave out any field and get its zero value.
+func structSchema(t reflect.Type, defs map[string]any) (map[string]any, error) {
+	props := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := jsonFieldName(f)
+		if !ok {
+			continue
+		}
+		s, err := typeSchema(f.Type, defs)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+		props[name] = s
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}, nil
+}
+
+// typeSchema describes a Go type, registering named structs in defs.
+func typeSchema(t reflect.Type, defs map[string]any) (map[string]any, error) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), defs)
+	case reflect.Struct:
+		ref := map[string]any{"$ref": "#/$defs/" + t.Name()}
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = nil // placeholder for recursive types
+			s, err := structSchema(t, defs)
+			if err != nil {
+				delete(defs, t.Name())
+				return nil, err
+			}
+			defs[t.Name()] = s
+		}
+		return ref, nil
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(t.Elem(), defs)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "minimum": 0, "maximum": uint64(math.MaxUint64 >> (64 - t.Bits()))}, nil
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	default:
+		return nil, fmt.Errorf("schema: unsupported kind %s", t.Kind())
+	}
+}
+
+// jsonFieldName returns the json name of an exported struct field; ok is false for skipped fields.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+
+	return name, true
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestConfigSchemaCoversFields(t *testing.T) {
+	t.Parallel()
+
+	for _, root := range []reflect.Type{reflect.TypeOf(tv4p.RoadConfig{}), reflect.TypeOf(tv4p.PortableConfig{})} {
+		schema, err := configSchema(root, "test")
+		if err != nil {
+			t.Fatalf("%s: %v", root.Name(), err)
+		}
+		defs, _ := schema["$defs"].(map[string]any)
+
+		seen := map[reflect.Type]bool{}
+		var check func(t reflect.Type, obj map[string]any)
+		check = func(typ reflect.Type, obj map[string]any) {
+			if seen[typ] {
+				return
+			}
+			seen[typ] = true
+
+			props, _ := obj["properties"].(map[string]any)
+			for i := 0; i < typ.NumField(); i++ {
+				f := typ.Field(i)
+				name, ok := jsonFieldName(f)
+				if !ok {
+					continue
+				}
+				if _, ok := props[name]; !ok {
+					t.Fatalf("%s.%s: property %q missing from schema", typ.Name(), f.Name, name)
+				}
+
+				ft := f.Type
+				for ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice {
+					ft = ft.Elem()
+				}
+				if ft.Kind() != reflect.Struct {
+					continue
+				}
+				def, ok := defs[ft.Name()].(map[string]any)
+				if !ok {
+					t.Fatalf("%s: $defs entry missing", ft.Name())
+				}
+				check(ft, def)
+			}
+		}
+		check(root, schema)
+	}
+}
+
+func TestTypeSchemaIntegerRange(t *testing.T) {
+	t.Parallel()
+
+	got, err := typeSchema(reflect.TypeOf(uint16(0)), map[string]any{})
+	if err != nil {
+		t.Fatalf("typeSchema: %v", err)
+	}
+	if got["maximum"] != uint64(0xFFFF) {
+		t.Fatalf("uint16 maximum=%v want 65535", got["maximum"])
+	}
+}
+
+func TestConfigSchemaUnsupportedKind(t *testing.T) {
+	t.Parallel()
+
+	type inner struct {
+		Weight float64 `json:"weight"`
+	}
+	type outer struct {
+		Items []inner `json:"items"`
+	}
+
+	_, err := configSchema(reflect.TypeOf(outer{}), "test")
+	if err == nil || !strings.Contains(err.Error(), "inner.Weight") || !strings.Contains(err.Error(), "float64") {
+		t.Fatalf("err=%v want unsupported float64 at inner.Weight", err)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/invopop/yaml"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+// generateTemplate maps road type and crossroad names to colors and defaults (generate --template).
+type generateTemplate map[string]templateEntry
+
+// templateEntry seeds one road type or crossroad. Unset fields keep the generated values.
+type templateEntry struct {
+	NormalColor      *tv4p.Color `json:"normal_color,omitempty"`      // normal parts color (custom)
+	KeyColor         *tv4p.Color `json:"key_color,omitempty"`         // key parts color (custom)
+	DefaultCrossroad string      `json:"default_crossroad,omitempty"` // crossroad name used as default
+
+	Color       *tv4p.Color `json:"color,omitempty"`        // crossroad color, kept instead of the mixed one
+	ColorCustom *bool       `json:"color_custom,omitempty"` // crossroad: false = TB standard color
+}
+
+// readTemplate reads a generate template file (yaml/json), gzipped or with a BOM
+// like any other config (see readConfigFile and trimConfigText).
+func readTemplate(path string) (generateTemplate, error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tmpl generateTemplate
+	if err := yaml.Unmarshal(trimConfigText(raw), &tmpl); err != nil {
+		return nil, fmt.Errorf("template %s: %w", path, err)
+	}
+
+	return tmpl, nil
+}
+
+// templateByName indexes the template by lowercase name.
+func templateByName(tmpl generateTemplate) map[string]templateEntry {
+	byName := make(map[string]templateEntry, len(tmpl))
+	for name, e := range tmpl {
+		byName[strings.ToLower(name)] = e
+	}
+
+	return byName
+}
+
+// applyRoadTemplate overrides road type palette colors with the template. It runs before
+// crossroad colors are mixed, so crossroads follow the templated road colors.
+// Road types are matched case-insensitively; others keep their generated colors.
+func applyRoadTemplate(roadTypes []tv4p.RoadType, tmpl generateTemplate) {
+	byName := templateByName(tmpl)
+	for i := range roadTypes {
+		rt := &roadTypes[i]
+		e, ok := byName[strings.ToLower(rt.Name)]
+		if !ok {
+			continue
+		}
+
+		if e.NormalColor != nil {
+			rt.NormalColor, rt.NormalCustom = *e.NormalColor, true
+		}
+		if e.KeyColor != nil {
+			rt.KeyColor, rt.KeyCustom = *e.KeyColor, true
+		}
+	}
+}
+
+// applyTemplate applies the crossroad side of the template, after crossroad colors are
+// mixed and defaults assigned: road type default_crossroad and crossroad colors.
+// Names are matched case-insensitively; names missing from the template keep their
+// generated values. A default_crossroad that does not exist or does not connect the road
+// type is reported and ignored. Crossroad colors from the template replace the mixed
+// connection colors. Road type colors are applied earlier, see applyRoadTemplate.
+func applyTemplate(roadTypes []tv4p.RoadType, crossroads []tv4p.CrossroadType, tmpl generateTemplate) {
+	byName := templateByName(tmpl)
+	names := make([]string, 0, len(tmpl))
+	for name := range tmpl {
+		names = append(names, name)
+	}
+
+	used := map[string]bool{}
+	for i := range roadTypes {
+		rt := &roadTypes[i]
+		key := strings.ToLower(rt.Name)
+		e, ok := byName[key]
+		if !ok {
+			continue
+		}
+		used[key] = true
+
+		if e.DefaultCrossroad != "" {
+			setCrossroadDefault(crossroads, rt.Name, e.DefaultCrossroad)
+		}
+	}
+
+	for i := range crossroads {
+		key := strings.ToLower(crossroads[i].Name)
+		e, ok := byName[key]
+		if !ok {
+			continue
+		}
+		used[key] = true
+		applyCrossroadTemplate(&crossroads[i], e)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if !used[strings.ToLower(name)] {
+			cliLog.Debugf("template: road type %q not generated, skipped", name)
+		}
+	}
+}
+
+// applyCrossroadTemplate sets the crossroad color of a template entry: color makes it
+// custom unless color_custom is false, and color_custom: false alone selects the
+// standard TB color.
+func applyCrossroadTemplate(cr *tv4p.CrossroadType, e templateEntry) {
+	if e.Color != nil {
+		cr.Color, cr.ColorCustom = *e.Color, true
+	}
+	if e.ColorCustom != nil && !*e.ColorCustom {
+		cr.ColorCustom = false
+		if e.Color == nil {
+			cr.Color = tv4p.Color{}
+		}
+	}
+}
+
+// setCrossroadDefault makes the named crossroad the default of a road type.
+// Taking over the default of another road type leaves that one without a default,
+// which is reported.
+func setCrossroadDefault(crossroads []tv4p.CrossroadType, roadType, crossroad string) {
+	idx := -1
+	for i := range crossroads {
+		if strings.EqualFold(crossroads[i].Name, crossroad) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		cliLog.Warnf("template: default crossroad %q for %q not found", crossroad, roadType)
+		return
+	}
+	c := crossroads[idx].Connections
+	if !slices.ContainsFunc([]string{c.A, c.B, c.C, c.D}, func(s string) bool { return strings.EqualFold(s, roadType) }) {
+		cliLog.Warnf("template: crossroad %q does not connect %q, default not changed", crossroad, roadType)
+		return
+	}
+
+	if prev := crossroads[idx].Default; prev != "" && !strings.EqualFold(prev, roadType) {
+		cliLog.Warnf("template: crossroad %q was the default of %q, which now has no default", crossroads[idx].Name, prev)
+	}
+
+	for i := range crossroads {
+		if strings.EqualFold(crossroads[i].Default, roadType) {
+			crossroads[i].Default = ""
+		}
+	}
+	crossroads[idx].Default = roadType
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestApplyTemplate(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "template.yaml")
+	// Read like any other config: a BOM is fine.
+	if err := os.WriteFile(path, []byte("\xEF\xBB\xBF"+`
+ASF1:
+  normal_color: {r: 1, g: 2, b: 3, a: 255}
+  default_crossroad: kr_x_asf1_city
+city:
+  key_color: {r: 9, g: 9, b: 9, a: 255}
+  default_crossroad: kr_t_asf1_asf1
+missing:
+  normal_color: {r: 0, g: 0, b: 0, a: 255}
+`), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	tmpl, err := readTemplate(path)
+	if err != nil {
+		t.Fatalf("readTemplate: %v", err)
+	}
+
+	palette := tv4p.Color{R: 100, G: 100, B: 100, A: 255}
+	roadTypes := []tv4p.RoadType{
+		{Name: "asf1", NormalColor: palette, KeyColor: palette},
+		{Name: "city", NormalColor: palette, KeyColor: palette},
+		{Name: "asf2", NormalColor: palette, KeyColor: palette},
+	}
+	crossroads := []tv4p.CrossroadType{
+		{Name: "kr_t_asf1_asf1", Default: "asf1", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf1"}},
+		{Name: "kr_x_asf1_city", Default: "city", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city", D: "city"}},
+	}
+
+	applyRoadTemplate(roadTypes, tmpl)
+	applyTemplate(roadTypes, crossroads, tmpl)
+
+	if got := roadTypes[0]; got.NormalColor != (tv4p.Color{R: 1, G: 2, B: 3, A: 255}) || !got.NormalCustom || got.KeyColor != palette || got.KeyCustom {
+		t.Fatalf("asf1=%+v want template normal color only", got)
+	}
+	if got := roadTypes[1]; got.KeyColor != (tv4p.Color{R: 9, G: 9, B: 9, A: 255}) || !got.KeyCustom || got.NormalColor != palette {
+		t.Fatalf("city=%+v want template key color only", got)
+	}
+	if got := roadTypes[2]; got.NormalColor != palette || got.NormalCustom {
+		t.Fatalf("asf2=%+v want untouched", got)
+	}
+
+	// asf1 default moves to the X crossroad, taking it from city (reported);
+	// city's template default does not connect city.
+	if crossroads[0].Default != "" || crossroads[1].Default != "asf1" {
+		t.Fatalf("defaults=%q,%q want \"\",asf1", crossroads[0].Default, crossroads[1].Default)
+	}
+}
+
+func TestGenerateConfigTemplateCrossroadColor(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"asf1_12.p3d", "kr_t_asf1_asf1.p3d", "kr_x_asf1_asf1.p3d", "kr_t_asf1_asf2.p3d"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("MLOD"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	explicit := tv4p.Color{R: 12, G: 34, B: 56, A: 255}
+	off := false
+	tmpl := generateTemplate{
+		"KR_T_ASF1_ASF1": {Color: &explicit},
+		"kr_x_asf1_asf1": {ColorCustom: &off},
+	}
+	cfg, _, err := generateConfig(context.Background(), []string{dir}, generateOptions{Template: tmpl})
+	if err != nil {
+		t.Fatalf("generateConfig: %v", err)
+	}
+
+	got := map[string]tv4p.CrossroadType{}
+	for _, cr := range cfg.CrossroadTypes {
+		got[cr.Name] = cr
+	}
+	if cr := got["kr_t_asf1_asf1"]; !cr.ColorCustom || cr.Color != explicit {
+		t.Fatalf("%s: custom=%v color=%v want custom %v", cr.Name, cr.ColorCustom, cr.Color, explicit)
+	}
+	if cr := got["kr_x_asf1_asf1"]; cr.ColorCustom || cr.Color != (tv4p.Color{}) {
+		t.Fatalf("%s: custom=%v color=%v want standard color", cr.Name, cr.ColorCustom, cr.Color)
+	}
+	if cr := got["kr_t_asf1_asf2"]; !cr.ColorCustom || cr.Color == explicit {
+		t.Fatalf("%s: custom=%v color=%v want mixed color", cr.Name, cr.ColorCustom, cr.Color)
+	}
+}
+
+func TestGenerateConfigTemplateRoadColorMix(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"asf1_12.p3d", "kr_t_asf1_asf1.p3d"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("MLOD"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	road := tv4p.Color{R: 200, G: 40, B: 80, A: 255}
+	cfg, _, err := generateConfig(context.Background(), []string{dir}, generateOptions{
+		Template: generateTemplate{"asf1": {NormalColor: &road}},
+	})
+	if err != nil {
+		t.Fatalf("generateConfig: %v", err)
+	}
+
+	if got := cfg.Types[0].NormalColor; got != road {
+		t.Fatalf("asf1 color=%v want %v", got, road)
+	}
+	want := roadparts.DarkenColor(roadparts.MixColors(road, road, road), 0.75)
+	if got := cfg.CrossroadTypes[0].Color; got != want {
+		t.Fatalf("crossroad color=%v want %v mixed from the templated road color", got, want)
+	}
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/p3d"
+	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+// canonicalizeConnections orders symmetric crossroad connections for stable diffs.
+//
+// A and B are the two ends of the through road, so they are ordered as A <= B.
+// For X shapes C and D are the two ends of the crossing road and are ordered as C <= D.
+// Pairs with an empty side are left as is: an unconnected end stays where it is.
+// Crossroads with unknown shape (no explicit shape, no `kr_t_`/`kr_x_` prefix) are left untouched.
+//
+// A swap is applied to the raw entries too, so patch writes what the config says: the
+// road type indices (0x84..0x87) of tv4p_def, the side lists (0x92..0x95) of tv4p_link
+// and the sides of tv4p_side_refs. The swapped ends are still a physical change in TB.
+func canonicalizeConnections(crossroads []tv4p.CrossroadType) {
+	for i := range crossroads {
+		cr := &crossroads[i]
+		isT := strings.HasPrefix(cr.Name, "kr_t_")
+		isX := strings.HasPrefix(cr.Name, "kr_x_")
+		if cr.Shape != "" {
+			isT, isX = cr.Shape == tv4p.ShapeT, cr.Shape == tv4p.ShapeX
+		}
+		if !isT && !isX {
+			continue
+		}
+
+		c := &cr.Connections
+		if connectionLess(c.B, c.A) {
+			c.A, c.B = c.B, c.A
+			swapCrossroadSides(cr, 0)
+		}
+		if isX && connectionLess(c.D, c.C) {
+			c.C, c.D = c.D, c.C
+			swapCrossroadSides(cr, 2)
+		}
+	}
+}
+
+// swapCrossroadSides swaps sides A/B (first=0) or C/D (first=2) in the raw entries of cr.
+func swapCrossroadSides(cr *tv4p.CrossroadType, first byte) {
+	swapRawFields(cr.TV4PDef, 0x84+first, 0x85+first)
+	swapRawFields(cr.TV4PLink, 0x92+first, 0x93+first)
+
+	sides := "ABCD"
+	a, b := string(sides[first]), string(sides[first+1])
+	for j := range cr.TV4PSideRefs {
+		switch cr.TV4PSideRefs[j].Side {
+		case a:
+			cr.TV4PSideRefs[j].Side = b
+		case b:
+			cr.TV4PSideRefs[j].Side = a
+		}
+	}
+}
+
+// swapRawFields swaps the values (raw bytes and nested lists) of two fields of e.
+func swapRawFields(e *tv4p.EntryRaw, tagA, tagB byte) {
+	if e == nil {
+		return
+	}
+	ia := slices.IndexFunc(e.Fields, func(f tv4p.FieldRaw) bool { return f.Tag == tagA })
+	ib := slices.IndexFunc(e.Fields, func(f tv4p.FieldRaw) bool { return f.Tag == tagB })
+	if ia < 0 || ib < 0 {
+		return
+	}
+	fa, fb := &e.Fields[ia], &e.Fields[ib]
+	fa.Raw, fb.Raw = fb.Raw, fa.Raw
+	fa.List, fb.List = fb.List, fa.List
+}
+
+// connectionLess compares two connection names case-insensitively.
+// A pair with an empty name is never out of order.
+func connectionLess(a, b string) bool {
+	a = strings.ToLower(strings.TrimSpace(a))
+	b = strings.ToLower(strings.TrimSpace(b))
+	if a == "" || b == "" {
+		return false
+	}
+
+	return a < b
+}
+
+// lowerModelPaths lowercases crossroad model paths like part paths already are, keeping an
+// upper-case drive letter ("P:\DZ\Roads\kr_t.p3d" -> "P:\dz\roads\kr_t.p3d").
+// TB resolves paths case-insensitively. Raw tv4p_def entries keep the original path.
+func lowerModelPaths(crossroads []tv4p.CrossroadType) {
+	for i := range crossroads {
+		m := strings.ToLower(crossroads[i].Model)
+		if len(m) >= 2 && m[1] == ':' {
+			m = strings.ToUpper(m[:1]) + m[1:]
+		}
+		crossroads[i].Model = m
+	}
+}
+
+// markNeedsMLOD sets needs_mlod on the road parts whose model under gameRoot is ODOL,
+// the flag generate --include-odol writes and tv4p has no field for.
+// It returns the number of marked parts and of models that could not be read.
+func markNeedsMLOD(cfg *tv4p.RoadConfig, gameRoot string) (marked, missing int) {
+	root := cleanAbs(gameRoot)
+	for i := range cfg.Types {
+		rt := &cfg.Types[i]
+		for _, parts := range [][]tv4p.RoadPart{rt.StraightParts, rt.CornerParts, rt.TerminatorPart} {
+			for j := range parts {
+				_, kind, err := p3d.IsMLOD(partModelPath(root, parts[j].Path))
+				if err != nil {
+					cliLog.Debugf("needs_mlod: %s: %v", parts[j].Path, err)
+					missing++
+					continue
+				}
+				parts[j].NeedsMLOD = kind == "ODOL"
+				if parts[j].NeedsMLOD {
+					marked++
+				}
+			}
+		}
+	}
+
+	return marked, missing
+}
+
+// partModelPath resolves a part Object File path (relative to the game root, backslashes)
+// to a file path.
+func partModelPath(root, p string) string {
+	if isAbsPath(p) {
+		return cleanAbs(p)
+	}
+	if _, _, ok := splitWindowsVolume(root); ok {
+		return joinPath(root, p)
+	}
+
+	return joinPath(root, filepath.FromSlash(strings.ReplaceAll(p, `\`, "/")))
+}
+
+// onlyRoadsWithCrossroads drops road types that no crossroad connects to
+// (tv4p.RoadTypesWithCrossroads) and logs their names.
+func onlyRoadsWithCrossroads(cfg *tv4p.RoadConfig) {
+	kept := tv4p.RoadTypesWithCrossroads(cfg.Types, cfg.CrossroadTypes)
+	if len(kept) == len(cfg.Types) {
+		cfg.Types = kept
+		return
+	}
+
+	var dropped []string
+	for _, rt := range cfg.Types {
+		if !slices.ContainsFunc(kept, func(k tv4p.RoadType) bool { return k.Name == rt.Name }) {
+			dropped = append(dropped, rt.Name)
+		}
+	}
+	cliLog.Infof("dropped %d road type(s) without crossroads: %s", len(dropped), strings.Join(dropped, ", "))
+	cfg.Types = kept
+}
+
+// stripIDs zeroes all road type, part and crossroad entry IDs (raw entries included),
+// so a patch relies on the ID allocator instead of pinning TB's internal IDs.
+func stripIDs(cfg *tv4p.RoadConfig) {
+	for i := range cfg.Types {
+		rt := &cfg.Types[i]
+		rt.ID = 0
+		for _, parts := range [][]tv4p.RoadPart{rt.StraightParts, rt.CornerParts, rt.TerminatorPart} {
+			for j := range parts {
+				parts[j].ID = 0
+			}
+		}
+		stripRawIDs(rt.TV4PRaw)
+	}
+
+	for i := range cfg.CrossroadTypes {
+		cr := &cfg.CrossroadTypes[i]
+		stripRawIDs(cr.TV4PDef)
+		stripRawIDs(cr.TV4PLink)
+		for j := range cr.TV4PSideRefs {
+			cr.TV4PSideRefs[j].ID = 0
+		}
+	}
+}
+
+// stripRawIDs zeroes the ID of a raw entry and of all entries nested in its list fields.
+func stripRawIDs(e *tv4p.EntryRaw) {
+	if e == nil {
+		return
+	}
+
+	e.ID = 0
+	for i := range e.Fields {
+		for j := range e.Fields[i].List {
+			stripRawIDs(&e.Fields[i].List[j])
+		}
+	}
+}
+
+// withZeroIDs converts a config to a generic value where every entry object
+// (road type, part, raw entry: has "type" but no field "tag") carries an "id" key,
+// even when it is zero and would be dropped by omitempty.
+// Object keys come out sorted, which matches the YAML encoder anyway.
+func withZeroIDs(cfg any) (any, error) {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	addZeroIDs(v)
+	return v, nil
+}
+
+// addZeroIDs walks a decoded JSON value and adds "id": 0 to entry objects missing it.
+func addZeroIDs(v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		_, hasType := t["type"]
+		_, hasTag := t["tag"]
+		if _, ok := t["id"]; hasType && !hasTag && !ok {
+			t["id"] = 0
+		}
+		for _, child := range t {
+			addZeroIDs(child)
+		}
+	case []any:
+		for _, child := range t {
+			addZeroIDs(child)
+		}
+	}
+}
+
+// namespaceOptions configures the --prefix/--suffix road type renaming.
+type namespaceOptions struct {
+	Prefix string // prepended to road type names
+	Suffix string // appended to road type names
+	Parts  bool   // also rename parts whose names start with their road type name
+}
+
+// namespaceRoadTypes renames road types to prefix+name+suffix and rewrites every
+// reference so the config stays consistent: crossroad connections and defaults,
+// every road type in crossroad names (the kr_t_/kr_x_ shape prefix is kept, so shape
+// detection still works), optionally part names, and the 0x33 name fields of raw
+// entries written back verbatim.
+// References to names that are not road types of cfg are left untouched.
+func namespaceRoadTypes(cfg *tv4p.RoadConfig, opts namespaceOptions) {
+	if opts.Prefix == "" && opts.Suffix == "" {
+		return
+	}
+
+	renamed := map[string]string{} // old name lower -> new name
+	for i := range cfg.Types {
+		rt := &cfg.Types[i]
+		old := rt.Name
+		rt.Name = opts.Prefix + old + opts.Suffix
+		renamed[strings.ToLower(old)] = rt.Name
+		setRawName(rt.TV4PRaw, rt.Name)
+
+		if !opts.Parts {
+			continue
+		}
+		for _, parts := range [][]tv4p.RoadPart{rt.StraightParts, rt.CornerParts, rt.TerminatorPart} {
+			for j := range parts {
+				parts[j].Name = renamePartName(parts[j].Name, old, rt.Name)
+			}
+		}
+		if rt.TV4PRaw != nil {
+			for fi := range rt.TV4PRaw.Fields {
+				list := rt.TV4PRaw.Fields[fi].List
+				for j := range list {
+					if name, ok := rawName(&list[j]); ok {
+						setRawName(&list[j], renamePartName(name, old, rt.Name))
+					}
+				}
+			}
+		}
+	}
+
+	ref := func(name string) string {
+		if n, ok := renamed[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return n
+		}
+		return name
+	}
+
+	for i := range cfg.CrossroadTypes {
+		cr := &cfg.CrossroadTypes[i]
+		c := &cr.Connections
+		c.A, c.B, c.C, c.D = ref(c.A), ref(c.B), ref(c.C), ref(c.D)
+		if cr.Default != "" {
+			cr.Default = ref(cr.Default)
+		}
+
+		cr.Name = renameCrossroadName(cr.Name, renamed, opts)
+		setRawName(cr.TV4PDef, cr.Name)
+	}
+}
+
+// renameCrossroadName renames every road type in a crossroad name after its
+// kr_t_/kr_x_ shape prefix ("kr_t_asf1_city" -> "kr_t_my_asf1_my_city").
+// A road type matches at the start or after "_" and must end at "_" or the end of the
+// name; the longest matching name wins. Other name parts are kept. A name without any
+// road type is namespaced as a whole, so it cannot clash either.
+func renameCrossroadName(name string, renamed map[string]string, opts namespaceOptions) string {
+	shape := ""
+	for _, p := range []string{"kr_t_", "kr_x_"} {
+		if strings.HasPrefix(name, p) {
+			shape = p
+		}
+	}
+
+	rest := name[len(shape):]
+	var b strings.Builder
+	b.WriteString(shape)
+	matched := false
+	for i := 0; i < len(rest); {
+		if n := matchRoadTypeName(rest[i:], renamed); n > 0 {
+			b.WriteString(renamed[strings.ToLower(rest[i:i+n])])
+			i += n
+			matched = true
+			continue
+		}
+
+		j := strings.IndexByte(rest[i:], '_')
+		if j < 0 {
+			b.WriteString(rest[i:])
+			break
+		}
+		b.WriteString(rest[i : i+j+1])
+		i += j + 1
+	}
+	if !matched {
+		return shape + opts.Prefix + rest + opts.Suffix
+	}
+
+	return b.String()
+}
+
+// matchRoadTypeName returns the length of the longest road type name (renamed keys,
+// lowercase) that s starts with, followed by "_" or the end of s; 0 if none.
+func matchRoadTypeName(s string, renamed map[string]string) int {
+	lower := strings.ToLower(s)
+	best := 0
+	for old := range renamed {
+		if len(old) > best && hasNamePrefix(lower, old) {
+			best = len(old)
+		}
+	}
+
+	return best
+}
+
+// hasNamePrefix reports whether s starts with prefix followed by "_" or the end of s.
+func hasNamePrefix(s, prefix string) bool {
+	return prefix != "" && strings.HasPrefix(s, prefix) && (len(s) == len(prefix) || s[len(prefix)] == '_')
+}
+
+// renamePartName replaces a leading road type name (case-insensitive) in a part name.
+// The type name must be followed by "_" or the end of the name, so "asf1" does not
+// match "asf10_12".
+func renamePartName(part, oldType, newType string) string {
+	if !hasNamePrefix(strings.ToLower(part), strings.ToLower(oldType)) {
+		return part
+	}
+
+	return newType + part[len(oldType):]
+}
+
+// rawName returns the 0x33 name string of a raw entry.
+func rawName(e *tv4p.EntryRaw) (string, bool) {
+	for _, f := range e.Fields {
+		if f.Tag == 0x33 && f.Type == 0x0B {
+			b, err := hex.DecodeString(f.Raw)
+			return string(b), err == nil
+		}
+	}
+
+	return "", false
+}
+
+// setRawName sets the 0x33 name string of a raw entry, if it has one.
+func setRawName(e *tv4p.EntryRaw, name string) {
+	if e == nil {
+		return
+	}
+	for i := range e.Fields {
+		if e.Fields[i].Tag == 0x33 && e.Fields[i].Type == 0x0B {
+			e.Fields[i].Raw = hex.EncodeToString([]byte(name))
+			return
+		}
+	}
+}
+
+// tagWorlds sets the World of every road type from its name (roadparts.World).
+// Road type order is kept: it decides the crossroad index fallback in TB.
+func tagWorlds(cfg *tv4p.RoadConfig) {
+	for i := range cfg.Types {
+		cfg.Types[i].World = roadparts.World(cfg.Types[i].Name)
+	}
+}
+
+// dedupeParts removes parts with the same normalized path (case-insensitive, / or \)
+// within each part list, keeping the first occurrence as is (its ID included).
+// Raw road types (tv4p_raw) are written verbatim, so they are skipped.
+// It returns the number of removed parts per road type name (only non-zero counts).
+func dedupeParts(cfg *tv4p.RoadConfig) map[string]int {
+	removed := map[string]int{}
+	for i := range cfg.Types {
+		rt := &cfg.Types[i]
+		if rt.TV4PRaw != nil {
+			continue
+		}
+		for _, parts := range []*[]tv4p.RoadPart{&rt.StraightParts, &rt.CornerParts, &rt.TerminatorPart} {
+			seen := map[string]struct{}{} // normalized paths
+			var out []tv4p.RoadPart
+			for _, p := range *parts {
+				key := partPathKey(p.Path)
+				if key == "" {
+					out = append(out, p)
+					continue
+				}
+				if _, ok := seen[key]; ok {
+					removed[rt.Name]++
+					continue
+				}
+				seen[key] = struct{}{}
+				out = append(out, p)
+			}
+			*parts = out
+		}
+	}
+
+	return removed
+}
+
+// logDedupe reports the dedupeParts result per road type.
+func logDedupe(removed map[string]int) {
+	names := make([]string, 0, len(removed))
+	for name := range removed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cliLog.Infof("removed %d duplicate part(s) from %s", removed[name], name)
+	}
+}
+
+// partPathKey normalizes a part or model path for comparison (case and slash style).
+func partPathKey(p string) string {
+	return strings.ToLower(toBackslashes(strings.TrimSpace(p)))
+}
@@ -0,0 +1,640 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestCanonicalizeConnections(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		cr   tv4p.CrossroadType
+		want tv4p.CrossroadConnections
+	}{
+		{
+			name: "t_swap_ab",
+			cr: tv4p.CrossroadType{
+				Name:        "kr_t_city_asf1",
+				Connections: tv4p.CrossroadConnections{A: "city", B: "asf1", C: "asf1"},
+			},
+			want: tv4p.CrossroadConnections{A: "asf1", B: "city", C: "asf1"},
+		},
+		{
+			name: "t_keeps_c",
+			cr: tv4p.CrossroadType{
+				Name:        "kr_t_asf1_asf2",
+				Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf2"},
+			},
+			want: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf2"},
+		},
+		{
+			name: "x_swap_cd",
+			cr: tv4p.CrossroadType{
+				Name:        "kr_x_asf1_city_asf3",
+				Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city", D: "asf3"},
+			},
+			want: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf3", D: "city"},
+		},
+		{
+			name: "x_empty_side_kept",
+			cr: tv4p.CrossroadType{
+				Name:        "kr_x_asf1_city",
+				Connections: tv4p.CrossroadConnections{A: "", B: "asf1", C: "city"},
+			},
+			want: tv4p.CrossroadConnections{A: "", B: "asf1", C: "city"},
+		},
+		{
+			name: "unknown_shape_untouched",
+			cr: tv4p.CrossroadType{
+				Name:        "custom_cross",
+				Connections: tv4p.CrossroadConnections{A: "city", B: "asf1"},
+			},
+			want: tv4p.CrossroadConnections{A: "city", B: "asf1"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			list := []tv4p.CrossroadType{tt.cr}
+			canonicalizeConnections(list)
+			if list[0].Connections != tt.want {
+				t.Fatalf("got=%+v want %+v", list[0].Connections, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeConnectionsRawDef(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}}},
+			{Name: "city", StraightParts: []tv4p.RoadPart{{Name: "city_12", Path: `dz\roads\city_12.p3d`}}},
+		},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{Name: "kr_t_city_asf1", Model: `P:\dz\roads\kr_t_city_asf1.p3d`, Connections: tv4p.CrossroadConnections{A: "city", B: "asf1", C: "asf1"}},
+		},
+	}
+	data, err := tv4p.PatchRoadTool(testTV4P(t, cfg), cfg, tv4p.ScopeAll)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	extracted, err := tv4p.ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if extracted.CrossroadTypes[0].TV4PDef == nil {
+		t.Fatalf("no tv4p_def extracted")
+	}
+
+	canonicalizeConnections(extracted.CrossroadTypes)
+	want := tv4p.CrossroadConnections{A: "asf1", B: "city", C: "asf1"}
+	if got := extracted.CrossroadTypes[0].Connections; got != want {
+		t.Fatalf("canonical=%+v want %+v", got, want)
+	}
+
+	// The verbatim tv4p_def carries the swapped indices, so patch agrees with the config.
+	out, err := tv4p.PatchRoadTool(data, extracted, tv4p.ScopeCrossroad)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	again, err := tv4p.ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := again.CrossroadTypes[0].Connections; got != want {
+		t.Fatalf("patched=%+v want %+v", got, want)
+	}
+}
+
+func TestStripIDs(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{{
+			Name:          "asf1",
+			ID:            0x100,
+			StraightParts: []tv4p.RoadPart{{Name: "asf1_6", ID: 0x104}},
+			CornerParts:   []tv4p.RoadPart{{Name: "asf1_6 10", ID: 0x108}},
+			TV4PRaw:       &tv4p.EntryRaw{Type: 0x12, ID: 0x100},
+		}},
+		CrossroadTypes: []tv4p.CrossroadType{{
+			Name: "kr_t_asf1_asf1",
+			TV4PDef: &tv4p.EntryRaw{Type: 0x17, ID: 0x200, Fields: []tv4p.FieldRaw{{
+				Tag: 0x92, Type: 0x0C, List: []tv4p.EntryRaw{{Type: 0x1B, ID: 0x204}},
+			}}},
+			TV4PSideRefs: []tv4p.CrossroadSideRef{{Side: "A", ID: 0x204}},
+		}},
+	}
+
+	stripIDs(&cfg)
+
+	rt := cfg.Types[0]
+	if rt.ID != 0 || rt.StraightParts[0].ID != 0 || rt.CornerParts[0].ID != 0 || rt.TV4PRaw.ID != 0 {
+		t.Fatalf("road type IDs not stripped: %+v", rt)
+	}
+	cr := cfg.CrossroadTypes[0]
+	if cr.TV4PDef.ID != 0 || cr.TV4PDef.Fields[0].List[0].ID != 0 || cr.TV4PSideRefs[0].ID != 0 {
+		t.Fatalf("crossroad IDs not stripped: %+v", cr)
+	}
+	if cr.TV4PDef.Type != 0x17 || rt.Name != "asf1" {
+		t.Fatalf("non-ID fields changed")
+	}
+}
+
+func TestWithZeroIDs(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{Types: []tv4p.RoadType{{
+		Name:          "asf1",
+		Type:          0x12,
+		StraightParts: []tv4p.RoadPart{{Name: "asf1_6", Type: 0x13, ID: 7}},
+		TV4PExtra:     []tv4p.FieldRaw{{Tag: 0x75, Type: 0x01, Raw: "00"}},
+	}}}
+
+	v, err := withZeroIDs(cfg)
+	if err != nil {
+		t.Fatalf("withZeroIDs: %v", err)
+	}
+	out, err := encodeConfig(v, "json")
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	var got struct {
+		Types []struct {
+			ID            *uint32 `json:"id"`
+			StraightParts []struct {
+				ID *uint32 `json:"id"`
+			} `json:"starting_parts"`
+			TV4PExtra []map[string]any `json:"tv4p_extra"`
+		} `json:"road_types"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	rt := got.Types[0]
+	if rt.ID == nil || *rt.ID != 0 {
+		t.Fatalf("road type id=%v want explicit 0", rt.ID)
+	}
+	if rt.StraightParts[0].ID == nil || *rt.StraightParts[0].ID != 7 {
+		t.Fatalf("part id=%v want 7", rt.StraightParts[0].ID)
+	}
+	if _, ok := rt.TV4PExtra[0]["id"]; ok {
+		t.Fatalf("raw field got an id key")
+	}
+}
+
+func TestNamespaceRoadTypes(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_6"}, {Name: "other_6"}}},
+			{Name: "city", CornerParts: []tv4p.RoadPart{{Name: "City_10 25"}}},
+		},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{
+				Name:        "kr_t_asf1_city",
+				Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"},
+				Default:     "asf1",
+				TV4PDef:     &tv4p.EntryRaw{Type: 0x17, Fields: []tv4p.FieldRaw{{Tag: 0x33, Type: 0x0B, Raw: hex.EncodeToString([]byte("kr_t_asf1_city"))}}},
+			},
+			{Name: "junction", Connections: tv4p.CrossroadConnections{A: "city", B: "CITY", C: "unknown"}},
+		},
+	}
+
+	namespaceRoadTypes(&cfg, namespaceOptions{Prefix: "mymod_", Suffix: "_v2", Parts: true})
+
+	if err := tv4p.ValidateCrossroadRefs(cfg.CrossroadTypes[:1], cfg.Types); err != nil {
+		t.Fatalf("connections do not resolve after prefixing: %v", err)
+	}
+
+	if got := cfg.Types[0].Name; got != "mymod_asf1_v2" {
+		t.Fatalf("road type=%q", got)
+	}
+	parts := cfg.Types[0].StraightParts
+	if parts[0].Name != "mymod_asf1_v2_6" || parts[1].Name != "other_6" {
+		t.Fatalf("parts=%+v", parts)
+	}
+	if got := cfg.Types[1].CornerParts[0].Name; got != "mymod_city_v2_10 25" {
+		t.Fatalf("case-insensitive part=%q", got)
+	}
+
+	cr := cfg.CrossroadTypes[0]
+	if cr.Name != "kr_t_mymod_asf1_v2_mymod_city_v2" || cr.Default != "mymod_asf1_v2" {
+		t.Fatalf("crossroad=%q default=%q", cr.Name, cr.Default)
+	}
+	if name, _ := rawName(cr.TV4PDef); name != cr.Name {
+		t.Fatalf("raw def name=%q want %q", name, cr.Name)
+	}
+
+	other := cfg.CrossroadTypes[1]
+	want := tv4p.CrossroadConnections{A: "mymod_city_v2", B: "mymod_city_v2", C: "unknown"}
+	if other.Name != "mymod_junction_v2" || other.Connections != want {
+		t.Fatalf("crossroad=%q connections=%+v", other.Name, other.Connections)
+	}
+}
+
+func TestNamespaceRoadTypesBoundaries(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_6"}, {Name: "asf10_6"}, {Name: "asf1"}}},
+			{Name: "asf10", StraightParts: []tv4p.RoadPart{{Name: "asf10_12"}}},
+			{Name: "city_a"},
+		},
+	}
+	for _, name := range []string{"kr_t_asf10_asf1", "kr_x_asf1_city_a_asf10", "kr_t_asf1x_asf1", "kr_x_ASF1_asf1_city_a_asf10"} {
+		cfg.CrossroadTypes = append(cfg.CrossroadTypes, tv4p.CrossroadType{Name: name})
+	}
+
+	namespaceRoadTypes(&cfg, namespaceOptions{Prefix: "my_", Parts: true})
+
+	var parts []string
+	for _, rt := range cfg.Types {
+		for _, p := range rt.StraightParts {
+			parts = append(parts, p.Name)
+		}
+	}
+	if want := []string{"my_asf1_6", "asf10_6", "my_asf1", "my_asf10_12"}; !slices.Equal(parts, want) {
+		t.Fatalf("parts=%v want %v", parts, want)
+	}
+
+	var names []string
+	for _, cr := range cfg.CrossroadTypes {
+		names = append(names, cr.Name)
+	}
+	want := []string{
+		"kr_t_my_asf10_my_asf1",
+		"kr_x_my_asf1_my_city_a_my_asf10",
+		"kr_t_asf1x_my_asf1",
+		"kr_x_my_asf1_my_asf1_my_city_a_my_asf10",
+	}
+	if !slices.Equal(names, want) {
+		t.Fatalf("crossroads=%v want %v", names, want)
+	}
+}
+
+func TestConvertConfigNamespace(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{{Name: "asf1"}, {Name: "city"}},
+		CrossroadTypes: []tv4p.CrossroadType{{
+			Name:        "kr_t_asf1_city",
+			Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"},
+			Default:     "city",
+		}},
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.yaml")
+	raw, err := encodeConfig(cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(in, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &convertCmd{Format: "json", Prefix: "mymod_"}
+	cmd.Args.Input = in
+	cmd.Args.Output = filepath.Join(dir, "out.json")
+	if err := cmd.Execute(nil); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+
+	got, err := readConfig(cmd.Args.Output, false, nil)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if err := tv4p.ValidateCrossroadRefs(got.CrossroadTypes, got.Types); err != nil {
+		t.Fatalf("connections do not resolve after convert: %v", err)
+	}
+	cr := got.CrossroadTypes[0]
+	if cr.Name != "kr_t_mymod_asf1_mymod_city" || cr.Default != "mymod_city" || cr.Connections.C != "mymod_city" {
+		t.Fatalf("crossroad=%+v", cr)
+	}
+}
+
+func TestDedupeParts(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{Types: []tv4p.RoadType{
+		{
+			Name: "asf1",
+			StraightParts: []tv4p.RoadPart{
+				{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`},
+				{Name: "asf1_6", Path: `dz\roads\asf1_6.p3d`},
+				{Name: "asf1_12 copy", Path: `DZ/Roads/ASF1_12.p3d`, ID: 42},
+				{Name: "asf1_12 again", Path: ` dz\roads\asf1_12.p3d `, ID: 43},
+			},
+			// Same path in another list is not a duplicate.
+			TerminatorPart: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}},
+		},
+		{
+			Name:        "city",
+			CornerParts: []tv4p.RoadPart{{Name: "a", Path: `dz\roads\city_7 100.p3d`}, {Name: "b", Path: `dz\roads\city_7 50.p3d`}},
+		},
+		{
+			// Written verbatim from tv4p_raw: not deduped, not counted.
+			Name:          "raw",
+			TV4PRaw:       &tv4p.EntryRaw{Type: 0x12},
+			StraightParts: []tv4p.RoadPart{{Name: "raw_12", Path: `dz\roads\raw_12.p3d`}, {Name: "raw_12", Path: `dz\roads\raw_12.p3d`}},
+		},
+	}}
+
+	removed := dedupeParts(&cfg)
+	if len(removed) != 1 || removed["asf1"] != 2 {
+		t.Fatalf("removed=%v want asf1:2", removed)
+	}
+
+	straight := cfg.Types[0].StraightParts
+	if len(straight) != 2 || straight[0].Name != "asf1_12" || straight[0].ID != 0 || straight[1].Name != "asf1_6" {
+		t.Fatalf("straight=%+v want asf1_12 (no ID taken from the duplicates), asf1_6", straight)
+	}
+	if len(cfg.Types[0].TerminatorPart) != 1 || len(cfg.Types[1].CornerParts) != 2 || len(cfg.Types[2].StraightParts) != 2 {
+		t.Fatalf("types=%+v: other lists changed", cfg.Types)
+	}
+}
+
+func TestConvertDedupeParts(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{Types: []tv4p.RoadType{{
+		Name: "asf1",
+		StraightParts: []tv4p.RoadPart{
+			{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`},
+			{Name: "asf1_12 copy", Path: `DZ/Roads/ASF1_12.p3d`},
+		},
+	}}}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.yaml")
+	raw, err := encodeConfig(cfg, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(in, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &convertCmd{Format: "yaml", Prefix: "mymod_", DedupeParts: true}
+	cmd.Args.Input = in
+	cmd.Args.Output = filepath.Join(dir, "out.yaml")
+	if err := cmd.Execute(nil); err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+
+	got, err := readConfig(cmd.Args.Output, false, nil)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(got.Types) != 1 || got.Types[0].Name != "mymod_asf1" || len(got.Types[0].StraightParts) != 1 {
+		t.Fatalf("types=%+v want mymod_asf1 with one straight part", got.Types)
+	}
+}
+
+func TestTagWorlds(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{Types: []tv4p.RoadType{{Name: "sakhal_asf1"}, {Name: "asf1"}, {Name: "enoch_city"}}}
+	tagWorlds(&cfg)
+
+	want := []string{"sakhal", "", "enoch"}
+	for i, rt := range cfg.Types {
+		if rt.World != want[i] {
+			t.Fatalf("%s: world=%q want %q", rt.Name, rt.World, want[i])
+		}
+	}
+	if got := tv4p.ToPortableConfig(cfg).Types[0].World; got != "sakhal" {
+		t.Fatalf("portable world=%q want sakhal", got)
+	}
+}
+
+func TestLowerModelPaths(t *testing.T) {
+	t.Parallel()
+
+	crossroads := func() []tv4p.CrossroadType {
+		return []tv4p.CrossroadType{
+			{Name: "kr_t_asf1_city", Model: `P:\DZ\Structures\Roads\Parts\KR_T_asf1_city.p3d`},
+			{Name: "kr_x_city_city", Model: `p:\dz\roads\kr_x_city_city.p3d`},
+			{Name: "kr_t_rel", Model: `DZ\Roads\kr_t_rel.P3D`},
+			{Name: "kr_t_empty"},
+		}
+	}
+
+	want := []string{
+		`P:\dz\structures\roads\parts\kr_t_asf1_city.p3d`,
+		`P:\dz\roads\kr_x_city_city.p3d`,
+		`dz\roads\kr_t_rel.p3d`,
+		"",
+	}
+
+	got := crossroads()
+	lowerModelPaths(got)
+	for i, cr := range got {
+		if cr.Model != want[i] {
+			t.Fatalf("%s: model=%q want %q", cr.Name, cr.Model, want[i])
+		}
+	}
+
+}
+
+func TestExtractLowerModelPaths(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}}}},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{Name: "kr_t_asf1_asf1", Model: `P:\DZ\Roads\KR_T_asf1_asf1.p3d`, Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "asf1"}},
+		},
+	}
+	data, err := tv4p.PatchRoadTool(testTV4P(t, cfg), cfg, tv4p.ScopeAll)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.tv4p")
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lower := range []bool{false, true} {
+		cmd := &extractCmd{Format: "yaml", Scope: "crossroads", Portable: true, ModelExts: []string{".p3d"}, LowerModelPaths: lower}
+		cmd.Args.Input = in
+		cmd.Args.Output = filepath.Join(dir, fmt.Sprintf("out-%v.yaml", lower))
+		if err := cmd.Execute(nil); err != nil {
+			t.Fatalf("lower=%v: extract: %v", lower, err)
+		}
+
+		got, err := readPortableConfig(cmd.Args.Output)
+		if err != nil {
+			t.Fatalf("lower=%v: read: %v", lower, err)
+		}
+		want := `P:\DZ\Roads\KR_T_asf1_asf1.p3d`
+		if lower {
+			want = `P:\dz\roads\kr_t_asf1_asf1.p3d`
+		}
+		if m := got.CrossroadTypes[0].Model; m != want {
+			t.Fatalf("lower=%v: model=%q want %q", lower, m, want)
+		}
+	}
+}
+
+func TestExtractNeedsMLODRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	models := filepath.Join(root, "dz", "roads")
+	if err := os.MkdirAll(models, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	for name, hdr := range map[string]string{"asf1_12.p3d": "MLOD", "asf1_25.p3d": "ODOL"} {
+		if err := os.WriteFile(filepath.Join(models, name), []byte(hdr), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := tv4p.RoadConfig{Types: []tv4p.RoadType{{
+		Name: "asf1",
+		StraightParts: []tv4p.RoadPart{
+			{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`},
+			{Name: "asf1_25", Path: `dz\roads\asf1_25.p3d`, NeedsMLOD: true},
+			{Name: "asf1_6", Path: `dz\roads\asf1_6.p3d`},
+		},
+	}}}
+	data, err := tv4p.PatchRoadTool(testTV4P(t, cfg), cfg, tv4p.ScopeRoads)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.tv4p")
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	extract := func(t *testing.T, input, gameRoot string) tv4p.RoadConfig {
+		t.Helper()
+		cmd := &extractCmd{Format: "yaml", Scope: "roads", ModelExts: []string{".p3d"}, GameRoot: gameRoot}
+		cmd.Args.Input = input
+		cmd.Args.Output = filepath.Join(dir, "out.yaml")
+		if err := cmd.Execute(nil); err != nil {
+			t.Fatalf("extract: %v", err)
+		}
+		got, err := readConfig(cmd.Args.Output, false, nil)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return got
+	}
+	flags := func(cfg tv4p.RoadConfig) []bool {
+		var out []bool
+		for _, p := range cfg.Types[0].StraightParts {
+			out = append(out, p.NeedsMLOD)
+		}
+		return out
+	}
+
+	if got := flags(extract(t, in, "")); !slices.Equal(got, []bool{false, false, false}) {
+		t.Fatalf("without --game-root needs_mlod=%v want none", got)
+	}
+
+	// extract -> patch -> extract keeps the flag; the missing asf1_6 model stays unmarked.
+	first := extract(t, in, root)
+	if got := flags(first); !slices.Equal(got, []bool{false, true, false}) {
+		t.Fatalf("needs_mlod=%v want only asf1_25", got)
+	}
+	patched, err := tv4p.PatchRoadTool(data, first, tv4p.ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	again := filepath.Join(dir, "again.tv4p")
+	if err := os.WriteFile(again, patched, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := flags(extract(t, again, root)); !slices.Equal(got, flags(first)) {
+		t.Fatalf("round-trip needs_mlod=%v want %v", got, flags(first))
+	}
+}
+
+func TestOnlyRoadsWithCrossroads(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{{Name: "asf1"}, {Name: "isolated"}, {Name: "city"}},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{Name: "kr_t_asf1_city", Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}},
+		},
+	}
+	onlyRoadsWithCrossroads(&cfg)
+
+	var names []string
+	for _, rt := range cfg.Types {
+		names = append(names, rt.Name)
+	}
+	if want := []string{"asf1", "city"}; !slices.Equal(names, want) {
+		t.Fatalf("types=%v want %v", names, want)
+	}
+	if len(cfg.CrossroadTypes) != 1 {
+		t.Fatalf("crossroads=%d want 1 (kept)", len(cfg.CrossroadTypes))
+	}
+}
+
+func TestExtractOnlyRoadsWithCrossroadsEmitRaw(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}}},
+			{Name: "isolated", StraightParts: []tv4p.RoadPart{{Name: "isolated_12", Path: `dz\roads\isolated_12.p3d`}}},
+			{Name: "city", StraightParts: []tv4p.RoadPart{{Name: "city_12", Path: `dz\roads\city_12.p3d`}}},
+		},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{Name: "kr_t_asf1_city", Model: `P:\dz\roads\kr_t_asf1_city.p3d`, Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}},
+		},
+	}
+	data, err := tv4p.PatchRoadTool(testTV4P(t, cfg), cfg, tv4p.ScopeAll)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.tv4p")
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &extractCmd{Format: "yaml", Scope: "roads", ModelExts: []string{".p3d"}, OnlyWithCrossroads: true, EmitRaw: true}
+	cmd.Args.Input = in
+	cmd.Args.Output = filepath.Join(dir, "out.yaml")
+	if err := cmd.Execute(nil); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+
+	got, err := readConfig(cmd.Args.Output, false, nil)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(got.Types) != 2 {
+		t.Fatalf("types=%d want asf1 and city", len(got.Types))
+	}
+	for _, rt := range got.Types {
+		if name, ok := rawName(rt.TV4PRaw); !ok || name != rt.Name {
+			t.Fatalf("%s: tv4p_raw name=%q want its own entry", rt.Name, name)
+		}
+	}
+}
@@ -1,33 +1,141 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/invopop/yaml"
 
+	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
 	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
 )
 
 // readConfig reads the config from the file.
-func readConfig(path string) (tv4p.RoadConfig, error) {
-	raw, err := os.ReadFile(path)
+// Gzip-compressed files are decompressed first (see readConfigFile).
+// A leading UTF-8 BOM and leading blank lines are stripped (see trimConfigText).
+// vars (nil: none) substitutes ${KEY} references in the raw text first (see configVars),
+// then advanced runs the yaml.v3 pre-processor (see decodeAdvancedConfig), which
+// substitutes them in !include files too.
+// Otherwise JSON (see configFormat) is decoded with encoding/json, YAML with yaml.
+func readConfig(path string, advanced bool, vars *configVars) (tv4p.RoadConfig, error) {
+	raw, err := readConfigFile(path)
 	if err != nil {
 		return tv4p.RoadConfig{}, err
 	}
+	raw = trimConfigText(raw)
+	if raw, err = vars.expand(raw); err != nil {
+		return tv4p.RoadConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if advanced {
+		return decodeAdvancedConfig(raw, path, vars)
+	}
 
 	var cfg tv4p.RoadConfig
+	if configFormat(path, raw) == "json" {
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return tv4p.RoadConfig{}, fmt.Errorf("%s: %w", path, err)
+		}
+		return cfg, nil
+	}
 	if err := yaml.Unmarshal(raw, &cfg); err != nil {
-		return tv4p.RoadConfig{}, err
+		return tv4p.RoadConfig{}, fmt.Errorf("%s: %w", path, err)
 	}
 
 	return cfg, nil
 }
 
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1F, 0x8B}
+
+// readConfigFile reads a config file and decompresses it when it is gzipped:
+// detected by the gzip magic bytes or a .gz extension.
+func readConfigFile(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(raw, gzipMagic) && !strings.EqualFold(filepath.Ext(path), ".gz") {
+		return raw, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%s: gzip: %w", path, err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: gzip: %w", path, err)
+	}
+
+	return out, nil
+}
+
+// gzipConfig compresses encoded config output (--gzip). The gzip header carries
+// no name or time, so equal configs give equal bytes.
+func gzipConfig(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// utf8BOM is the byte order mark some Windows editors put at the start of text files.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// trimConfigText strips a leading UTF-8 BOM and leading blank lines from config text.
+// The first non-blank line keeps its indentation: uniformly indented YAML stays valid.
+func trimConfigText(raw []byte) []byte {
+	raw = bytes.TrimPrefix(raw, utf8BOM)
+	for {
+		i := bytes.IndexByte(raw, '\n')
+		if i < 0 || len(bytes.TrimSpace(raw[:i])) > 0 {
+			return raw
+		}
+		raw = raw[i+1:]
+	}
+}
+
+// configFormat returns "json" or "yaml" for a config file: by extension (.json,
+// .yaml/.yml, also before a trailing .gz), else by content, where text starting
+// with '{' or '[' is JSON.
+func configFormat(path string, raw []byte) string {
+	if strings.EqualFold(filepath.Ext(path), ".gz") {
+		path = path[:len(path)-len(".gz")]
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	if t := bytes.TrimLeft(trimConfigText(raw), " \t"); len(t) > 0 && (t[0] == '{' || t[0] == '[') {
+		return "json"
+	}
+
+	return "yaml"
+}
+
 // encodeConfig encodes any config-like value to the raw data.
+// Map keys are emitted sorted (encoding/json sorts them and the YAML encoder
+// goes through JSON), so map fields added to config types keep the output
+// byte-identical across runs.
 func encodeConfig(cfg any, format string) ([]byte, error) {
 	switch format {
 	case "yaml":
@@ -39,6 +147,26 @@ func encodeConfig(cfg any, format string) ([]byte, error) {
 	}
 }
 
+// parseModelExts normalizes --p3d-ext values: lowercase with a leading dot
+// ("P3DE" becomes ".p3de"), duplicates dropped.
+func parseModelExts(list []string) ([]string, error) {
+	var out []string
+	for _, ext := range list {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" || ext == "." {
+			return nil, errors.New("--p3d-ext: empty extension")
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if !slices.Contains(out, ext) {
+			out = append(out, ext)
+		}
+	}
+
+	return out, nil
+}
+
 // filterConfigByScope filters the config by scope.
 func filterConfigByScope(cfg tv4p.RoadConfig, scope tv4p.Scope) any {
 	switch scope {
@@ -60,37 +188,110 @@ func filterPortableByScope(cfg tv4p.PortableConfig, scope tv4p.Scope) any {
 	switch scope {
 	case tv4p.ScopeRoads:
 		return struct {
-			Types []tv4p.PortableRoadType `json:"road_types"`
-		}{Types: cfg.Types}
+			Types    []tv4p.PortableRoadType `json:"road_types"`
+			Checksum string                  `json:"checksum,omitempty"`
+		}{Types: cfg.Types, Checksum: cfg.Checksum}
 	case tv4p.ScopeCrossroad:
 		return struct {
 			CrossroadTypes []tv4p.PortableCrossroadType `json:"crossroad_types,omitempty"`
-		}{CrossroadTypes: cfg.CrossroadTypes}
+			Checksum       string                       `json:"checksum,omitempty"`
+		}{CrossroadTypes: cfg.CrossroadTypes, Checksum: cfg.Checksum}
 	default:
 		return cfg
 	}
 }
 
-// decodeConfig decodes the config from the raw data.
+// withPortableChecksum drops the lists outside scope and sets the checksum of the rest,
+// so it matches what filterPortableByScope writes.
+func withPortableChecksum(cfg tv4p.PortableConfig, scope tv4p.Scope) (tv4p.PortableConfig, error) {
+	switch scope {
+	case tv4p.ScopeRoads:
+		cfg.CrossroadTypes = nil
+	case tv4p.ScopeCrossroad:
+		cfg.Types = nil
+	}
+
+	if err := cfg.SetChecksum(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// inspectInput summarizes the input tv4p file and warns about untested header versions.
+func inspectInput(data []byte) tv4p.FileInfo {
+	info, err := tv4p.Inspect(data)
+	if err != nil {
+		return info
+	}
+	if w := info.VersionWarning(); w != "" {
+		cliLog.Warnf("%s", w)
+	}
+
+	return info
+}
+
+// withFileHead adds the leading file bytes to a parse error to help diagnose unknown layouts.
+func withFileHead(err error, info tv4p.FileInfo) error {
+	if err == nil || info.Head == "" {
+		return err
+	}
+
+	return fmt.Errorf("%w (file head: %s)", err, info.Head)
+}
+
+// withCountHint points to --repair-counts when err is a list count mismatch.
+func withCountHint(err error) error {
+	var mismatch *tv4p.CountMismatchError
+	if !errors.As(err, &mismatch) {
+		return err
+	}
+
+	return fmt.Errorf("%w (pass --repair-counts to rewrite the header counts)", err)
+}
+
+// repairListCounts fixes crossroad list header counts in memory and reports each fix to stderr.
+func repairListCounts(data []byte, loc tv4p.LocateOptions) ([]byte, error) {
+	out, fixed, err := tv4p.RepairListCounts(data, loc)
+	if err != nil {
+		return nil, err
+	}
+	for i := range fixed {
+		cliLog.Infof("repaired %v", &fixed[i])
+	}
+
+	return out, nil
+}
+
 // printPatchStats prints the patch statistics.
 func printPatchStats(cfg tv4p.RoadConfig, outPath string) {
-	var straight, corner, terminator int
+	var straight, corner, terminator, needsMLOD int
 	for _, rt := range cfg.Types {
 		straight += len(rt.StraightParts)
 		corner += len(rt.CornerParts)
 		terminator += len(rt.TerminatorPart)
+		for _, parts := range [][]tv4p.RoadPart{rt.StraightParts, rt.CornerParts, rt.TerminatorPart} {
+			for _, p := range parts {
+				if p.NeedsMLOD {
+					needsMLOD++
+				}
+			}
+		}
+	}
+	if needsMLOD > 0 {
+		cliLog.Warnf("%d part(s) are marked needs_mlod: Road Tool needs MLOD models for them", needsMLOD)
 	}
 
-	fmt.Printf("patched %s\n", outPath)
-	fmt.Printf("road types: %d\n", len(cfg.Types))
-	fmt.Printf("starting parts: %d\n", straight)
-	fmt.Printf("corner parts: %d\n", corner)
-	fmt.Printf("terminator parts: %d\n", terminator)
+	cliLog.Infof("patched %s", outPath)
+	cliLog.Infof("road types: %d", len(cfg.Types))
+	cliLog.Infof("starting parts: %d", straight)
+	cliLog.Infof("corner parts: %d", corner)
+	cliLog.Infof("terminator parts: %d", terminator)
 
 	// Crossroads are only patched when `crossroad_types` is present in the config.
 	// When absent, the patcher preserves existing crossroads in the tv4p file.
 	if cfg.CrossroadTypes == nil {
-		fmt.Printf("crossroads: preserved (not provided in config)\n")
+		cliLog.Infof("crossroads: preserved (not provided in config)")
 		return
 	}
 
@@ -109,8 +310,8 @@ func printPatchStats(cfg tv4p.RoadConfig, outPath string) {
 			d++
 		}
 	}
-	fmt.Printf("crossroads: %d\n", len(cfg.CrossroadTypes))
-	fmt.Printf("crossroad connections: A=%d B=%d C=%d D=%d\n", a, b, c, d)
+	cliLog.Infof("crossroads: %d", len(cfg.CrossroadTypes))
+	cliLog.Infof("crossroad connections: A=%d B=%d C=%d D=%d", a, b, c, d)
 }
 
 // resolvePaths resolves the paths relative to the game root.
@@ -123,13 +324,13 @@ func resolvePaths(gameRoot string, paths []string) []string {
 			continue
 		}
 
-		if filepath.IsAbs(p) {
+		if isAbsPath(p) {
 			out = append(out, cleanAbs(p))
 			continue
 		}
 
 		if root != "" {
-			out = append(out, cleanAbs(filepath.Join(root, p)))
+			out = append(out, joinPath(root, p))
 			continue
 		}
 
@@ -144,68 +345,145 @@ func toBackslashes(p string) string {
 	return strings.ReplaceAll(p, "/", "\\")
 }
 
+// Color merge policies for road types present in both merged configs (--merge-colors).
+const (
+	mergeColorsFirst   = "first"   // keep the existing custom color
+	mergeColorsLast    = "last"    // take the incoming custom color
+	mergeColorsAverage = "average" // blend both custom colors (roadparts.MixColors)
+)
+
 // mergeConfigWithFile merges the config with the input tv4p file.
-func mergeConfigWithFile(cfg tv4p.RoadConfig, data []byte) (tv4p.RoadConfig, error) {
-	existing, err := tv4p.ParseRoadTypes(data)
+func mergeConfigWithFile(cfg tv4p.RoadConfig, data []byte, loc tv4p.LocateOptions, colors string) (tv4p.RoadConfig, error) {
+	existing, err := tv4p.ParseRoadTypesWith(data, loc)
 	if err != nil {
 		return cfg, err
 	}
 
-	byName := map[string]*tv4p.RoadType{}
-	for i := range existing.Types {
-		rt := &existing.Types[i]
-		byName[rt.Name] = rt
-	}
+	return tv4p.RoadConfig{Types: mergeRoadTypes(existing.Types, cfg.Types, colors)}, nil
+}
 
-	for _, rt := range cfg.Types {
-		ex := byName[rt.Name]
-		if ex == nil {
-			copyRt := rt
-			existing.Types = append(existing.Types, copyRt)
-			continue
-		}
+// mergeRoadTypes appends incoming road types to existing ones, merging parts of
+// road types with the same name (tv4p.MergeRoadTypes). Colors follow the merge policy.
+func mergeRoadTypes(existing []tv4p.RoadType, incoming []tv4p.RoadType, colors string) []tv4p.RoadType {
+	return tv4p.MergeRoadTypes(existing, incoming, colorMergeFunc(colors))
+}
 
-		ex.StraightParts = append(ex.StraightParts, rt.StraightParts...)
-		ex.CornerParts = append(ex.CornerParts, rt.CornerParts...)
-		ex.TerminatorPart = append(ex.TerminatorPart, rt.TerminatorPart...)
-		if rt.KeyCustom {
-			ex.KeyCustom = true
-			ex.KeyColor = rt.KeyColor
-		}
-		if rt.NormalCustom {
-			ex.NormalCustom = true
-			ex.NormalColor = rt.NormalColor
-		}
-		if rt.Type != 0 {
-			ex.Type = rt.Type
-		}
-		if rt.ID != 0 {
-			ex.ID = rt.ID
-		}
+// colorMergeFunc returns the tv4p.ColorMergeFunc of a --merge-colors policy
+// (nil for last: the incoming color wins).
+func colorMergeFunc(policy string) tv4p.ColorMergeFunc {
+	switch policy {
+	case mergeColorsFirst:
+		return func(ex, _ tv4p.Color) tv4p.Color { return ex }
+	case mergeColorsAverage:
+		return func(ex, in tv4p.Color) tv4p.Color { return roadparts.MixColors(ex, in) }
+	default:
+		return nil
 	}
-
-	return tv4p.RoadConfig{Types: existing.Types}, nil
 }
 
 // cleanAbs cleans a path and returns it as an absolute path.
+//
+// Windows paths are cleaned the same way on every OS, with backslashes:
+// "P:" and "P:/" become "P:\", UNC paths keep their "\\server\share" prefix
+// (the leading "\\" is never collapsed), and long-path prefixes are dropped
+// ("\\?\P:\dz" -> "P:\dz", "\\?\UNC\server\share" -> "\\server\share").
 func cleanAbs(p string) string {
 	p = strings.TrimSpace(p)
 	if p == "" {
 		return ""
 	}
 
-	// Windows drive root normalization
-	// "P:" -> "P:\\"
-	if len(p) == 2 && p[1] == ':' &&
-		((p[0] >= 'A' && p[0] <= 'Z') || (p[0] >= 'a' && p[0] <= 'z')) {
-		return strings.ToUpper(p[:1]) + `:\`
-	}
-
-	// Keep "P:\\" and "P:/" as drive root
-	if len(p) == 3 && p[1] == ':' && (p[2] == '\\' || p[2] == '/') {
-		return strings.ToUpper(p[:1]) + `:\`
+	if vol, rest, ok := splitWindowsVolume(p); ok {
+		return vol + toBackslashes(path.Clean("/"+strings.ReplaceAll(rest, `\`, "/")))
 	}
 
 	// Cross-platform cleanup
 	return filepath.Clean(p)
 }
+
+// splitWindowsVolume splits a Windows path into its volume (upper-case drive "P:"
+// or UNC "\\server\share") and the rest. Long-path prefixes (\\?\, \\.\) are dropped.
+// UNC paths must start with backslashes, so POSIX paths like "//tmp" are not matched.
+func splitWindowsVolume(p string) (vol, rest string, ok bool) {
+	s := p
+	for _, prefix := range []string{`\\?\`, `\\.\`} {
+		if strings.HasPrefix(s, prefix) {
+			s = toBackslashes(s[len(prefix):])
+			if len(s) >= 4 && strings.EqualFold(s[:4], `UNC\`) {
+				s = `\\` + s[4:]
+			}
+			break
+		}
+	}
+
+	if len(s) >= 2 && s[1] == ':' &&
+		((s[0] >= 'A' && s[0] <= 'Z') || (s[0] >= 'a' && s[0] <= 'z')) {
+		return strings.ToUpper(s[:1]) + ":", s[2:], true
+	}
+
+	if strings.HasPrefix(s, `\\`) {
+		parts := strings.SplitN(toBackslashes(s[2:]), `\`, 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return "", "", false
+		}
+		if len(parts) == 3 {
+			rest = parts[2]
+		}
+		return `\\` + parts[0] + `\` + parts[1], rest, true
+	}
+
+	return "", "", false
+}
+
+// isAbsPath reports whether p is absolute on this OS or is a Windows drive/UNC path.
+func isAbsPath(p string) bool {
+	if filepath.IsAbs(p) {
+		return true
+	}
+	vol, rest, ok := splitWindowsVolume(p)
+	return ok && (strings.HasPrefix(vol, `\\`) || rest == "" || rest[0] == '\\' || rest[0] == '/')
+}
+
+// joinPath joins a relative path to root, keeping backslashes for Windows roots.
+func joinPath(root, p string) string {
+	if _, _, ok := splitWindowsVolume(root); ok {
+		return cleanAbs(strings.TrimRight(root, `\/`) + `\` + toBackslashes(p))
+	}
+
+	return cleanAbs(filepath.Join(root, p))
+}
+
+// relPath returns target relative to root, or false if target is not below root.
+// Windows paths are compared case-insensitively by volume and path on every OS.
+func relPath(root, target string) (string, bool) {
+	rootVol, rootRest, rootWin := splitWindowsVolume(root)
+	targetVol, targetRest, targetWin := splitWindowsVolume(target)
+	if rootWin || targetWin {
+		if !rootWin || !targetWin || !strings.EqualFold(rootVol, targetVol) {
+			return "", false
+		}
+
+		r := strings.Trim(toBackslashes(rootRest), `\`)
+		t := strings.Trim(toBackslashes(targetRest), `\`)
+		switch {
+		case r == "":
+			if t == "" {
+				return ".", true
+			}
+			return t, true
+		case strings.EqualFold(t, r):
+			return ".", true
+		case len(t) > len(r) && t[len(r)] == '\\' && strings.EqualFold(t[:len(r)], r):
+			return t[len(r)+1:], true
+		default:
+			return "", false
+		}
+	}
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return rel, true
+}
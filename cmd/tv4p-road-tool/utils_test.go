@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestEncodeConfigSortedMapKeys(t *testing.T) {
+	t.Parallel()
+
+	type withMap struct {
+		Defaults map[string]string `json:"defaults"`
+	}
+	cfg := withMap{Defaults: map[string]string{}}
+	for _, name := range []string{"city", "asf1", "grav", "asf3", "kr_t", "mud", "asf2", "dirt"} {
+		cfg.Defaults[name] = "kr_t_" + name + "_" + name
+	}
+
+	for _, format := range []string{"json", "yaml"} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			first, err := encodeConfig(cfg, format)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			// Map iteration order is randomized: repeat to catch unsorted output.
+			for i := 0; i < 20; i++ {
+				again, err := encodeConfig(cfg, format)
+				if err != nil {
+					t.Fatalf("encode: %v", err)
+				}
+				if !bytes.Equal(first, again) {
+					t.Fatalf("run %d differs:\n%s\nwant:\n%s", i, again, first)
+				}
+			}
+
+			out := string(first)
+			if a, c := strings.Index(out, "asf1"), strings.Index(out, "city"); a < 0 || c < a {
+				t.Fatalf("keys not sorted:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestReadConfigBOMAndSniffing(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tests := []struct {
+		name   string
+		data   string
+		format string
+	}{
+		{name: "bom.yaml", data: "\xEF\xBB\xBF\n  road_types:\n    - name: asf1\n", format: "yaml"},
+		{name: "config.txt", data: "\xEF\xBB\xBF  {\"road_types\": [{\"name\": \"asf1\"}]}\n\n", format: "json"},
+		{name: "config.conf", data: "road_types:\n  - name: asf1\n", format: "yaml"},
+		{name: "indented.yaml", data: "\xEF\xBB\xBF\n\n  road_types:\n    - name: asf1\n  crossroad_types: []\n", format: "yaml"},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got := configFormat(path, []byte(tt.data)); got != tt.format {
+			t.Fatalf("%s: format=%q want %q", tt.name, got, tt.format)
+		}
+
+		cfg, err := readConfig(path, false, nil)
+		if err != nil {
+			t.Fatalf("%s: readConfig: %v", tt.name, err)
+		}
+		if len(cfg.Types) != 1 || cfg.Types[0].Name != "asf1" {
+			t.Fatalf("%s: types=%+v want asf1", tt.name, cfg.Types)
+		}
+	}
+
+	bad := filepath.Join(dir, "bad.txt")
+	if err := os.WriteFile(bad, []byte(`{"road_types": [`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConfig(bad, false, nil); err == nil || !strings.Contains(err.Error(), bad) {
+		t.Fatalf("err=%v want JSON error naming %s", err, bad)
+	}
+
+	badYAML := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(badYAML, []byte("road_types: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConfig(badYAML, false, nil); err == nil || !strings.Contains(err.Error(), badYAML) {
+		t.Fatalf("err=%v want YAML error naming %s", err, badYAML)
+	}
+}
+
+func TestReadConfigGzipRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	want := tv4p.RoadConfig{Types: []tv4p.RoadType{
+		{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}}},
+	}}
+
+	tests := []struct {
+		name   string
+		format string
+	}{
+		{name: "roads.yaml.gz", format: "yaml"},
+		{name: "roads.json.gz", format: "json"},
+		{name: "roads.cfg", format: "json"}, // no .gz: detected by the magic bytes
+	}
+
+	for _, tt := range tests {
+		out, err := encodeConfig(want, tt.format)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", tt.name, err)
+		}
+		if out, err = gzipConfig(out); err != nil {
+			t.Fatalf("%s: gzip: %v", tt.name, err)
+		}
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, out, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := readConfig(path, false, nil)
+		if err != nil {
+			t.Fatalf("%s: readConfig: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: config=%+v want %+v", tt.name, got, want)
+		}
+	}
+
+	bad := filepath.Join(dir, "bad.yaml.gz")
+	if err := os.WriteFile(bad, []byte("road_types: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConfig(bad, false, nil); err == nil || !strings.Contains(err.Error(), "gzip") {
+		t.Fatalf("err=%v want gzip error", err)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/roadparts"
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type validateCmd struct {
+	Args struct {
+		Config string `positional-arg-name:"CONFIG" required:"true" description:"Config file (yaml/json)"`
+	} `positional-args:"true"`
+
+	Input        string `short:"i" long:"tv4p" value-name:"FILE" description:"tv4p file to take road types from when the config has none"`
+	YAMLAdvanced bool   `long:"yaml-advanced" description:"Pre-process the config with yaml.v3: anchors, merge keys, !include, x- keys"`
+	StrictNames  bool   `long:"strict-crossroad-names" description:"Require crossroad names to follow kr_t_<ab>_<c> / kr_x_<ab>_<c>[_<d>]"`
+	AllowUndef   bool   `long:"allow-undef" description:"Leave undefined ${KEY} config references literal instead of failing"`
+
+	ConfigVars []string `long:"config-var" value-name:"KEY=VALUE" description:"Replace ${KEY} in the config text with VALUE (repeatable; fallback: env TV4P_VAR_KEY)"`
+}
+
+// validateCheck is a named config check; the error may join several problems.
+// Problems of a warn check are reported but do not fail validation.
+type validateCheck struct {
+	name string
+	run  func(cfg tv4p.RoadConfig) error
+	warn bool
+}
+
+// errCheckSkipped marks a check that could not run because an earlier one failed.
+var errCheckSkipped = errors.New("skipped")
+
+// validateChecks lists the checks run by the validate command, in order.
+var validateChecks = []validateCheck{
+	{name: "crossroad refs", run: func(cfg tv4p.RoadConfig) error {
+		return tv4p.ValidateCrossroadRefs(cfg.CrossroadTypes, cfg.Types)
+	}},
+	{name: "crossroad defaults", run: func(cfg tv4p.RoadConfig) error {
+		// Unknown refs are reported above; only run the default checks on resolvable configs.
+		if tv4p.ValidateCrossroadRefs(cfg.CrossroadTypes, cfg.Types) != nil {
+			return errCheckSkipped
+		}
+		return tv4p.ValidateCrossroads(cfg.CrossroadTypes, cfg.Types)
+	}},
+	{name: "entry ids", run: tv4p.ValidateUniqueIDs},
+	{name: "part names", warn: true, run: func(cfg tv4p.RoadConfig) error {
+		return validatePartNames(cfg.Types)
+	}},
+}
+
+// crossroadNamesCheck is the opt-in --strict-crossroad-names check.
+var crossroadNamesCheck = validateCheck{name: "crossroad names", run: func(cfg tv4p.RoadConfig) error {
+	return validateCrossroadNames(cfg.CrossroadTypes)
+}}
+
+// validateCrossroadNames reports every crossroad whose name does not parse as
+// kr_t_*/kr_x_* (roadparts.ParseCrossroadBase). Patch derives the def shape from
+// the name prefix, so such crossroads are silently written as T shapes.
+func validateCrossroadNames(crossroads []tv4p.CrossroadType) error {
+	var errs []error
+	for _, cr := range crossroads {
+		if _, ok := roadparts.ParseCrossroadBase(cr.Name); !ok {
+			errs = append(errs, fmt.Errorf("crossroad %q: name does not match kr_t_<ab>_<c> or kr_x_<ab>_<c>[_<d>] (written as T shape)", cr.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validatePartNames reports parts whose object_file basename belongs to another
+// road type than the part name (typically a copy-paste error). The type prefix
+// comes from roadparts.ParseBase; basenames it cannot parse are not checked.
+func validatePartNames(types []tv4p.RoadType) error {
+	var errs []error
+	for _, rt := range types {
+		for _, parts := range [][]tv4p.RoadPart{rt.StraightParts, rt.CornerParts, rt.TerminatorPart} {
+			for _, p := range parts {
+				base := path.Base(strings.ReplaceAll(p.Path, `\`, "/"))
+				base = strings.TrimSuffix(base, path.Ext(base))
+				if strings.EqualFold(base, p.Name) {
+					continue
+				}
+
+				parsed, ok := roadparts.ParseBase(strings.ToLower(base))
+				if !ok || parsed.Kind == roadparts.Crossroad {
+					continue
+				}
+				if !strings.HasPrefix(strings.ToLower(p.Name), parsed.TypeName+"_") {
+					errs = append(errs, fmt.Errorf("road type %q part %q: object_file %q belongs to road type %q",
+						rt.Name, p.Name, p.Path, parsed.TypeName))
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Execute validates a config without patching anything.
+func (c *validateCmd) Execute(_ []string) error {
+	vars, err := parseConfigVars(c.ConfigVars, c.AllowUndef)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := readConfig(c.Args.Config, c.YAMLAdvanced, vars)
+	if err != nil {
+		return err
+	}
+
+	// Crossroads-only configs reference road types stored in the tv4p file.
+	if len(cfg.Types) == 0 && c.Input != "" {
+		data, err := os.ReadFile(c.Input)
+		if err != nil {
+			return err
+		}
+		block, err := tv4p.ParseRoadTypes(data)
+		if err != nil {
+			return err
+		}
+		cfg.Types = block.Types
+	}
+
+	checks := validateChecks
+	if c.StrictNames {
+		checks = append(slices.Clone(checks), crossroadNamesCheck)
+	}
+
+	total, warnings := 0, 0
+	for _, check := range checks {
+		err := check.run(cfg)
+		if errors.Is(err, errCheckSkipped) {
+			fmt.Printf("%s: skipped\n", check.name)
+			continue
+		}
+
+		problems := splitErrors(err)
+		if len(problems) == 0 {
+			fmt.Printf("%s: ok\n", check.name)
+			continue
+		}
+
+		label := "problem(s)"
+		if check.warn {
+			label = "warning(s)"
+			warnings += len(problems)
+		} else {
+			total += len(problems)
+		}
+		fmt.Printf("%s: %d %s\n", check.name, len(problems), label)
+		for _, p := range problems {
+			fmt.Printf("  - %v\n", p)
+		}
+	}
+
+	if total > 0 {
+		return fmt.Errorf("validation failed: %d problem(s)", total)
+	}
+	if warnings > 0 {
+		cliLog.Warnf("validation passed with %d warning(s)", warnings)
+	}
+
+	return nil
+}
+
+// splitErrors flattens an errors.Join result into its parts.
+func splitErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		return joined.Unwrap()
+	}
+
+	return []error{err}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestValidateCrossroadNames(t *testing.T) {
+	t.Parallel()
+
+	crossroads := []tv4p.CrossroadType{
+		{Name: "kr_t_asf1_city"},
+		{Name: "kr_x_city_city"},
+		{Name: "kr_x_asf1_city_gr"},
+		{Name: "kr_t_asf1"},
+		{Name: "crossing_asf1"},
+		{Name: "KR_X_asf1_city"},
+	}
+
+	problems := splitErrors(validateCrossroadNames(crossroads))
+	want := []string{"kr_t_asf1", "crossing_asf1", "KR_X_asf1_city"}
+	if len(problems) != len(want) {
+		t.Fatalf("problems=%v want %d", problems, len(want))
+	}
+	for i, p := range problems {
+		if got := p.Error(); !strings.HasPrefix(got, `crossroad "`+want[i]+`"`) {
+			t.Fatalf("problem %d=%q want crossroad %q", i, got, want[i])
+		}
+	}
+
+	if err := validateCrossroadNames(crossroads[:3]); err != nil {
+		t.Fatalf("valid names: err=%v", err)
+	}
+}
+
+func TestValidatePartNames(t *testing.T) {
+	t.Parallel()
+
+	types := []tv4p.RoadType{
+		{
+			Name: "asf1",
+			StraightParts: []tv4p.RoadPart{
+				{Name: "asf1_12", Path: `P:\DZ\roads\asf1_12.p3d`},
+				{Name: "asf1_25", Path: "dz/roads/ASF1_6.p3d"}, // same type, other size: ok
+				{Name: "asf1_6", Path: `dz\roads\asf2_6.p3d`},  // copy-paste from asf2
+				{Name: "custom", Path: `dz\roads\special.p3d`}, // basename not parseable: skipped
+			},
+			TerminatorPart: []tv4p.RoadPart{
+				{Name: "asf1_6konec", Path: `dz\roads\asf1_6konec.p3d`},
+				{Name: "asf1_6konec", Path: `dz\roads\city_6konec.p3d`},
+			},
+		},
+	}
+
+	problems := splitErrors(validatePartNames(types))
+	want := []string{`part "asf1_6"`, `part "asf1_6konec"`}
+	if len(problems) != len(want) {
+		t.Fatalf("problems=%v want %d", problems, len(want))
+	}
+	for i, p := range problems {
+		if got := p.Error(); !strings.Contains(got, want[i]) {
+			t.Fatalf("problem %d=%q want %s", i, got, want[i])
+		}
+	}
+}
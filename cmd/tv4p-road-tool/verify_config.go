@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/invopop/yaml"
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+type verifyConfigCmd struct {
+	Args struct {
+		Config string `positional-arg-name:"CONFIG" required:"true" description:"Portable config file (yaml/json) written with extract --portable --with-checksum"`
+	} `positional-args:"true"`
+}
+
+// Execute recomputes the checksum of a portable config and compares it with the stored one.
+func (c *verifyConfigCmd) Execute(_ []string) error {
+	cfg, err := readPortableConfig(c.Args.Config)
+	if err != nil {
+		return err
+	}
+	if err := cfg.VerifyChecksum(); err != nil {
+		return fmt.Errorf("%s: %w", c.Args.Config, err)
+	}
+
+	cliLog.Infof("%s: checksum %s ok", c.Args.Config, cfg.Checksum)
+	return nil
+}
+
+// readPortableConfig reads a yaml/json portable config (optionally gzipped).
+func readPortableConfig(path string) (tv4p.PortableConfig, error) {
+	raw, err := readConfigFile(path)
+	if err != nil {
+		return tv4p.PortableConfig{}, err
+	}
+
+	var cfg tv4p.PortableConfig
+	if err := yaml.Unmarshal(trimConfigText(raw), &cfg); err != nil {
+		return tv4p.PortableConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
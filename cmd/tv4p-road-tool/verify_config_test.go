@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestVerifyConfigChecksum(t *testing.T) {
+	t.Parallel()
+
+	cfg := tv4p.RoadConfig{
+		Types: []tv4p.RoadType{
+			{Name: "asf1", StraightParts: []tv4p.RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}}},
+			{Name: "city", StraightParts: []tv4p.RoadPart{{Name: "city_12", Path: `dz\roads\city_12.p3d`}}},
+		},
+		CrossroadTypes: []tv4p.CrossroadType{
+			{Name: "kr_t_asf1_city", Model: `dz\roads\kr_t_asf1_city.p3d`, Connections: tv4p.CrossroadConnections{A: "asf1", B: "asf1", C: "city"}},
+		},
+	}
+
+	dir := t.TempDir()
+	for _, scope := range []tv4p.Scope{tv4p.ScopeAll, tv4p.ScopeRoads, tv4p.ScopeCrossroad} {
+		for _, format := range []string{"yaml", "json"} {
+			pc, err := withPortableChecksum(tv4p.ToPortableConfig(cfg), scope)
+			if err != nil {
+				t.Fatalf("%s/%s: checksum: %v", scope, format, err)
+			}
+			out, err := encodeConfig(filterPortableByScope(pc, scope), format)
+			if err != nil {
+				t.Fatalf("%s/%s: encode: %v", scope, format, err)
+			}
+			if !strings.Contains(string(out), pc.Checksum) {
+				t.Fatalf("%s/%s: output has no checksum:\n%s", scope, format, out)
+			}
+
+			path := filepath.Join(dir, string(scope)+"."+format)
+			if err := os.WriteFile(path, out, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cmd := &verifyConfigCmd{}
+			cmd.Args.Config = path
+			if err := cmd.Execute(nil); err != nil {
+				t.Fatalf("%s/%s: verify: %v", scope, format, err)
+			}
+
+			edited := strings.Replace(string(out), "asf1_12", "asf1_25", 1)
+			if edited == string(out) {
+				edited = strings.Replace(string(out), "kr_t_asf1_city", "kr_t_asf1_asf1", 1)
+			}
+			if err := os.WriteFile(path, []byte(edited), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := cmd.Execute(nil); err == nil {
+				t.Fatalf("%s/%s: hand edit: want checksum mismatch", scope, format)
+			}
+		}
+	}
+}
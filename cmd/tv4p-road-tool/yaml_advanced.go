@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+// includeTag marks a scalar whose value is a YAML file to splice in (--yaml-advanced).
+const includeTag = "!include"
+
+// decodeAdvancedConfig decodes a YAML config through a yaml.v3 pre-processing step
+// (--yaml-advanced). Supported on top of plain YAML/JSON:
+//   - anchors, aliases and merge keys (`<<: *a`, `<<: [*a, *b]`), expanded in place;
+//   - `!include FILE` on any value, resolved relative to the including file
+//     (nested includes are allowed, cycles are an error, anchors do not cross files);
+//   - top-level keys starting with `x-` are dropped, so they can hold anchor definitions.
+//
+// raw is expected to have its ${KEY} references substituted already; vars (nil: none)
+// substitutes them in included files.
+func decodeAdvancedConfig(raw []byte, path string, vars *configVars) (tv4p.RoadConfig, error) {
+	v, err := expandYAML(raw, path, map[string]bool{}, vars)
+	if err != nil {
+		return tv4p.RoadConfig{}, err
+	}
+
+	if m, ok := v.(map[string]any); ok {
+		for k := range m {
+			if strings.HasPrefix(k, "x-") {
+				delete(m, k)
+			}
+		}
+	}
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		return tv4p.RoadConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var cfg tv4p.RoadConfig
+	if err := json.Unmarshal(js, &cfg); err != nil {
+		return tv4p.RoadConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// expandYAML parses raw (read from path) with includes resolved and returns the plain value.
+// seen holds the absolute paths of the files being included, for cycle detection.
+func expandYAML(raw []byte, path string, seen map[string]bool, vars *configVars) (any, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	seen[abs] = true
+	defer delete(seen, abs)
+
+	if err := resolveIncludes(doc.Content[0], filepath.Dir(abs), seen, vars); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var v any
+	if err := doc.Decode(&v); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return v, nil
+}
+
+// resolveIncludes replaces `!include FILE` scalars below n with the parsed file content,
+// after substituting its ${KEY} references with vars.
+func resolveIncludes(n *yamlv3.Node, dir string, seen map[string]bool, vars *configVars) error {
+	if n.Kind == yamlv3.AliasNode {
+		return nil
+	}
+
+	if n.Kind == yamlv3.ScalarNode && n.Tag == includeTag {
+		path := n.Value
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if seen[abs] {
+			return fmt.Errorf("include cycle at %s", n.Value)
+		}
+
+		raw, err := os.ReadFile(abs)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		if raw, err = vars.expand(raw); err != nil {
+			return fmt.Errorf("%s: %w", n.Value, err)
+		}
+
+		var inc yamlv3.Node
+		if err := yamlv3.Unmarshal(raw, &inc); err != nil {
+			return fmt.Errorf("%s: %w", n.Value, err)
+		}
+		if len(inc.Content) == 0 {
+			return fmt.Errorf("%s: empty include", n.Value)
+		}
+
+		seen[abs] = true
+		defer delete(seen, abs)
+		if err := resolveIncludes(inc.Content[0], filepath.Dir(abs), seen, vars); err != nil {
+			return fmt.Errorf("%s: %w", n.Value, err)
+		}
+
+		*n = *inc.Content[0]
+		return nil
+	}
+
+	for _, c := range n.Content {
+		if err := resolveIncludes(c, dir, seen, vars); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigYAMLAdvanced(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+		return path
+	}
+
+	write("corners.yaml", `
+- name: asf1_7 100
+  object_file: dz\roads\asf1_7 100.p3d
+`)
+	path := write("roads.yaml", `
+x-base: &base
+  normal_parts_custom: true
+  normal_parts_color: {R: 10, G: 20, B: 30, A: 255}
+  corner_parts: !include corners.yaml
+road_types:
+  - <<: *base
+    name: asf1
+  - <<: *base
+    name: asf2
+    normal_parts_color: {R: 1, G: 2, B: 3, A: 255}
+`)
+
+	cfg, err := readConfig(path, true, nil)
+	if err != nil {
+		t.Fatalf("readConfig: %v", err)
+	}
+	if len(cfg.Types) != 2 {
+		t.Fatalf("road types=%d want 2", len(cfg.Types))
+	}
+	for i, want := range []struct {
+		name string
+		r    uint8
+	}{{"asf1", 10}, {"asf2", 1}} {
+		rt := cfg.Types[i]
+		if rt.Name != want.name || !rt.NormalCustom || rt.NormalColor.R != want.r {
+			t.Fatalf("type %d=%+v want name %q custom color R=%d", i, rt, want.name, want.r)
+		}
+		if len(rt.CornerParts) != 1 || rt.CornerParts[0].Name != "asf1_7 100" {
+			t.Fatalf("type %q corner parts=%+v want included part", rt.Name, rt.CornerParts)
+		}
+	}
+
+	cycle := write("cycle.yaml", "road_types: !include cycle.yaml\n")
+	if _, err := readConfig(cycle, true, nil); err == nil || !strings.Contains(err.Error(), "include cycle") {
+		t.Fatalf("err=%v want include cycle", err)
+	}
+}
@@ -6,9 +6,7 @@ require (
 	github.com/cespare/xxhash v1.1.0
 	github.com/invopop/yaml v0.3.1
 	github.com/jessevdk/go-flags v1.6.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	golang.org/x/sys v0.21.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
-)
+require golang.org/x/sys v0.21.0 // indirect
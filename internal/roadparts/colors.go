@@ -2,9 +2,26 @@ package roadparts
 
 import "github.com/woozymasta/tv4p-road-tool/internal/tv4p"
 
+// MatchMode selects how a palette rule key is matched against a lower-case name.
+type MatchMode string
+
+const (
+	// MatchContains matches the key anywhere in the name (the default).
+	MatchContains MatchMode = "contains"
+
+	// MatchPrefix matches the key at the start of a word (the name start or after
+	// a non-alphanumeric character). A key ending in a digit must not be followed
+	// by another digit, so "asf1" does not match "asf10".
+	MatchPrefix MatchMode = "prefix"
+
+	// MatchExact matches the key as a whole word of the name.
+	MatchExact MatchMode = "exact"
+)
+
 // paletteRule represents a color palette rule.
 type paletteRule struct {
 	Keys   []string   // Keys to match (e.g. ["snow", "runway"])
+	Match  MatchMode  // how Keys are matched (empty: MatchContains)
 	Normal tv4p.Color // Normal color (e.g. {R: 170, G: 220, B: 255, A: 255})
 	Key    tv4p.Color // Key color (e.g. {R: 90, G: 150, B: 210, A: 255})
 }
@@ -53,21 +70,25 @@ var paletteRules = []paletteRule{
 	},
 	{
 		Keys:   []string{"asf1"},
+		Match:  MatchPrefix,
 		Normal: tv4p.Color{R: 110, G: 125, B: 150, A: 255},
 		Key:    tv4p.Color{R: 60, G: 80, B: 110, A: 255},
 	},
 	{
 		Keys:   []string{"asf2"},
+		Match:  MatchPrefix,
 		Normal: tv4p.Color{R: 120, G: 115, B: 110, A: 255},
 		Key:    tv4p.Color{R: 70, G: 70, B: 65, A: 255},
 	},
 	{
 		Keys:   []string{"asf3"},
+		Match:  MatchPrefix,
 		Normal: tv4p.Color{R: 125, G: 105, B: 90, A: 255},
 		Key:    tv4p.Color{R: 75, G: 60, B: 45, A: 255},
 	},
 	{
 		Keys:   []string{"asf"},
+		Match:  MatchPrefix,
 		Normal: tv4p.Color{R: 95, G: 115, B: 140, A: 255},
 		Key:    tv4p.Color{R: 50, G: 70, B: 100, A: 255},
 	},
@@ -0,0 +1,92 @@
+package roadparts
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+// paletteRetries is how many salted re-hashes PaletteDistinct tries per name.
+const paletteRetries = 32
+
+// PaletteColor is the normal/key color pair of a road type.
+type PaletteColor struct {
+	Normal tv4p.Color // normal parts color
+	Key    tv4p.Color // key parts color
+}
+
+// HasPaletteRule reports whether a name gets fixed colors from a palette rule.
+func HasPaletteRule(name string) bool {
+	name = strings.ToLower(name)
+	for _, rule := range paletteRules {
+		if rule.matches(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PaletteDistinct assigns colors to names like PaletteWith, in two phases.
+//
+// Names matching a palette rule keep their fixed colors and are assigned first.
+// Then, in order, every other name gets its hashed color; while its normal color is
+// closer than minDist (RGB distance) to any color assigned so far, the name is
+// re-hashed with a "#n" salt. After paletteRetries tries the farthest candidate wins.
+// minDist <= 0 returns the plain PaletteWith colors. bounds limit the hashed clamp
+// colors (see PaletteWithBounds).
+func PaletteDistinct(names []string, mode PaletteMode, minDist float64, bounds ColorBounds) []PaletteColor {
+	out := make([]PaletteColor, len(names))
+	var used []tv4p.Color
+
+	for i, name := range names {
+		if HasPaletteRule(name) || minDist <= 0 {
+			out[i].Normal, out[i].Key, _ = PaletteWithBounds(name, mode, bounds)
+			used = append(used, out[i].Normal)
+		}
+	}
+	if minDist <= 0 {
+		return out
+	}
+
+	for i, name := range names {
+		if HasPaletteRule(name) {
+			continue
+		}
+
+		bestDist := -1.0
+		for try := 0; try <= paletteRetries; try++ {
+			salted := name
+			if try > 0 {
+				salted += "#" + strconv.Itoa(try)
+			}
+			normal, key, _ := PaletteWithBounds(salted, mode, bounds)
+
+			d := nearestColorDist(normal, used)
+			if d > bestDist {
+				bestDist = d
+				out[i] = PaletteColor{Normal: normal, Key: key}
+			}
+			if d >= minDist {
+				break
+			}
+		}
+		used = append(used, out[i].Normal)
+	}
+
+	return out
+}
+
+// nearestColorDist returns the RGB distance from c to the closest color in used
+// (+Inf when used is empty).
+func nearestColorDist(c tv4p.Color, used []tv4p.Color) float64 {
+	best := math.Inf(1)
+	for _, u := range used {
+		dr, dg, db := float64(c.R)-float64(u.R), float64(c.G)-float64(u.G), float64(c.B)-float64(u.B)
+		best = min(best, math.Sqrt(dr*dr+dg*dg+db*db))
+	}
+
+	return best
+}
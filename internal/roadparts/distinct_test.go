@@ -0,0 +1,50 @@
+package roadparts
+
+import (
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
+
+func TestPaletteDistinct(t *testing.T) {
+	t.Parallel()
+
+	// road217 hashes close to the asf1 rule color (distance ~10) and comes first,
+	// so the rule colors must be reserved before hashed names are assigned.
+	names := []string{"road217", "asf1", "road217_b", "gravel_x"}
+	const minDist = 40
+
+	got := PaletteDistinct(names, PaletteClamp, minDist, DefaultColorBounds)
+
+	asf1, asf1Key, _ := PaletteWith("asf1", PaletteClamp)
+	if got[1].Normal != asf1 || got[1].Key != asf1Key {
+		t.Fatalf("asf1=%+v want rule colors %v/%v", got[1], asf1, asf1Key)
+	}
+
+	plain, _, _ := PaletteWith("road217", PaletteClamp)
+	if nearestColorDist(plain, []tv4p.Color{asf1}) >= minDist {
+		t.Fatalf("fixture: road217 %v no longer collides with asf1 %v", plain, asf1)
+	}
+	if got[0].Normal == plain {
+		t.Fatalf("road217 kept its colliding color %v", plain)
+	}
+
+	for i := range got {
+		var others []tv4p.Color
+		for j := range got {
+			if j != i {
+				others = append(others, got[j].Normal)
+			}
+		}
+		if d := nearestColorDist(got[i].Normal, others); d < minDist {
+			t.Fatalf("%s: color %v is %.1f from another color, want >= %d", names[i], got[i].Normal, d, minDist)
+		}
+	}
+
+	for i, c := range PaletteDistinct(names, PaletteClamp, 0, DefaultColorBounds) {
+		normal, key, _ := PaletteWith(names[i], PaletteClamp)
+		if c.Normal != normal || c.Key != key {
+			t.Fatalf("%s: minDist 0=%+v want plain %v/%v", names[i], c, normal, key)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package roadparts
 
 import (
 	"encoding/binary"
+	"fmt"
+	"math"
 	"strings"
 
 	"github.com/cespare/xxhash"
@@ -9,22 +11,114 @@ import (
 	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
 )
 
+// PaletteMode selects the generator used for names without a palette rule.
+type PaletteMode string
+
+const (
+	// PaletteClamp hashes the name to RGB and clamps channels to 40..220 (muted tones,
+	// see ColorBounds).
+	PaletteClamp PaletteMode = "clamp"
+
+	// PaletteHSV hashes the name to a hue with fixed high saturation/value (bright, distinct).
+	PaletteHSV PaletteMode = "hsv"
+)
+
+// HSV generator parameters: key color uses a lower value for contrast.
+const (
+	hsvSaturation = 0.75
+	hsvValue      = 0.95
+	hsvKeyValue   = 0.6
+)
+
+// ColorBounds are the channel limits of clamp palette colors.
+// The zero value means DefaultColorBounds.
+type ColorBounds struct {
+	Min byte // lowest channel value
+	Max byte // highest channel value
+}
+
+// DefaultColorBounds avoid pure black and white in generated colors.
+var DefaultColorBounds = ColorBounds{Min: 40, Max: 220}
+
+// Validate checks that Min is below Max.
+func (b ColorBounds) Validate() error {
+	if b.Min >= b.Max {
+		return fmt.Errorf("color bounds: min %d must be below max %d", b.Min, b.Max)
+	}
+
+	return nil
+}
+
+// orDefault returns DefaultColorBounds for the zero value.
+func (b ColorBounds) orDefault() ColorBounds {
+	if b == (ColorBounds{}) {
+		return DefaultColorBounds
+	}
+
+	return b
+}
+
+// World names detected from road type names (see World).
+const (
+	WorldSakhal = "sakhal" // blue tint
+	WorldEnoch  = "enoch"  // green tint
+)
+
+// World returns the world a road type name belongs to, using the same name
+// substrings that select the palette tint: "sakhal", "enoch", or "" for none.
+// Sakhal wins when a name contains both.
+func World(name string) string {
+	shiftBlue, shiftGreen := worldTints(strings.ToLower(name))
+	switch {
+	case shiftBlue:
+		return WorldSakhal
+	case shiftGreen:
+		return WorldEnoch
+	default:
+		return ""
+	}
+}
+
+// worldTints reports which world tints apply to a lower-case name.
+func worldTints(name string) (shiftBlue bool, shiftGreen bool) {
+	return strings.Contains(name, WorldSakhal), strings.Contains(name, WorldEnoch)
+}
+
 // Palette returns the color palette for a road part name.
 func Palette(name string) (tv4p.Color, tv4p.Color, bool) {
+	return PaletteWith(name, PaletteClamp)
+}
+
+// PaletteWith returns the color palette for a road part name using the given generator.
+// Known names (palette rules) get the same colors in every mode. An empty mode means clamp.
+func PaletteWith(name string, mode PaletteMode) (tv4p.Color, tv4p.Color, bool) {
+	return PaletteWithBounds(name, mode, DefaultColorBounds)
+}
+
+// PaletteWithBounds is PaletteWith with custom clamp channel limits. They apply to
+// hashed clamp colors and to world tints; palette rule colors and HSV colors ignore them.
+func PaletteWithBounds(name string, mode PaletteMode, bounds ColorBounds) (tv4p.Color, tv4p.Color, bool) {
+	bounds = bounds.orDefault()
 	name = strings.ToLower(name)
-	shiftBlue := strings.Contains(name, "sakhal")
-	shiftGreen := strings.Contains(name, "enoch")
+	shiftBlue, shiftGreen := worldTints(name)
 
 	for _, rule := range paletteRules {
 		if rule.matches(name) {
-			normal, key := applyWorldTint(rule.Normal, rule.Key, shiftBlue, shiftGreen)
+			normal, key := applyWorldTint(rule.Normal, rule.Key, shiftBlue, shiftGreen, bounds)
 			return normal, key, true
 		}
 	}
 
-	normal := hashColor(name)
-	key := darkenAndSaturate(normal, 0.7, 1.25)
-	normal, key = applyWorldTint(normal, key, shiftBlue, shiftGreen)
+	if mode == PaletteHSV {
+		// No world tint: it clamps channels and would mute the colors again.
+		// The world name is part of the hashed name, so the hue differs anyway.
+		hue := hashHue(name)
+		return hsvToRGB(hue, hsvSaturation, hsvValue), hsvToRGB(hue, hsvSaturation, hsvKeyValue), true
+	}
+
+	normal := hashColor(name, bounds)
+	key := darkenAndSaturate(normal, 0.7, 1.25, bounds)
+	normal, key = applyWorldTint(normal, key, shiftBlue, shiftGreen, bounds)
 
 	return normal, key, true
 }
@@ -32,7 +126,7 @@ func Palette(name string) (tv4p.Color, tv4p.Color, bool) {
 // matches checks if a name matches a palette rule.
 func (r paletteRule) matches(name string) bool {
 	for _, k := range r.Keys {
-		if strings.Contains(name, k) {
+		if matchKey(name, k, r.Match) {
 			return true
 		}
 	}
@@ -40,68 +134,159 @@ func (r paletteRule) matches(name string) bool {
 	return false
 }
 
-// hashColor hashes a name to a color.
-func hashColor(name string) tv4p.Color {
+// matchKey reports whether key occurs in name as selected by mode.
+func matchKey(name string, key string, mode MatchMode) bool {
+	if mode == "" || mode == MatchContains {
+		return strings.Contains(name, key)
+	}
+	if key == "" {
+		return false
+	}
+
+	for off := 0; ; {
+		i := strings.Index(name[off:], key)
+		if i < 0 {
+			return false
+		}
+		start := off + i
+		end := start + len(key)
+		off = start + 1
+
+		if start > 0 && isAlnum(name[start-1]) {
+			continue
+		}
+		if end == len(name) {
+			return true
+		}
+
+		next := name[end]
+		switch mode {
+		case MatchExact:
+			if !isAlnum(next) {
+				return true
+			}
+		case MatchPrefix:
+			if !isDigit(key[len(key)-1]) || !isDigit(next) {
+				return true
+			}
+		}
+	}
+}
+
+// isAlnum reports whether b is an ASCII letter or digit.
+func isAlnum(b byte) bool {
+	return isDigit(b) || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// isDigit reports whether b is an ASCII digit.
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// hashColor hashes a name to a color within bounds.
+// Channels start in the upper part of the range (60..219 for the default 40..220)
+// and are then spread around their mean.
+func hashColor(name string, bounds ColorBounds) tv4p.Color {
 	h64 := xxhash.Sum64String(name)
 	var buf [8]byte
 	binary.LittleEndian.PutUint64(buf[:], h64)
 	lo := binary.LittleEndian.Uint32(buf[:4])
 	hi := binary.LittleEndian.Uint32(buf[4:])
 	h := lo ^ hi
-	r := byte(60 + (h&0xff)%160)
-	g := byte(60 + ((h>>8)&0xff)%160)
-	b := byte(60 + ((h>>16)&0xff)%160)
+	span := uint32(bounds.Max) - uint32(bounds.Min)
+	inset := span / 9
+	base := uint32(bounds.Min) + inset
+	n := span - inset
+	r := byte(base + (h&0xff)%n)
+	g := byte(base + ((h>>8)&0xff)%n)
+	b := byte(base + ((h>>16)&0xff)%n)
 	avg := (int(r) + int(g) + int(b)) / 3
-	r = clampByte(avg + int(float64(int(r)-avg)*1.2))
-	g = clampByte(avg + int(float64(int(g)-avg)*1.2))
-	b = clampByte(avg + int(float64(int(b)-avg)*1.2))
+	r = clampByte(avg+int(float64(int(r)-avg)*1.2), bounds)
+	g = clampByte(avg+int(float64(int(g)-avg)*1.2), bounds)
+	b = clampByte(avg+int(float64(int(b)-avg)*1.2), bounds)
 
 	return tv4p.Color{R: r, G: g, B: b, A: 255}
 }
 
-// darkenAndSaturate darkens and saturates a color.
-func darkenAndSaturate(c tv4p.Color, darken float64, sat float64) tv4p.Color {
+// hashHue hashes a name to a hue in degrees [0, 360).
+func hashHue(name string) float64 {
+	return float64(xxhash.Sum64String(name)%3600) / 10
+}
+
+// hsvToRGB converts HSV (h in degrees, s/v in [0, 1]) to an opaque RGB color.
+func hsvToRGB(h float64, s float64, v float64) tv4p.Color {
+	c := v * s
+	hp := h / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case hp < 1:
+		r, g = c, x
+	case hp < 2:
+		r, g = x, c
+	case hp < 3:
+		g, b = c, x
+	case hp < 4:
+		g, b = x, c
+	case hp < 5:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+
+	m := v - c
+	return tv4p.Color{
+		R: byte(clamp255(int(math.Round((r + m) * 255)))),
+		G: byte(clamp255(int(math.Round((g + m) * 255)))),
+		B: byte(clamp255(int(math.Round((b + m) * 255)))),
+		A: 255,
+	}
+}
+
+// darkenAndSaturate darkens and saturates a color within bounds.
+func darkenAndSaturate(c tv4p.Color, darken float64, sat float64, bounds ColorBounds) tv4p.Color {
 	avg := (int(c.R) + int(c.G) + int(c.B)) / 3
-	r := clampByte(int(float64(int(c.R)-avg)*sat) + avg)
-	g := clampByte(int(float64(int(c.G)-avg)*sat) + avg)
-	b := clampByte(int(float64(int(c.B)-avg)*sat) + avg)
-	r = clampByte(int(float64(r) * darken))
-	g = clampByte(int(float64(g) * darken))
-	b = clampByte(int(float64(b) * darken))
+	r := clampByte(int(float64(int(c.R)-avg)*sat)+avg, bounds)
+	g := clampByte(int(float64(int(c.G)-avg)*sat)+avg, bounds)
+	b := clampByte(int(float64(int(c.B)-avg)*sat)+avg, bounds)
+	r = clampByte(int(float64(r)*darken), bounds)
+	g = clampByte(int(float64(g)*darken), bounds)
+	b = clampByte(int(float64(b)*darken), bounds)
 
 	return tv4p.Color{R: r, G: g, B: b, A: 255}
 }
 
 // applyWorldTint applies a world tint to a color.
-func applyWorldTint(normal tv4p.Color, key tv4p.Color, shiftBlue bool, shiftGreen bool) (tv4p.Color, tv4p.Color) {
+func applyWorldTint(normal tv4p.Color, key tv4p.Color, shiftBlue bool, shiftGreen bool, bounds ColorBounds) (tv4p.Color, tv4p.Color) {
 	if shiftBlue {
-		normal = shiftChannel(normal, 0, 25)
-		key = shiftChannel(key, 0, 18)
+		normal = shiftChannel(normal, 0, 25, bounds)
+		key = shiftChannel(key, 0, 18, bounds)
 	}
 	if shiftGreen {
-		normal = shiftChannel(normal, 20, 0)
-		key = shiftChannel(key, 14, 0)
+		normal = shiftChannel(normal, 20, 0, bounds)
+		key = shiftChannel(key, 14, 0, bounds)
 	}
 
 	return normal, key
 }
 
 // shiftChannel shifts a channel of a color.
-func shiftChannel(c tv4p.Color, dg int, db int) tv4p.Color {
-	r := clampByte(int(c.R))
-	g := clampByte(int(c.G) + dg)
-	b := clampByte(int(c.B) + db)
+func shiftChannel(c tv4p.Color, dg int, db int, bounds ColorBounds) tv4p.Color {
+	r := clampByte(int(c.R), bounds)
+	g := clampByte(int(c.G)+dg, bounds)
+	b := clampByte(int(c.B)+db, bounds)
 
 	return tv4p.Color{R: r, G: g, B: b, A: 255}
 }
 
-// clampByte clamps a byte value.
-func clampByte(v int) byte {
-	if v < 40 {
-		return 40
+// clampByte clamps a channel value to bounds.
+func clampByte(v int, bounds ColorBounds) byte {
+	if v < int(bounds.Min) {
+		return bounds.Min
 	}
-	if v > 220 {
-		return 220
+	if v > int(bounds.Max) {
+		return bounds.Max
 	}
 
 	return byte(v)
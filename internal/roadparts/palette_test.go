@@ -1,6 +1,10 @@
 package roadparts
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/woozymasta/tv4p-road-tool/internal/tv4p"
+)
 
 func TestPaletteBasic(t *testing.T) {
 	t.Parallel()
@@ -36,3 +40,224 @@ func TestPaletteBasic(t *testing.T) {
 		})
 	}
 }
+
+func TestPaletteHSV(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"weird_type_123", "weird_type_124", "my_dirt", "sakhal_custom", "enoch_custom"}
+	seen := map[tv4p.Color]string{}
+	for _, name := range names {
+		normal, key, ok := PaletteWith(name, PaletteHSV)
+		if !ok {
+			t.Fatalf("%s: ok=false", name)
+		}
+		if normal.A != 255 || key.A != 255 {
+			t.Fatalf("%s: alpha not 255", name)
+		}
+
+		// Fixed high saturation/value: max channel ~ 0.95*255, min ~ 0.25 of it.
+		hi := max(normal.R, normal.G, normal.B)
+		lo := min(normal.R, normal.G, normal.B)
+		if hi < 240 || lo > 70 {
+			t.Fatalf("%s: normal=%+v not bright/saturated", name, normal)
+		}
+		if max(key.R, key.G, key.B) >= hi {
+			t.Fatalf("%s: key=%+v not darker than normal=%+v", name, key, normal)
+		}
+
+		if other, ok := seen[normal]; ok {
+			t.Fatalf("%s and %s share color %+v", name, other, normal)
+		}
+		seen[normal] = name
+	}
+
+	// Rule colors are the same in every mode.
+	a, _, _ := PaletteWith("asf1", PaletteHSV)
+	b, _, _ := Palette("asf1")
+	if a != b {
+		t.Fatalf("rule color differs: hsv=%+v clamp=%+v", a, b)
+	}
+}
+
+func TestHSVToRGB(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		h, s, v float64
+		want    tv4p.Color
+	}{
+		{h: 0, s: 1, v: 1, want: tv4p.Color{R: 255, A: 255}},
+		{h: 120, s: 1, v: 1, want: tv4p.Color{G: 255, A: 255}},
+		{h: 240, s: 1, v: 1, want: tv4p.Color{B: 255, A: 255}},
+		{h: 60, s: 1, v: 1, want: tv4p.Color{R: 255, G: 255, A: 255}},
+		{h: 300, s: 0, v: 0.5, want: tv4p.Color{R: 128, G: 128, B: 128, A: 255}},
+	}
+
+	for _, tt := range tests {
+		if got := hsvToRGB(tt.h, tt.s, tt.v); got != tt.want {
+			t.Fatalf("hsv(%v,%v,%v)=%+v want %+v", tt.h, tt.s, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestWorld(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "sakhal_asf2", want: WorldSakhal},
+		{name: "Sakhal_City", want: WorldSakhal},
+		{name: "enoch_city", want: WorldEnoch},
+		{name: "asf1_enoch", want: WorldEnoch},
+		{name: "sakhal_enoch_mix", want: WorldSakhal},
+		{name: "asf1", want: ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := World(tt.name); got != tt.want {
+				t.Fatalf("world=%q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaletteAsfPrecedence(t *testing.T) {
+	t.Parallel()
+
+	ruleNormal := map[string]tv4p.Color{}
+	for _, r := range paletteRules {
+		for _, k := range r.Keys {
+			ruleNormal[k] = r.Normal
+		}
+	}
+
+	tests := []struct {
+		name string
+		rule string // "" = no rule, hashed color
+	}{
+		{name: "asf1", rule: "asf1"},
+		{name: "asf10", rule: "asf"},
+		{name: "asf", rule: "asf"},
+		{name: "asf2_old", rule: "asf2"},
+		{name: "dz_asf3", rule: "asf3"},
+		{name: "gasfield"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			normal, _, _ := Palette(tt.name)
+			if tt.rule == "" {
+				if HasPaletteRule(tt.name) {
+					t.Fatalf("%s matched a palette rule", tt.name)
+				}
+				return
+			}
+			if want := ruleNormal[tt.rule]; normal != want {
+				t.Fatalf("normal=%+v want %s rule %+v", normal, tt.rule, want)
+			}
+		})
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		key  string
+		mode MatchMode
+		want bool
+	}{
+		{name: "asf10", key: "asf1", mode: MatchContains, want: true},
+		{name: "asf10", key: "asf1", mode: MatchPrefix},
+		{name: "asf10", key: "asf", mode: MatchPrefix, want: true},
+		{name: "asf1_asf10", key: "asf1", mode: MatchPrefix, want: true},
+		{name: "asf10_asf1", key: "asf1", mode: MatchPrefix, want: true},
+		{name: "old_asf1b", key: "asf1", mode: MatchPrefix, want: true},
+		{name: "gasf1", key: "asf1", mode: MatchPrefix},
+		{name: "city_old", key: "city", mode: MatchExact, want: true},
+		{name: "cityold", key: "city", mode: MatchExact},
+		{name: "asf1", key: "", mode: MatchPrefix},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name+"/"+tt.key+"/"+string(tt.mode), func(t *testing.T) {
+			t.Parallel()
+
+			if got := matchKey(tt.name, tt.key, tt.mode); got != tt.want {
+				t.Fatalf("match=%v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaletteWithBounds(t *testing.T) {
+	t.Parallel()
+
+	// Palette rule colors are fixed: only hashed names follow the bounds.
+	var names []string
+	for i := 0; i < 60; i++ {
+		name := "synthetic_" + string(rune('a'+i%26)) + string(rune('0'+i/26))
+		if i%3 == 0 {
+			name = "sakhal_" + name
+		}
+		if !HasPaletteRule(name) {
+			names = append(names, name)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		bounds ColorBounds
+		widen  bool // some channel must leave the default 40..220
+	}{
+		{name: "narrow", bounds: ColorBounds{Min: 100, Max: 150}},
+		{name: "wide", bounds: ColorBounds{Min: 0, Max: 255}, widen: true},
+		{name: "tiny", bounds: ColorBounds{Min: 7, Max: 8}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			widened := false
+			for _, name := range names {
+				normal, key, _ := PaletteWithBounds(name, PaletteClamp, tt.bounds)
+				for _, c := range []tv4p.Color{normal, key} {
+					for _, v := range []byte{c.R, c.G, c.B} {
+						if v < tt.bounds.Min || v > tt.bounds.Max {
+							t.Fatalf("%s: color %+v outside %d..%d", name, c, tt.bounds.Min, tt.bounds.Max)
+						}
+						widened = widened || v < DefaultColorBounds.Min || v > DefaultColorBounds.Max
+					}
+				}
+			}
+			if tt.widen && !widened {
+				t.Fatalf("no channel left the default bounds")
+			}
+		})
+	}
+
+	for _, name := range names {
+		a, ak, _ := PaletteWith(name, PaletteClamp)
+		b, bk, _ := PaletteWithBounds(name, PaletteClamp, ColorBounds{})
+		if a != b || ak != bk {
+			t.Fatalf("%s: zero bounds differ from default", name)
+		}
+	}
+
+	if err := (ColorBounds{Min: 200, Max: 200}).Validate(); err == nil {
+		t.Fatalf("expected error for min == max")
+	}
+}
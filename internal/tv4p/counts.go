@@ -0,0 +1,100 @@
+package tv4p
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CountMismatchError reports a list whose header count disagrees with the entries in its payload.
+type CountMismatchError struct {
+	Tag    byte   // list tag (0x89 crossroad defs, 0x8A crossroad links)
+	Offset int    // offset of the list header
+	Header uint32 // count stored in the list header
+	Actual int    // entries found in the list payload
+}
+
+// Error implements error.
+func (e *CountMismatchError) Error() string {
+	return fmt.Sprintf("list 0x%02X at 0x%X: count mismatch: header=%d entries=%d", e.Tag, e.Offset, e.Header, e.Actual)
+}
+
+// countMismatch returns the mismatch of a found list, or nil when the count is consistent
+// (or the payload could not be walked entry by entry).
+func (l taggedList) countMismatch() *CountMismatchError {
+	if !l.Found || l.Actual < 0 || uint64(l.Actual) == uint64(l.Count) {
+		return nil
+	}
+
+	return &CountMismatchError{Tag: l.Tag, Offset: l.Start, Header: l.Count, Actual: l.Actual}
+}
+
+// checkListCounts returns the joined count mismatches of the given lists.
+func checkListCounts(lists ...taggedList) error {
+	var errs []error
+	for _, l := range lists {
+		if m := l.countMismatch(); m != nil {
+			errs = append(errs, m)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// countEntries counts the entries (06 00 0D <u32 bodyLen> ...) in a list payload.
+// It returns -1 when the payload is not a clean sequence of entries.
+func countEntries(data []byte, pos int, listLen int) int {
+	end := pos + listLen
+	if pos < 0 || end > len(data) {
+		return -1
+	}
+
+	n := 0
+	for pos < end {
+		if pos+7 > end || data[pos] != 0x06 || data[pos+1] != 0x00 || data[pos+2] != 0x0D {
+			return -1
+		}
+		bodyEnd := pos + 7 + int(readU32(data[pos+3:]))
+		if bodyEnd > end {
+			return -1
+		}
+		n++
+		pos = bodyEnd
+	}
+
+	return n
+}
+
+// RepairListCounts rewrites the header counts of the crossroad lists (0x89, 0x8A)
+// to match the entries in their payloads and returns the repaired copy of data with
+// the fixed mismatches. Sizes do not change, so no offsets need adjusting.
+func RepairListCounts(data []byte, loc LocateOptions) ([]byte, []CountMismatchError, error) {
+	block, err := ParseRoadTypesWith(data, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterRoadTypes := block.Start + 7 + block.ListLen
+	crDefs, ok := findTaggedListAfter(data, afterRoadTypes, 0x89, validateCrossroadDefs)
+	if !ok {
+		return data, nil, nil
+	}
+	crLinks, _ := findTaggedListAfter(data, crDefs.Start+crDefs.FieldLen, 0x8A, validateCrossroadLinks)
+
+	out := data
+	var fixed []CountMismatchError
+	for _, l := range []taggedList{crDefs, crLinks} {
+		m := l.countMismatch()
+		if m == nil {
+			continue
+		}
+		if len(fixed) == 0 {
+			out = append([]byte(nil), data...)
+		}
+		if err := writeU32FromInt(out[l.Start+7:], m.Actual); err != nil {
+			return nil, nil, err
+		}
+		fixed = append(fixed, *m)
+	}
+
+	return out, fixed, nil
+}
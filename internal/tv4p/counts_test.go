@@ -0,0 +1,79 @@
+package tv4p
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListCountMismatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		tag   byte
+		delta int
+	}{
+		{name: "defs_header_too_high", tag: 0x89, delta: 1},
+		{name: "defs_header_too_low", tag: 0x89, delta: -1},
+		{name: "links_header_too_high", tag: 0x8A, delta: 2},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := buildTestFile(t, testRoadConfig(), fixtureOptions{links: true})
+			l, ok := findTaggedListAfter(data, 0, tt.tag, nil)
+			if !ok {
+				t.Fatalf("list 0x%02X not found", tt.tag)
+			}
+			if err := writeU32FromInt(data[l.Start+7:], int(l.Count)+tt.delta); err != nil {
+				t.Fatalf("corrupt count: %v", err)
+			}
+
+			_, err := ParseRoadToolConfig(data)
+			var mismatch *CountMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("parse err=%v want CountMismatchError", err)
+			}
+			if mismatch.Tag != tt.tag || mismatch.Actual != int(l.Count) {
+				t.Fatalf("mismatch=%+v want tag 0x%02X actual %d", mismatch, tt.tag, l.Count)
+			}
+			if _, err := PatchRoadTool(data, testRoadConfig(), ScopeCrossroad); !errors.As(err, &mismatch) {
+				t.Fatalf("patch err=%v want CountMismatchError", err)
+			}
+
+			repaired, fixed, err := RepairListCounts(data, LocateOptions{})
+			if err != nil {
+				t.Fatalf("repair: %v", err)
+			}
+			if len(fixed) != 1 || fixed[0].Tag != tt.tag {
+				t.Fatalf("fixed=%+v want one 0x%02X fix", fixed, tt.tag)
+			}
+			if len(repaired) != len(data) {
+				t.Fatalf("repair changed size: %d -> %d", len(data), len(repaired))
+			}
+			cfg, err := ParseRoadToolConfig(repaired)
+			if err != nil {
+				t.Fatalf("parse repaired: %v", err)
+			}
+			if len(cfg.CrossroadTypes) != 2 {
+				t.Fatalf("crossroads=%d want 2", len(cfg.CrossroadTypes))
+			}
+		})
+	}
+}
+
+func TestRepairListCountsConsistent(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	out, fixed, err := RepairListCounts(data, LocateOptions{})
+	if err != nil {
+		t.Fatalf("repair: %v", err)
+	}
+	if len(fixed) != 0 || &out[0] != &data[0] {
+		t.Fatalf("consistent file changed: fixed=%+v", fixed)
+	}
+}
@@ -2,13 +2,77 @@ package tv4p
 
 import (
 	"encoding/hex"
+	"fmt"
+	"slices"
 	"strings"
 )
 
+// CrossroadShapeScore ranks a crossroad by its shape (explicit Shape, else name prefix) for
+// default selection: the preferred shape scores 2, the other one 1 and unknown shapes 0.
+// An empty prefer means ShapeT.
+func CrossroadShapeScore(cr CrossroadType, prefer CrossroadShape) int {
+	isT := strings.HasPrefix(cr.Name, "kr_t_")
+	isX := strings.HasPrefix(cr.Name, "kr_x_")
+	if cr.Shape != "" {
+		isT, isX = cr.Shape == ShapeT, cr.Shape == ShapeX
+	}
+	if prefer == ShapeX {
+		isT, isX = isX, isT
+	}
+
+	switch {
+	case isT:
+		return 2
+	case isX:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Crossroad shape enum in def 0x7F and link 0x90 (observed).
+const (
+	shapeEnumT uint32 = 2
+	shapeEnumX uint32 = 3
+)
+
+// shapeFromEnum decodes a shape enum; unknown values give "".
+func shapeFromEnum(v uint32) CrossroadShape {
+	switch v {
+	case shapeEnumT:
+		return ShapeT
+	case shapeEnumX:
+		return ShapeX
+	default:
+		return ""
+	}
+}
+
+// crossroadShapeEnum returns the shape enum to write: the explicit Shape, else
+// X for kr_x_ names and T otherwise.
+func crossroadShapeEnum(cr CrossroadType) uint32 {
+	switch cr.Shape {
+	case ShapeX:
+		return shapeEnumX
+	case ShapeT:
+		return shapeEnumT
+	}
+	if strings.HasPrefix(cr.Name, "kr_x_") {
+		return shapeEnumX
+	}
+
+	return shapeEnumT
+}
+
 // ParseRoadToolConfig extracts both road types (0x88) and crossroad definitions (0x89/0x8A)
 // into a single config structure.
 func ParseRoadToolConfig(data []byte) (RoadConfig, error) {
-	rtBlock, err := ParseRoadTypes(data)
+	return ParseRoadToolConfigWith(data, LocateOptions{})
+}
+
+// ParseRoadToolConfigWith is ParseRoadToolConfig with explicit locate options.
+func ParseRoadToolConfigWith(data []byte, loc LocateOptions) (RoadConfig, error) {
+	rtBlock, err := ParseRoadTypesWith(data, loc)
 	if err != nil {
 		return RoadConfig{}, err
 	}
@@ -24,11 +88,15 @@ func ParseRoadToolConfig(data []byte) (RoadConfig, error) {
 
 	afterDefs := crDefs.Start + crDefs.FieldLen
 	crLinks, _ := findTaggedListAfter(data, afterDefs, 0x8A, validateCrossroadLinks)
+	if err := checkListCounts(crDefs, crLinks); err != nil {
+		return RoadConfig{}, err
+	}
 
+	// Links are matched to defs by model path; case and slash style may differ.
 	linksByModel := map[string]Entry{}
 	if crLinks.Found {
 		for _, e := range crLinks.Entries {
-			if p := entryString(e, 0x91); p != "" {
+			if p := normalizeModelKey(entryString(e, 0x91)); p != "" {
 				linksByModel[p] = e
 			}
 		}
@@ -60,15 +128,9 @@ func ParseRoadToolConfig(data []byte) (RoadConfig, error) {
 		}
 
 		// Fallback: if indices are missing/out of range, derive from name semantics.
-		if conns.A == "" && conns.B == "" && conns.C == "" && conns.D == "" {
-			ab, c, d, shapeOK := parseCrossroadNameTypes(name)
-			if shapeOK {
-				conns.A = ab
-				conns.B = ab
-				conns.C = c
-				if d != "" {
-					conns.D = d
-				}
+		if conns == (CrossroadConnections{}) {
+			if named, ok := ConnectionsFromName(name); ok {
+				conns = named
 			}
 		}
 
@@ -79,10 +141,18 @@ func ParseRoadToolConfig(data []byte) (RoadConfig, error) {
 			ColorCustom: colorCustom,
 			Connections: conns,
 			TV4PDef:     entryToRaw(e),
+			TV4PBlobs:   crossroadBlobs(e),
 		}
 
-		if link, ok := linksByModel[model]; ok {
+		if v, ok := entryU32(e, 0x7F); ok {
+			cr.Shape = shapeFromEnum(v)
+		}
+		if link, ok := linksByModel[normalizeModelKey(model)]; ok {
 			cr.TV4PLink = entryToRaw(link)
+			cr.TV4PSideRefs = crossroadSideRefs(link)
+			if v, ok := entryU32(link, 0x90); ok && cr.Shape == "" {
+				cr.Shape = shapeFromEnum(v)
+			}
 		}
 
 		cfg.CrossroadTypes = append(cfg.CrossroadTypes, cr)
@@ -113,6 +183,57 @@ func ParseRoadToolConfig(data []byte) (RoadConfig, error) {
 	return cfg, nil
 }
 
+// UseRawConnections replaces resolved crossroad connections with the raw road type
+// indices stored in tv4p_def (0x84..0x87), so a patch writes them back unchanged
+// even when names are ambiguous. Name-derived defaults are dropped as well.
+// Crossroads without tv4p_def are left untouched.
+func UseRawConnections(cfg *RoadConfig) error {
+	for i := range cfg.CrossroadTypes {
+		cr := &cfg.CrossroadTypes[i]
+		if cr.TV4PDef == nil {
+			continue
+		}
+
+		idx := [4]int{-1, -1, -1, -1}
+		for side, tag := range []byte{0x84, 0x85, 0x86, 0x87} {
+			v, ok, err := rawFieldU32(cr.TV4PDef, tag)
+			if err != nil {
+				return fmt.Errorf("crossroad %q: %w", cr.Name, err)
+			}
+			if ok && v != 0xFFFFFFFF {
+				idx[side] = int(v)
+			}
+		}
+
+		cr.ConnectionIndices = &CrossroadIndices{A: idx[0], B: idx[1], C: idx[2], D: idx[3]}
+		cr.Connections = CrossroadConnections{}
+		cr.Default = ""
+	}
+
+	return nil
+}
+
+// rawFieldU32 reads a u32 field (type 0x05/0x0D) of a raw entry.
+func rawFieldU32(e *EntryRaw, tag byte) (uint32, bool, error) {
+	for _, f := range e.Fields {
+		if f.Tag != tag || (f.Type != 0x05 && f.Type != 0x0D) {
+			continue
+		}
+
+		b, err := decodeHex(f.Raw)
+		if err != nil {
+			return 0, false, fmt.Errorf("field 0x%02X: %w", tag, err)
+		}
+		if len(b) < 4 {
+			return 0, false, fmt.Errorf("field 0x%02X: short u32 (%d bytes)", tag, len(b))
+		}
+
+		return readU32(b), true, nil
+	}
+
+	return 0, false, nil
+}
+
 // crossroadHasRoadType checks if a crossroad type has a specific road type in its connections.
 // Example: `kr_t_asf1_asf2` has `asf1` in both `A` and `B` connections.
 func crossroadHasRoadType(cr CrossroadType, rtName string) bool {
@@ -138,6 +259,7 @@ type taggedList struct {
 	ListLen  int     // the length of the list
 	FieldLen int     // the length of the list fields
 	Count    uint32  // the count of the list
+	Actual   int     // entries actually present in the payload (-1 if not walkable)
 	Found    bool    // true if the list was found
 	Tag      byte    // the tag of the list
 }
@@ -188,6 +310,7 @@ func findTaggedListAfter(data []byte, from int, tag byte, validate listValidator
 			Start:    i,
 			ListLen:  listLen,
 			Count:    count,
+			Actual:   countEntries(data, entriesStart, entriesLen),
 			FieldLen: fieldLen,
 			Entries:  entries,
 		}, true
@@ -262,6 +385,53 @@ func entryByte(e Entry, tag byte) byte {
 	return 0
 }
 
+// entryList returns the nested entries of a list field.
+func entryList(e Entry, tag byte) []Entry {
+	for _, f := range e.Fields {
+		if f.Tag == tag && f.Type == 0x0C {
+			return f.List
+		}
+	}
+
+	return nil
+}
+
+// crossroadSideLists maps 0x8A side list tags to the A/B/C/D sides.
+var crossroadSideLists = []struct {
+	side string
+	tag  byte
+}{
+	{side: "A", tag: 0x92},
+	{side: "B", tag: 0x93},
+	{side: "C", tag: 0x94},
+	{side: "D", tag: 0x95},
+}
+
+// crossroadSideRefs decodes the 0x1B reference entries of a 0x8A link entry.
+// It mirrors buildCrossroadSideLists.
+func crossroadSideRefs(link Entry) []CrossroadSideRef {
+	var out []CrossroadSideRef
+	for _, sl := range crossroadSideLists {
+		for _, ref := range entryList(link, sl.tag) {
+			if ref.TypeID != 0x1B {
+				continue
+			}
+
+			kind, _ := entryU32(ref, 0x7F)
+			flag, _ := entryU32(ref, 0x6C)
+			out = append(out, CrossroadSideRef{
+				Side: sl.side,
+				Path: entryString(ref, 0x33),
+				Kind: kind,
+				Flag: flag,
+				ID:   ref.ID,
+			})
+		}
+	}
+
+	return out
+}
+
 // entryU32 extracts a 32-bit unsigned integer from an entry.
 func entryU32(e Entry, tag byte) (uint32, bool) {
 	for _, f := range e.Fields {
@@ -290,6 +460,50 @@ func idxToRoadType(types []RoadType, idx uint32) string {
 	return types[idx].Name
 }
 
+// ConnectionsFromName derives A/B/C/D from a conventional crossroad name:
+// kr_t_<ab>_<c> sets A=B=ab and C, kr_x_<ab>_<c>[_<d>] also sets D (D=C when omitted).
+// It reports false for names that do not follow the scheme.
+func ConnectionsFromName(name string) (CrossroadConnections, bool) {
+	ab, c, d, ok := parseCrossroadNameTypes(name)
+	if !ok {
+		return CrossroadConnections{}, false
+	}
+
+	return CrossroadConnections{A: ab, B: ab, C: c, D: d}, true
+}
+
+// FillConnectionsFromName sets the connections of crossroads that have none
+// (all of A/B/C/D empty and no connection_indices) from their names, see ConnectionsFromName.
+// Crossroads with unconventional names are left untouched.
+// It returns the number of crossroads that were filled.
+func FillConnectionsFromName(crossroads []CrossroadType) int {
+	n := 0
+	for i := range crossroads {
+		cr := &crossroads[i]
+		if cr.ConnectionIndices != nil || !connectionsEmpty(cr.Connections) {
+			continue
+		}
+
+		if conns, ok := ConnectionsFromName(cr.Name); ok {
+			cr.Connections = conns
+			n++
+		}
+	}
+
+	return n
+}
+
+// connectionsEmpty reports whether no side of the connections is set (blank names count as empty).
+func connectionsEmpty(c CrossroadConnections) bool {
+	for _, s := range []string{c.A, c.B, c.C, c.D} {
+		if strings.TrimSpace(s) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
 // parseCrossroadNameTypes parses a crossroad name into its components.
 func parseCrossroadNameTypes(name string) (ab string, c string, d string, ok bool) {
 	// T-junction
@@ -325,6 +539,34 @@ func parseCrossroadNameTypes(name string) (ab string, c string, d string, ok boo
 	return "", "", "", false
 }
 
+// crossroadBlobs reads the 0x80..0x82 fields of a crossroad def.
+// It returns nil when all of them are missing or zero, as generated defs write them.
+func crossroadBlobs(e Entry) *CrossroadBlobs {
+	var out CrossroadBlobs
+	found := false
+	for _, f := range e.Fields {
+		var dst *string
+		switch f.Tag {
+		case 0x80:
+			dst = &out.F80
+		case 0x81:
+			dst = &out.F81
+		case 0x82:
+			dst = &out.F82
+		}
+		if dst == nil || f.Type != 0x14 || f.List != nil || !slices.ContainsFunc(f.Raw, func(b byte) bool { return b != 0 }) {
+			continue
+		}
+		*dst = hex.EncodeToString(f.Raw)
+		found = true
+	}
+	if !found {
+		return nil
+	}
+
+	return &out
+}
+
 // entryToRaw converts an entry to an EntryRaw.
 func entryToRaw(e Entry) *EntryRaw {
 	raw := &EntryRaw{
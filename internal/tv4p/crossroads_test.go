@@ -0,0 +1,292 @@
+package tv4p
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseCrossroadSideRefs(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{links: true})
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	tests := []struct {
+		crossroad string
+		path      string
+	}{
+		{crossroad: "kr_t_asf1_city", path: `dz\roads\asf1_12.p3d`},
+		{crossroad: "kr_x_city_city", path: `dz\roads\city_12.p3d`},
+	}
+
+	for i, tt := range tests {
+		cr := cfg.CrossroadTypes[i]
+		if cr.Name != tt.crossroad {
+			t.Fatalf("crossroad %d=%q want %q", i, cr.Name, tt.crossroad)
+		}
+		if cr.TV4PLink == nil {
+			t.Fatalf("%s: link not extracted", cr.Name)
+		}
+		// Generated links only reference the A side.
+		if len(cr.TV4PSideRefs) != 1 {
+			t.Fatalf("%s: side refs=%+v", cr.Name, cr.TV4PSideRefs)
+		}
+		ref := cr.TV4PSideRefs[0]
+		if ref.Side != "A" || ref.Path != tt.path || ref.Kind != 3 || ref.Flag != 1 || ref.ID == 0 {
+			t.Fatalf("%s: ref=%+v want side A path %q kind 3 flag 1", cr.Name, ref, tt.path)
+		}
+	}
+}
+
+func TestParseLinksMatchModelCaseAndSlashes(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	data := buildTestFile(t, cfg, fixtureOptions{links: true})
+
+	// Rewrite the link (0x91) copies of the models only: links follow the defs.
+	for _, cr := range cfg.CrossroadTypes {
+		model := []byte(cr.Model)
+		i := bytes.LastIndex(data, model)
+		if i < 0 || i == bytes.Index(data, model) {
+			t.Fatalf("%s: link model not found", cr.Name)
+		}
+		copy(data[i:], strings.ReplaceAll(strings.ToUpper(cr.Model), `\`, "/"))
+	}
+
+	got, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	for _, cr := range got.CrossroadTypes {
+		if cr.TV4PLink == nil {
+			t.Fatalf("%s: link with case/slash-different model not attached", cr.Name)
+		}
+	}
+}
+
+func TestCrossroadExplicitShape(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{links: true})
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	for _, cr := range cfg.CrossroadTypes {
+		want := ShapeT
+		if strings.HasPrefix(cr.Name, "kr_x_") {
+			want = ShapeX
+		}
+		if cr.Shape != want {
+			t.Fatalf("%s: shape=%q want %q", cr.Name, cr.Shape, want)
+		}
+	}
+
+	// The name says T, the explicit shape says X: the explicit one is written.
+	crossroads := []CrossroadType{{
+		Name:        "kr_t_asf1_city",
+		Model:       `P:\dz\roads\kr_t_asf1_city.p3d`,
+		Connections: CrossroadConnections{A: "asf1", B: "asf1", C: "city"},
+		Shape:       ShapeX,
+	}}
+	out, err := PatchRoadTool(data, RoadConfig{CrossroadTypes: crossroads}, ScopeCrossroad)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	got, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(got.CrossroadTypes) != 1 || got.CrossroadTypes[0].Shape != ShapeX {
+		t.Fatalf("crossroads=%+v want shape x", got.CrossroadTypes)
+	}
+	if v, ok, err := rawFieldU32(got.CrossroadTypes[0].TV4PDef, 0x7F); err != nil || !ok || v != shapeEnumX {
+		t.Fatalf("def shape=%d,%v,%v want %d", v, ok, err, shapeEnumX)
+	}
+	if s := CrossroadShapeScore(crossroads[0], ShapeX); s != 2 {
+		t.Fatalf("score=%d want 2", s)
+	}
+
+	crossroads[0].Shape = "y"
+	if _, err := PatchRoadTool(data, RoadConfig{CrossroadTypes: crossroads}, ScopeCrossroad); err == nil || !strings.Contains(err.Error(), "unknown shape") {
+		t.Fatalf("err=%v want unknown shape", err)
+	}
+}
+
+func TestEntryList(t *testing.T) {
+	t.Parallel()
+
+	e := Entry{Fields: []Field{
+		{Tag: 0x92, Type: 0x0B, Raw: []byte("x")},
+		{Tag: 0x93, Type: 0x0C, List: []Entry{{TypeID: 0x1B}}},
+	}}
+	if got := entryList(e, 0x93); len(got) != 1 || got[0].TypeID != 0x1B {
+		t.Fatalf("entryList(0x93)=%+v", got)
+	}
+	if got := entryList(e, 0x92); got != nil {
+		t.Fatalf("entryList(0x92) on non-list field=%+v", got)
+	}
+}
+
+func TestRawConnectionsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := UseRawConnections(&cfg); err != nil {
+		t.Fatalf("UseRawConnections: %v", err)
+	}
+
+	cr := cfg.CrossroadTypes[0]
+	if cr.ConnectionIndices == nil || *cr.ConnectionIndices != (CrossroadIndices{A: 0, B: 0, C: 1, D: -1}) {
+		t.Fatalf("indices=%+v want {0 0 1 -1}", cr.ConnectionIndices)
+	}
+	if cr.Connections != (CrossroadConnections{}) || cr.Default != "" {
+		t.Fatalf("connections=%+v default=%q want empty", cr.Connections, cr.Default)
+	}
+
+	out, err := PatchRoadToolWithOptions(data, RoadConfig{CrossroadTypes: cfg.CrossroadTypes}, PatchOptions{Scope: ScopeCrossroad, CrossroadOrder: CrossroadOrderKeep})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatalf("raw-index round-trip changed the file")
+	}
+}
+
+func TestPatchConnectionIndices(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	crossroads := []CrossroadType{{
+		Name:              "kr_t_asf1_city",
+		Model:             `P:\dz\roads\kr_t_asf1_city.p3d`,
+		ConnectionIndices: &CrossroadIndices{A: 1, B: 1, C: 0, D: -1},
+	}}
+
+	out, err := PatchRoadTool(data, RoadConfig{CrossroadTypes: crossroads}, ScopeCrossroad)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	got, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := CrossroadConnections{A: "city", B: "city", C: "asf1"}
+	if len(got.CrossroadTypes) != 1 || got.CrossroadTypes[0].Connections != want {
+		t.Fatalf("crossroads=%+v want connections %+v", got.CrossroadTypes, want)
+	}
+
+	crossroads[0].ConnectionIndices.D = 2
+	if _, err := PatchRoadTool(data, RoadConfig{CrossroadTypes: crossroads}, ScopeCrossroad); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Fatalf("err=%v want index out of range", err)
+	}
+}
+
+func TestCrossroadBlobsHybridEdit(t *testing.T) {
+	t.Parallel()
+
+	src := testRoadConfig()
+	blobs := &CrossroadBlobs{F80: "0000803f00000040", F82: "0102030405060708"}
+	src.CrossroadTypes[0].TV4PBlobs = blobs
+	data := buildTestFile(t, src, fixtureOptions{})
+
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := cfg.CrossroadTypes[0].TV4PBlobs; got == nil || *got != *blobs {
+		t.Fatalf("blobs=%+v want %+v", got, blobs)
+	}
+	if got := cfg.CrossroadTypes[1].TV4PBlobs; got != nil {
+		t.Fatalf("zero blobs=%+v want nil", got)
+	}
+
+	// Hybrid edit: drop the raw def to change the color, the blobs must survive.
+	cr := &cfg.CrossroadTypes[0]
+	cr.TV4PDef = nil
+	cr.ColorCustom = true
+	cr.Color = Color{R: 10, G: 20, B: 30, A: 255}
+	out, err := PatchRoadTool(data, cfg, ScopeCrossroad)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	got, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("parse patched: %v", err)
+	}
+	edited := got.CrossroadTypes[0]
+	if edited.Color != cr.Color || edited.TV4PBlobs == nil || *edited.TV4PBlobs != *blobs {
+		t.Fatalf("color=%+v blobs=%+v want %+v and %+v", edited.Color, edited.TV4PBlobs, cr.Color, blobs)
+	}
+
+	cr.TV4PBlobs = &CrossroadBlobs{F81: "00ff"}
+	if _, err := PatchRoadTool(data, cfg, ScopeCrossroad); err == nil || !strings.Contains(err.Error(), "want 8 bytes") {
+		t.Fatalf("err=%v want short blob error", err)
+	}
+}
+
+func TestFillConnectionsFromName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   CrossroadType
+		want CrossroadConnections
+	}{
+		{
+			name: "t",
+			in:   CrossroadType{Name: "kr_t_asf1_city"},
+			want: CrossroadConnections{A: "asf1", B: "asf1", C: "city"},
+		},
+		{
+			name: "x two types",
+			in:   CrossroadType{Name: "kr_x_city_asf2"},
+			want: CrossroadConnections{A: "city", B: "city", C: "asf2", D: "asf2"},
+		},
+		{
+			name: "x three types",
+			in:   CrossroadType{Name: "kr_x_asf1_city_asf3"},
+			want: CrossroadConnections{A: "asf1", B: "asf1", C: "city", D: "asf3"},
+		},
+		{
+			name: "existing connections kept",
+			in:   CrossroadType{Name: "kr_t_asf1_city", Connections: CrossroadConnections{C: "asf2"}},
+			want: CrossroadConnections{C: "asf2"},
+		},
+		{
+			name: "raw indices kept",
+			in:   CrossroadType{Name: "kr_t_asf1_city", ConnectionIndices: &CrossroadIndices{}},
+			want: CrossroadConnections{},
+		},
+		{
+			name: "unconventional name",
+			in:   CrossroadType{Name: "junction_asf1"},
+			want: CrossroadConnections{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			crossroads := []CrossroadType{tt.in}
+			filled := FillConnectionsFromName(crossroads)
+			if got := crossroads[0].Connections; got != tt.want {
+				t.Fatalf("connections=%+v want %+v", got, tt.want)
+			}
+			if wantFilled := tt.want != tt.in.Connections; (filled == 1) != wantFilled {
+				t.Fatalf("filled=%d want %v", filled, wantFilled)
+			}
+		})
+	}
+}
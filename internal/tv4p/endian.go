@@ -0,0 +1,66 @@
+package tv4p
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// minEntrySize is the smallest entry: the 06 00 0D marker and its u32 body length.
+const minEntrySize = 7
+
+// ByteSwapError reports a list header that is implausible as little-endian
+// but valid when its u32 fields are byte-swapped, which points to a file
+// rewritten by a tool with the wrong endianness (or otherwise corrupted).
+type ByteSwapError struct {
+	Tag     byte   // list tag (0x88 road types)
+	Offset  int    // offset of the list header
+	ListLen uint32 // list length as stored (little-endian read)
+	Count   uint32 // entry count as stored (little-endian read)
+}
+
+// Error implements error.
+func (e *ByteSwapError) Error() string {
+	return fmt.Sprintf(
+		"list 0x%02X at 0x%X looks byte-swapped: length=%d count=%d (swapped: length=%d count=%d); "+
+			"tv4p is little-endian, the file is likely corrupted",
+		e.Tag, e.Offset, e.ListLen, e.Count, bits.ReverseBytes32(e.ListLen), bits.ReverseBytes32(e.Count))
+}
+
+// plausibleListHeader reports whether a list header at pos with the given length
+// and count fits in data: the payload ends inside data and can hold count entries.
+func plausibleListHeader(data []byte, pos int, listLen uint32, count uint32) bool {
+	if listLen < 4 || uint64(pos)+7+uint64(listLen) > uint64(len(data)) {
+		return false
+	}
+
+	return uint64(count)*minEntrySize <= uint64(listLen-4)
+}
+
+// findByteSwappedList returns the first tag 00 0C list header whose length and
+// count are implausible as little-endian but plausible once byte-swapped.
+// A non-empty swapped list must also start with an entry marker.
+func findByteSwappedList(data []byte, tag byte) *ByteSwapError {
+	for i := 0; i+11 < len(data); i++ {
+		if data[i] != tag || data[i+1] != 0x00 || data[i+2] != 0x0C {
+			continue
+		}
+
+		listLen := readU32(data[i+3:])
+		count := readU32(data[i+7:])
+		if plausibleListHeader(data, i, listLen, count) {
+			continue
+		}
+
+		swappedLen, swappedCount := bits.ReverseBytes32(listLen), bits.ReverseBytes32(count)
+		if !plausibleListHeader(data, i, swappedLen, swappedCount) {
+			continue
+		}
+		if swappedCount > 0 && (i+14 > len(data) || data[i+11] != 0x06 || data[i+12] != 0x00 || data[i+13] != 0x0D) {
+			continue
+		}
+
+		return &ByteSwapError{Tag: tag, Offset: i, ListLen: listLen, Count: count}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package tv4p
+
+import (
+	"errors"
+	"math/bits"
+	"testing"
+)
+
+func TestParseRoadTypesByteSwapped(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	block, err := ParseRoadTypes(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	swapped := append([]byte(nil), data...)
+	writeU32(swapped[block.Start+3:], bits.ReverseBytes32(uint32(block.ListLen)))
+	writeU32(swapped[block.Start+7:], bits.ReverseBytes32(block.Count))
+
+	_, err = ParseRoadTypes(swapped)
+	var swapErr *ByteSwapError
+	if !errors.As(err, &swapErr) {
+		t.Fatalf("err=%v want ByteSwapError", err)
+	}
+	if swapErr.Offset != block.Start || swapErr.Tag != 0x88 {
+		t.Fatalf("swap=%+v want offset 0x%X", swapErr, block.Start)
+	}
+
+	// A truncated header is not reported as byte-swapped.
+	truncated := append([]byte(nil), data...)
+	writeU32(truncated[block.Start+3:], uint32(len(data)))
+	if _, err := ParseRoadTypes(truncated); err == nil || errors.As(err, &swapErr) {
+		t.Fatalf("truncated: err=%v want plain not found", err)
+	}
+}
@@ -0,0 +1,125 @@
+package tv4p
+
+import (
+	"testing"
+)
+
+// fixtureOptions controls the layout of a crafted tv4p test file.
+type fixtureOptions struct {
+	noLinks      bool // omit meta + 0x8A list
+	noCrossroads bool // omit 0x89, meta and 0x8A lists
+	links        bool // fill 0x8A with synthesized link entries instead of an empty list
+}
+
+// buildTestFile builds a minimal tv4p-like byte stream around a Road Tool region:
+//
+//	header | 0x18/0x0D | 0x3E/0x0D | 0x88 | 0x89 | meta (0x3F/0x0D, 0x19/0x20) | 0x8A | trailer
+//
+// Road types and crossroads are encoded with the regular writers; IDs set in cfg are kept
+// and allocated ones are unique across the lists.
+func buildTestFile(t testing.TB, cfg RoadConfig, opts fixtureOptions) []byte {
+	t.Helper()
+
+	existing := map[uint32]struct{}{}
+	rtEntries, err := buildRoadTypesEntries(cfg, existing, 0)
+	if err != nil {
+		t.Fatalf("build road types: %v", err)
+	}
+	rtField, err := fieldList(0x88, rtEntries)
+	if err != nil {
+		t.Fatalf("build 0x88: %v", err)
+	}
+
+	// Allocate crossroad IDs after the road type ones, as in a real file.
+	existing = collectEntryIDs(rtField)
+	defField, _, err := buildCrossroadFields(cfg, existing, false, PatchOptions{})
+	if err != nil {
+		t.Fatalf("build 0x89: %v", err)
+	}
+
+	out := []byte{0x01, 0x02, 0x03, 0x04}
+	out = append(out, 0x18, 0x00, 0x0D, 0x00, 0x10, 0x00, 0x00)
+	out = append(out, 0x3E, 0x00, 0x0D, 0x00, 0x20, 0x00, 0x00)
+	out = append(out, rtField...)
+	if !opts.noCrossroads {
+		out = append(out, defField...)
+	}
+
+	if !opts.noLinks && !opts.noCrossroads {
+		linkField, err := fieldList(0x8A, nil)
+		if err != nil {
+			t.Fatalf("build 0x8A: %v", err)
+		}
+		if opts.links {
+			// One synthesized link entry per crossroad (A side reference only).
+			alloc := newIDAllocator(cfg, collectEntryIDs(append(rtField, defField...)), 0)
+			var entries [][]byte
+			for _, cr := range cfg.CrossroadTypes {
+				e, err := buildCrossroadLinkEntry(cr, alloc, cfg.Types)
+				if err != nil {
+					t.Fatalf("build link: %v", err)
+				}
+				entries = append(entries, e)
+			}
+			linkField, err = fieldList(0x8A, entries)
+			if err != nil {
+				t.Fatalf("build 0x8A: %v", err)
+			}
+		}
+		out = append(out, 0x3F, 0x00, 0x0D, 0x00, 0x30, 0x00, 0x00)
+		out = append(out, 0x19, 0x00, 0x20, 0x00, 0x00, 0x00)
+		out = append(out, linkField...)
+	}
+
+	out = append(out, 0xFE, 0xFE, 0xFE, 0xFE)
+
+	return out
+}
+
+// testRoadConfig returns a small config with two road types and two crossroads.
+func testRoadConfig() RoadConfig {
+	return RoadConfig{
+		Types: []RoadType{
+			{
+				Name:          "asf1",
+				NormalCustom:  true,
+				NormalColor:   Color{R: 110, G: 125, B: 150, A: 255},
+				StraightParts: []RoadPart{{Name: "asf1_12", Path: `dz\roads\asf1_12.p3d`}},
+				CornerParts:   []RoadPart{{Name: "asf1_7 100", Path: `dz\roads\asf1_7 100.p3d`}},
+			},
+			{
+				Name:           "city",
+				StraightParts:  []RoadPart{{Name: "city_12", Path: `dz\roads\city_12.p3d`}},
+				TerminatorPart: []RoadPart{{Name: "city_6konec", Path: `dz\roads\city_6konec.p3d`}},
+			},
+		},
+		CrossroadTypes: []CrossroadType{
+			{
+				Name:        "kr_t_asf1_city",
+				Model:       `P:\dz\roads\kr_t_asf1_city.p3d`,
+				Connections: CrossroadConnections{A: "asf1", B: "asf1", C: "city"},
+			},
+			{
+				Name:        "kr_x_city_city",
+				Model:       `P:\dz\roads\kr_x_city_city.p3d`,
+				Connections: CrossroadConnections{A: "city", B: "city", C: "city", D: "city"},
+			},
+		},
+	}
+}
+
+// roadTypesField encodes a standalone 0x88 road types list field.
+func roadTypesField(t *testing.T, cfg RoadConfig) []byte {
+	t.Helper()
+
+	entries, err := buildRoadTypesEntries(cfg, map[uint32]struct{}{}, 0)
+	if err != nil {
+		t.Fatalf("build road types: %v", err)
+	}
+	field, err := fieldList(0x88, entries)
+	if err != nil {
+		t.Fatalf("build 0x88: %v", err)
+	}
+
+	return field
+}
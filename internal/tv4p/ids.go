@@ -0,0 +1,251 @@
+package tv4p
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IDInfo describes the entry ID layout detected in a tv4p file.
+// It mirrors what the patcher infers before allocating new IDs.
+type IDInfo struct {
+	RoadTypes     IDSeries `json:"road_types"`     // 0x88 entries (TypeID 0x12)
+	CrossroadDefs IDSeries `json:"crossroad_defs"` // 0x89 entries (TypeID 0x17)
+}
+
+// IDSeries describes a list of entry IDs in file order.
+type IDSeries struct {
+	IDs            []uint32 `json:"ids,omitempty"`   // non-zero IDs in file order
+	Count          int      `json:"count"`           // number of non-zero IDs
+	Min            uint32   `json:"min"`             // smallest ID
+	Max            uint32   `json:"max"`             // largest ID
+	Stride         uint32   `json:"stride"`          // greatest common step between sorted IDs
+	ExpectedStride uint32   `json:"expected_stride"` // stride the patcher allocates with
+	Remainder      uint32   `json:"remainder"`       // first ID mod ExpectedStride (used for new IDs)
+	Aligned        bool     `json:"aligned"`         // all IDs share Remainder mod ExpectedStride
+	Progression    bool     `json:"progression"`     // IDs increase by exactly ExpectedStride in file order
+}
+
+// InspectIDs reports the road type and crossroad def ID layout of a tv4p file.
+func InspectIDs(data []byte) (IDInfo, error) {
+	block, err := ParseRoadTypes(data)
+	if err != nil {
+		return IDInfo{}, err
+	}
+
+	info := IDInfo{}
+	var rtIDs []uint32
+	for _, e := range block.Entries {
+		rtIDs = append(rtIDs, e.ID)
+	}
+	info.RoadTypes = newIDSeries(rtIDs, roadTypeIDStride)
+
+	afterRoadTypes := block.Start + 7 + block.ListLen
+	crDefs, ok := findTaggedListAfter(data, afterRoadTypes, 0x89, validateCrossroadDefs)
+	var defIDs []uint32
+	if ok {
+		for _, e := range crDefs.Entries {
+			defIDs = append(defIDs, e.ID)
+		}
+	}
+	info.CrossroadDefs = newIDSeries(defIDs, crossroadDefIDStride)
+
+	return info, nil
+}
+
+// newIDSeries builds an IDSeries from IDs in file order, skipping zero IDs.
+func newIDSeries(ids []uint32, expected uint32) IDSeries {
+	s := IDSeries{ExpectedStride: expected}
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		s.IDs = append(s.IDs, id)
+	}
+
+	s.Count = len(s.IDs)
+	if s.Count == 0 {
+		return s
+	}
+
+	s.Min = s.IDs[0]
+	s.Max = s.IDs[0]
+	s.Remainder = s.IDs[0] % expected
+	s.Aligned = true
+	s.Progression = true
+	for i, id := range s.IDs {
+		if id < s.Min {
+			s.Min = id
+		}
+		if id > s.Max {
+			s.Max = id
+		}
+		if id%expected != s.Remainder {
+			s.Aligned = false
+		}
+		if i > 0 && (id <= s.IDs[i-1] || id-s.IDs[i-1] != expected) {
+			s.Progression = false
+		}
+	}
+
+	for _, id := range s.IDs {
+		s.Stride = gcdU32(s.Stride, id-s.Min)
+	}
+
+	return s
+}
+
+// gcdU32 returns the greatest common divisor of a and b.
+func gcdU32(a, b uint32) uint32 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+// IDReport lists entry IDs that differ between two tv4p files (see CompareIDs).
+type IDReport struct {
+	Changed   []IDChange `json:"changed,omitempty"`   // present in both files with a different ID
+	Unmatched []IDChange `json:"unmatched,omitempty"` // present in one file only (the other ID is 0)
+	Compared  int        `json:"compared"`            // entries present in both files
+}
+
+// IDChange is one road type, part or crossroad def whose ID differs.
+type IDChange struct {
+	Kind   string `json:"kind"`   // road_type, part or crossroad
+	Name   string `json:"name"`   // road type, road_type/list/part or crossroad name
+	Before uint32 `json:"before"` // ID in the first file (0 if missing)
+	After  uint32 `json:"after"`  // ID in the second file (0 if missing)
+}
+
+// Stable reports whether every compared entry kept its ID and nothing is unmatched.
+func (r IDReport) Stable() bool {
+	return len(r.Changed) == 0 && len(r.Unmatched) == 0
+}
+
+// CompareIDs parses two tv4p files and reports road types, parts and crossroad defs
+// whose entry IDs changed, e.g. to confirm an extract -> patch round-trip kept them.
+//
+// Entries are matched case-insensitively by name: road types by name, parts by road type,
+// list and part name, crossroad defs by name. Matching by name (not path) surfaces
+// IDs lost to path-casing mismatches during ID inheritance.
+func CompareIDs(before, after []byte) (IDReport, error) {
+	a, err := ParseRoadToolConfig(before)
+	if err != nil {
+		return IDReport{}, fmt.Errorf("before: %w", err)
+	}
+	b, err := ParseRoadToolConfig(after)
+	if err != nil {
+		return IDReport{}, fmt.Errorf("after: %w", err)
+	}
+
+	idsA, order := configIDs(a)
+	idsB, orderB := configIDs(b)
+	for _, k := range orderB {
+		if _, ok := idsA[k]; !ok {
+			order = append(order, k)
+		}
+	}
+
+	var r IDReport
+	for _, k := range order {
+		ca, okA := idsA[k]
+		cb, okB := idsB[k]
+		switch {
+		case okA && okB:
+			r.Compared++
+			if ca.id != cb.id {
+				r.Changed = append(r.Changed, IDChange{Kind: ca.kind, Name: ca.name, Before: ca.id, After: cb.id})
+			}
+		case okA:
+			r.Unmatched = append(r.Unmatched, IDChange{Kind: ca.kind, Name: ca.name, Before: ca.id})
+		default:
+			r.Unmatched = append(r.Unmatched, IDChange{Kind: cb.kind, Name: cb.name, After: cb.id})
+		}
+	}
+
+	return r, nil
+}
+
+// MaxEntryID returns the largest entry ID in data (0 if there is none).
+// New IDs from a patch --id-base at or below it interleave with existing ones.
+func MaxEntryID(data []byte) uint32 {
+	var maxID uint32
+	for id := range collectEntryIDs(data) {
+		maxID = max(maxID, id)
+	}
+
+	return maxID
+}
+
+// IDEntry is one road type, part or crossroad def ID (see ListIDs).
+type IDEntry struct {
+	Kind string `json:"kind"` // road_type, part or crossroad
+	Name string `json:"name"` // road type, road_type/list/part or crossroad name
+	ID   uint32 `json:"id"`   // entry ID (0 when not set)
+}
+
+// ListIDs returns the road type, part and crossroad def IDs of a tv4p file in file
+// order, named the way CompareIDs matches them (duplicate names are listed once).
+func ListIDs(data []byte) ([]IDEntry, error) {
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, order := configIDs(cfg)
+	out := make([]IDEntry, 0, len(order))
+	for _, k := range order {
+		n := ids[k]
+		out = append(out, IDEntry{Kind: n.kind, Name: n.name, ID: n.id})
+	}
+
+	return out, nil
+}
+
+// namedID is an entry ID with the kind and name it is matched by.
+type namedID struct {
+	kind string
+	name string
+	id   uint32
+}
+
+// configIDs collects entry IDs keyed by kind and lower-case name, with keys in config order.
+func configIDs(cfg RoadConfig) (map[string]namedID, []string) {
+	ids := map[string]namedID{}
+	var order []string
+	add := func(kind, name string, id uint32) {
+		key := kind + "|" + strings.ToLower(name)
+		if _, ok := ids[key]; ok {
+			return
+		}
+		ids[key] = namedID{kind: kind, name: name, id: id}
+		order = append(order, key)
+	}
+
+	for _, rt := range cfg.Types {
+		add("road_type", rt.Name, rt.ID)
+		lists := []struct {
+			name  string
+			parts []RoadPart
+		}{
+			{"starting", rt.StraightParts},
+			{"corner", rt.CornerParts},
+			{"terminator", rt.TerminatorPart},
+		}
+		for _, l := range lists {
+			for _, p := range l.parts {
+				add("part", rt.Name+"/"+l.name+"/"+p.Name, p.ID)
+			}
+		}
+	}
+	for _, cr := range cfg.CrossroadTypes {
+		var id uint32
+		if cr.TV4PDef != nil {
+			id = cr.TV4PDef.ID
+		}
+		add("crossroad", cr.Name, id)
+	}
+
+	return ids, order
+}
@@ -0,0 +1,110 @@
+package tv4p
+
+import "testing"
+
+func TestInspectIDs(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	cfg.Types[0].ID = 0x0C + 0x48
+	cfg.Types[1].ID = 0x0C + 2*0x48
+	data := buildTestFile(t, cfg, fixtureOptions{})
+
+	info, err := InspectIDs(data)
+	if err != nil {
+		t.Fatalf("InspectIDs: %v", err)
+	}
+
+	rt := info.RoadTypes
+	if rt.Count != 2 || rt.Min != 0x54 || rt.Max != 0x9C {
+		t.Fatalf("road types: got=%+v", rt)
+	}
+	if rt.Remainder != 0x0C || rt.Stride != 0x48 || !rt.Aligned || !rt.Progression {
+		t.Fatalf("road types series: got=%+v", rt)
+	}
+
+	cr := info.CrossroadDefs
+	if cr.Count != 2 || cr.Stride != 0x178 || !cr.Progression {
+		t.Fatalf("crossroad defs: got=%+v", cr)
+	}
+}
+
+func TestNewIDSeriesGap(t *testing.T) {
+	t.Parallel()
+
+	s := newIDSeries([]uint32{0x54, 0, 0x9C + 0x48}, 0x48)
+	if s.Count != 2 {
+		t.Fatalf("count=%d want 2", s.Count)
+	}
+	if !s.Aligned {
+		t.Fatalf("expected aligned series")
+	}
+	if s.Progression {
+		t.Fatalf("expected gap to break progression")
+	}
+	if s.Stride != 0x90 {
+		t.Fatalf("stride=0x%X want 0x90", s.Stride)
+	}
+}
+
+func TestCompareIDs(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+
+	// extract -> patch round-trip keeps every ID.
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	same, err := PatchRoadTool(data, cfg, ScopeAll)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	r, err := CompareIDs(data, same)
+	if err != nil {
+		t.Fatalf("CompareIDs: %v", err)
+	}
+	if !r.Stable() || r.Compared == 0 {
+		t.Fatalf("round-trip report=%+v want stable", r)
+	}
+
+	// A part whose ID is lost gets a new one from the allocator.
+	cfg.Types[0].StraightParts[0].ID = 0
+	cfg.Types[0].StraightParts[0].Path = "other\\path.p3d"
+	changed, err := PatchRoadTool(data, cfg, ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	r, err = CompareIDs(data, changed)
+	if err != nil {
+		t.Fatalf("CompareIDs: %v", err)
+	}
+	if len(r.Changed) != 1 || r.Changed[0].Kind != "part" || r.Changed[0].Before == r.Changed[0].After {
+		t.Fatalf("report=%+v want one changed part", r)
+	}
+}
+
+func TestListIDs(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	cfg.Types[0].ID = 0x54
+	data := buildTestFile(t, cfg, fixtureOptions{})
+
+	ids, err := ListIDs(data)
+	if err != nil {
+		t.Fatalf("ListIDs: %v", err)
+	}
+	if len(ids) == 0 || ids[0] != (IDEntry{Kind: "road_type", Name: "asf1", ID: 0x54}) {
+		t.Fatalf("ids=%+v want asf1 road type first", ids)
+	}
+
+	kinds := map[string]int{}
+	for _, id := range ids {
+		kinds[id.Kind]++
+	}
+	if kinds["road_type"] != 2 || kinds["crossroad"] != 2 || kinds["part"] == 0 {
+		t.Fatalf("kinds=%v want 2 road types, parts, 2 crossroads", kinds)
+	}
+}
@@ -0,0 +1,165 @@
+package tv4p
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// fileHeadLen is how many leading bytes FileInfo keeps for diagnostics.
+const fileHeadLen = 16
+
+// testedVersions lists the header versions confirmed with sample files.
+// The sample files seen so far have no signature and so no version; those never
+// warn. A versioned header stays untested until its version is added here.
+var testedVersions = []uint32{}
+
+// FileInfo is a summary of a tv4p file used for diagnostics.
+//
+// Header semantics are only partly known: Signature is the leading run of
+// ASCII letters/digits (if any) and Version the u32 right after it.
+type FileInfo struct {
+	Head        string `json:"head"`                   // first bytes as hex (up to 16, e.g. "0102ff")
+	Signature   string `json:"signature,omitempty"`    // leading ASCII signature, if present
+	Size        int    `json:"size"`                   // file size in bytes
+	Version     uint32 `json:"version,omitempty"`      // u32 after the signature
+	HasVersion  bool   `json:"has_version"`            // Version was read
+	RegionStart int    `json:"region_start"`           // Road Tool region start (-1 if not found)
+	RegionEnd   int    `json:"region_end"`             // Road Tool region end (-1 if not found)
+	RegionError string `json:"region_error,omitempty"` // why the region was not found
+}
+
+// Inspect reads the leading signature/version bytes and locates the Road Tool region.
+// A missing Road Tool region is reported in FileInfo, not as an error.
+func Inspect(data []byte) (FileInfo, error) {
+	if len(data) == 0 {
+		return FileInfo{}, errors.New("empty file")
+	}
+
+	info := FileInfo{
+		Size:        len(data),
+		Head:        hex.EncodeToString(data[:min(len(data), fileHeadLen)]),
+		RegionStart: -1,
+		RegionEnd:   -1,
+	}
+
+	n := 0
+	for n < len(data) && n < 8 && isSignatureByte(data[n]) {
+		n++
+	}
+	if n >= 3 {
+		info.Signature = string(data[:n])
+		if n+4 <= len(data) {
+			info.Version = readU32(data[n:])
+			info.HasVersion = true
+		}
+	}
+
+	start, end, err := RoadToolRegion(data)
+	if err != nil {
+		info.RegionError = err.Error()
+	} else {
+		info.RegionStart, info.RegionEnd = start, end
+	}
+
+	return info, nil
+}
+
+// VersionWarning returns a non-empty message when the header has a version that is
+// not in testedVersions.
+func (fi FileInfo) VersionWarning() string {
+	if !fi.HasVersion || slices.Contains(testedVersions, fi.Version) {
+		return ""
+	}
+
+	tested := "none"
+	if len(testedVersions) > 0 {
+		tested = fmt.Sprint(testedVersions)
+	}
+
+	return fmt.Sprintf("tv4p header %q version %d is untested (tested versions: %s)", fi.Signature, fi.Version, tested)
+}
+
+// isSignatureByte reports whether b can be part of a leading ASCII signature.
+func isSignatureByte(b byte) bool {
+	return (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9')
+}
+
+// ProbeResult reports which Road Tool lists a tv4p file has (see Probe).
+// Offsets are -1 when the list is not found.
+type ProbeResult struct {
+	Roads          bool   `json:"roads"`           // 0x88 road types list found
+	RoadsOffset    int    `json:"roads_offset"`    // offset of the 0x88 list header
+	RoadTypes      int    `json:"road_types"`      // road types in the list
+	CrossroadDefs  bool   `json:"crossroad_defs"`  // 0x89 crossroad defs list found
+	DefsOffset     int    `json:"defs_offset"`     // offset of the 0x89 list header
+	CrossroadLinks bool   `json:"crossroad_links"` // 0x8A crossroad links list found
+	LinksOffset    int    `json:"links_offset"`    // offset of the 0x8A list header
+	Error          string `json:"error,omitempty"` // why the road types list was not found
+}
+
+// Probe checks whether data has a patchable Road Tool block without decoding it.
+// Crossroad lists are looked up the way ParseRoadToolConfig does; their header
+// counts are not checked.
+func Probe(data []byte, loc LocateOptions) ProbeResult {
+	r := ProbeResult{RoadsOffset: -1, DefsOffset: -1, LinksOffset: -1}
+	block, err := ParseRoadTypesWith(data, loc)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.Roads, r.RoadsOffset, r.RoadTypes = true, block.Start, len(block.Entries)
+
+	after := block.Start + 7 + block.ListLen
+	if defs, ok := findTaggedListAfter(data, after, 0x89, validateCrossroadDefs); ok {
+		r.CrossroadDefs, r.DefsOffset = true, defs.Start
+		after = defs.Start + defs.FieldLen
+	}
+	if links, ok := findTaggedListAfter(data, after, 0x8A, validateCrossroadLinks); ok {
+		r.CrossroadLinks, r.LinksOffset = true, links.Start
+	}
+
+	return r
+}
+
+// TypeHistogram counts entry TypeIDs and field (Tag, Type) pairs across the whole file.
+// Every byte run that parses as an entry counts, including entries nested in list fields;
+// each entry contributes its own fields only, so nested entries are not counted twice.
+// Maps have no order: sort the keys for stable output.
+func TypeHistogram(data []byte) (map[uint16]int, map[[2]byte]int, error) {
+	types := map[uint16]int{}
+	fields := map[[2]byte]int{}
+	for i := 0; ; i++ {
+		idx := bytes.Index(data[i:], entryHeader)
+		if idx < 0 {
+			break
+		}
+		i += idx
+		if i+7 > len(data) {
+			break
+		}
+
+		bodyStart := i + 7
+		bodyEnd := bodyStart + int(readU32(data[i+3:]))
+		if bodyEnd > len(data) || bodyEnd < bodyStart {
+			continue
+		}
+		ent, ok := parseEntry(data[bodyStart:bodyEnd], bodyStart)
+		if !ok {
+			continue
+		}
+
+		types[ent.TypeID]++
+		for _, f := range ent.Fields {
+			fields[[2]byte{f.Tag, f.Type}]++
+		}
+	}
+
+	if len(types) == 0 {
+		return nil, nil, errors.New("no entries found")
+	}
+
+	return types, fields, nil
+}
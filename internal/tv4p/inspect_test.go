@@ -0,0 +1,139 @@
+package tv4p
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	region := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+
+	tests := []struct {
+		name       string
+		data       []byte
+		signature  string
+		version    uint32
+		hasVersion bool
+		region     bool
+	}{
+		{name: "no_signature", data: region, region: true},
+		{name: "signature", data: append([]byte("TV4P\x07\x00\x00\x00"), region...), signature: "TV4P", version: 7, hasVersion: true, region: true},
+		{name: "short_signature", data: []byte("AB"), signature: ""},
+		{name: "signature_only", data: []byte("TV4P"), signature: "TV4P"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			info, err := Inspect(tt.data)
+			if err != nil {
+				t.Fatalf("Inspect: %v", err)
+			}
+			if info.Size != len(tt.data) {
+				t.Fatalf("size=%d want %d", info.Size, len(tt.data))
+			}
+			if info.Signature != tt.signature || info.HasVersion != tt.hasVersion || info.Version != tt.version {
+				t.Fatalf("signature=%q version=%d/%v want %q %d/%v",
+					info.Signature, info.Version, info.HasVersion, tt.signature, tt.version, tt.hasVersion)
+			}
+			if (info.RegionStart >= 0) != tt.region {
+				t.Fatalf("region_start=%d region=%v (%s)", info.RegionStart, tt.region, info.RegionError)
+			}
+			if !tt.region && info.RegionError == "" {
+				t.Fatalf("missing region error")
+			}
+		})
+	}
+
+	info, err := Inspect(region)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if info.Head != "0102030418000d001000003e000d0020" {
+		t.Fatalf("head=%q", info.Head)
+	}
+	if w := info.VersionWarning(); w != "" {
+		t.Fatalf("unexpected warning %q", w)
+	}
+
+	// Versioned headers warn until their version is confirmed.
+	versioned, err := Inspect(append([]byte("TV4P\x07\x00\x00\x00"), region...))
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if w := versioned.VersionWarning(); !strings.Contains(w, "version 7 is untested") {
+		t.Fatalf("warning=%q want version 7 untested", w)
+	}
+
+	if _, err := Inspect(nil); err == nil {
+		t.Fatalf("expected error for empty data")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		data  []byte
+		roads bool
+		defs  bool
+		links bool
+	}{
+		{name: "full", data: buildTestFile(t, testRoadConfig(), fixtureOptions{links: true}), roads: true, defs: true, links: true},
+		{name: "no_links", data: buildTestFile(t, testRoadConfig(), fixtureOptions{noLinks: true}), roads: true, defs: true},
+		{name: "roads_only", data: buildTestFile(t, testRoadConfig(), fixtureOptions{noCrossroads: true}), roads: true},
+		{name: "garbage", data: []byte("not a tv4p file at all")},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := Probe(tt.data, LocateOptions{})
+			if r.Roads != tt.roads || r.CrossroadDefs != tt.defs || r.CrossroadLinks != tt.links {
+				t.Fatalf("probe=%+v want roads=%v defs=%v links=%v", r, tt.roads, tt.defs, tt.links)
+			}
+			if r.Roads != (r.RoadsOffset >= 0) || r.CrossroadDefs != (r.DefsOffset > r.RoadsOffset) ||
+				r.CrossroadLinks != (r.LinksOffset > r.DefsOffset) {
+				t.Fatalf("offsets=%+v do not match the found lists", r)
+			}
+			if r.Roads != (r.Error == "") {
+				t.Fatalf("error=%q with roads=%v", r.Error, r.Roads)
+			}
+			if r.Roads && r.RoadTypes != 2 {
+				t.Fatalf("road types=%d want 2", r.RoadTypes)
+			}
+		})
+	}
+}
+
+func TestTypeHistogram(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	types, fields, err := TypeHistogram(append([]byte("TV4P\x00\x06\x00\x0D\xFF"), data...))
+	if err != nil {
+		t.Fatalf("TypeHistogram: %v", err)
+	}
+
+	wantTypes := map[uint16]int{0x12: 2, 0x13: 2, 0x14: 1, 0x16: 1, 0x17: 2}
+	if !reflect.DeepEqual(types, wantTypes) {
+		t.Fatalf("types=%v want %v", types, wantTypes)
+	}
+	for key, want := range map[[2]byte]int{{0x33, 0x0B}: 8, {0x78, 0x0C}: 2, {0x84, 0x05}: 2} {
+		if fields[key] != want {
+			t.Fatalf("field %02X/%02X=%d want %d", key[0], key[1], fields[key], want)
+		}
+	}
+
+	if _, _, err := TypeHistogram([]byte("no entries")); err == nil {
+		t.Fatalf("expected error without entries")
+	}
+}
@@ -0,0 +1,104 @@
+package tv4p
+
+import "errors"
+
+// findNestedParent looks for one level of nesting around the field [start, end):
+// the closest entry whose body parses and holds a 0x88 list field covering the span,
+// and the closest list field whose entries include that entry.
+// Returns nil when the list is not nested.
+func findNestedParent(data []byte, start, end int) *NestedParent {
+	for p := start - 7; p >= 0; p-- {
+		if data[p] != 0x06 || data[p+1] != 0x00 || data[p+2] != 0x0D {
+			continue
+		}
+
+		bodyEnd := p + 7 + int(readU32(data[p+3:]))
+		if bodyEnd < end || bodyEnd > len(data) {
+			continue
+		}
+
+		ent, ok := parseEntry(data[p+7:bodyEnd], p+7)
+		if !ok || !entryHasListField(ent, 0x88) {
+			continue
+		}
+
+		if listStart, ok := findEnclosingList(data, p, bodyEnd); ok {
+			return &NestedParent{ListStart: listStart, EntryStart: p}
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+// findEnclosingList finds the closest list field whose entries cover [entryStart, entryEnd).
+func findEnclosingList(data []byte, entryStart, entryEnd int) (int, bool) {
+	for q := entryStart - 11; q >= 0; q-- {
+		if data[q+1] != 0x00 || data[q+2] != 0x0C {
+			continue
+		}
+
+		listLen := int(readU32(data[q+3:]))
+		countU32 := readU32(data[q+7:])
+		if listLen < 4 || countU32 > (^uint32(0)>>1) || q+7+listLen < entryEnd || q+7+listLen > len(data) {
+			continue
+		}
+
+		entries, ok := parseEntries(data, q+11, listLen-4, int(countU32), 0)
+		if !ok || len(entries) != int(countU32) {
+			continue
+		}
+		for _, e := range entries {
+			if e.Offset == entryStart+7 {
+				return q, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// entryHasListField reports whether an entry has a list field with the given tag.
+func entryHasListField(e Entry, tag byte) bool {
+	for _, f := range e.Fields {
+		if f.Tag == tag && f.Type == 0x0C {
+			return true
+		}
+	}
+
+	return false
+}
+
+// adjustNestedParent updates the enclosing entry and list lengths in out by the size
+// change of the replacements that fall inside them. Offsets refer to the original data;
+// parent headers precede the replaced ranges, so their positions are the same in out.
+func adjustNestedParent(out, data []byte, parent *NestedParent, repls []replacement) error {
+	entryEnd := parent.EntryStart + 7 + int(readU32(data[parent.EntryStart+3:]))
+	listEnd := parent.ListStart + 7 + int(readU32(data[parent.ListStart+3:]))
+
+	var entryDelta, listDelta int
+	for _, r := range repls {
+		if r.start < parent.EntryStart+7 {
+			return errors.New("replacement overlaps nested parent header")
+		}
+		if (r.start < entryEnd && r.end > entryEnd) || (r.start < listEnd && r.end > listEnd) {
+			return errors.New("replacement crosses nested parent boundary")
+		}
+		d := len(r.blob) - (r.end - r.start)
+		if r.end <= entryEnd {
+			entryDelta += d
+		}
+		if r.end <= listEnd {
+			listDelta += d
+		}
+	}
+
+	cur := int(readU32(out[parent.EntryStart+3:]))
+	if err := writeU32FromInt(out[parent.EntryStart+3:], cur+entryDelta); err != nil {
+		return err
+	}
+
+	cur = int(readU32(out[parent.ListStart+3:]))
+	return writeU32FromInt(out[parent.ListStart+3:], cur+listDelta)
+}
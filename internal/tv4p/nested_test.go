@@ -0,0 +1,107 @@
+package tv4p
+
+import (
+	"testing"
+)
+
+// buildNestedTestFile wraps a 0x88 list inside one entry of a 0x55 list field:
+//
+//	header | 0x18/0x0D | 0x3E/0x0D | 0x55 list [ entry { 0x33 name, 0x88 list, 0x33 name } ] | trailer
+func buildNestedTestFile(t *testing.T, cfg RoadConfig) []byte {
+	t.Helper()
+
+	before, err := fieldString(0x33, "before")
+	if err != nil {
+		t.Fatalf("field: %v", err)
+	}
+	after, err := fieldString(0x33, "after")
+	if err != nil {
+		t.Fatalf("field: %v", err)
+	}
+	entry, err := buildEntry(0x30, 0x10, [][]byte{before, roadTypesField(t, cfg), after})
+	if err != nil {
+		t.Fatalf("entry: %v", err)
+	}
+	parent, err := fieldList(0x55, [][]byte{entry})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+
+	out := []byte{0x01, 0x02, 0x03, 0x04}
+	out = append(out, 0x18, 0x00, 0x0D, 0x00, 0x10, 0x00, 0x00)
+	out = append(out, 0x3E, 0x00, 0x0D, 0x00, 0x20, 0x00, 0x00)
+	out = append(out, parent...)
+	out = append(out, 0xFE, 0xFE, 0xFE, 0xFE)
+
+	return out
+}
+
+func TestParseRoadTypesNested(t *testing.T) {
+	t.Parallel()
+
+	cfg := RoadConfig{Types: testRoadConfig().Types}
+	data := buildNestedTestFile(t, cfg)
+	const parentList = 18
+
+	block, err := ParseRoadTypes(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if block.Parent != nil {
+		t.Fatalf("parent resolved without Nested: %+v", block.Parent)
+	}
+
+	block, err = ParseRoadTypesWith(data, LocateOptions{Nested: true})
+	if err != nil {
+		t.Fatalf("parse nested: %v", err)
+	}
+	if block.Parent == nil {
+		t.Fatalf("parent not resolved")
+	}
+	if block.Parent.ListStart != parentList || block.Parent.EntryStart != parentList+11 {
+		t.Fatalf("parent=%+v want list=%d entry=%d", block.Parent, parentList, parentList+11)
+	}
+
+	// Grow the list: parent entry/list lengths must follow.
+	cfg.Types[1].CornerParts = []RoadPart{{Name: "city_7 100", Path: `dz\roads\city_7 100.p3d`}}
+	out, err := PatchRoadToolWithOptions(data, cfg, PatchOptions{Scope: ScopeRoads, Locate: LocateOptions{Nested: true}})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+
+	listLen := int(readU32(out[parentList+3:]))
+	entries, ok := parseEntries(out, parentList+11, listLen-4, 1, 0)
+	if !ok || len(entries) != 1 {
+		t.Fatalf("parent list does not parse after patch")
+	}
+	var names []string
+	for _, f := range entries[0].Fields {
+		if f.Tag == 0x33 {
+			names = append(names, string(f.Raw))
+		}
+	}
+	if len(names) != 2 || names[1] != "after" {
+		t.Fatalf("parent entry fields=%v", names)
+	}
+
+	got, err := ParseRoadTypesWith(out, LocateOptions{Nested: true})
+	if err != nil {
+		t.Fatalf("re-parse: %v", err)
+	}
+	if len(got.Types[1].CornerParts) != 1 {
+		t.Fatalf("corner parts=%+v", got.Types[1].CornerParts)
+	}
+}
+
+func TestFindNestedParentTopLevel(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	block, err := ParseRoadTypesWith(data, LocateOptions{Nested: true})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if block.Parent != nil {
+		t.Fatalf("top-level list reported as nested: %+v", block.Parent)
+	}
+}
@@ -0,0 +1,166 @@
+package tv4p
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PatchOptions controls PatchRoadToolWithOptions.
+// The zero value patches like PatchRoadTool(data, cfg, ScopeAll).
+type PatchOptions struct {
+	// Scope selects the lists to rewrite. Empty means ScopeAll.
+	Scope Scope
+
+	// Locate tunes how the Road Tool block is found.
+	Locate LocateOptions
+
+	// Append merges the config road types into the ones in the file (MergeRoadTypes)
+	// instead of replacing them. Config crossroads are then ignored and the file's
+	// crossroads are kept. Callers that transform the merged config (e.g. deduplicate
+	// parts) merge first with MergeRoadTypes and leave Append off.
+	Append bool
+
+	// MergeColor picks the color when Append meets a road type that has a custom color
+	// both in the file and in the config. Nil keeps the config color.
+	MergeColor ColorMergeFunc
+
+	// UpdateOnly refuses to add road types: every config road type must already be in
+	// the file (matched by name, case-insensitive). Without Append, the matched road types
+	// replace the file's ones in place and the others are kept (UpdateRoadTypes).
+	UpdateOnly bool
+
+	// CrossroadOrder controls the 0x89 def order. Empty means CrossroadOrderAuto.
+	CrossroadOrder CrossroadOrder
+
+	// PreferShape is the crossroad shape that wins when CrossroadOrderAuto places
+	// defaults at their road type index. Empty means ShapeT.
+	PreferShape CrossroadShape
+
+	// RoadTypeIDStride and CrossroadDefIDStride are the steps between newly allocated
+	// road type and crossroad def IDs. Zero means the observed 0x48 and 0x178.
+	RoadTypeIDStride     uint32
+	CrossroadDefIDStride uint32
+
+	// IDBase makes newly allocated IDs start at IDBase instead of after the largest
+	// used ID (used IDs are still skipped, road type IDs keep their stride). Zero = auto.
+	IDBase uint32
+
+	// SynthLinks rewrites the 0x8A list even when the config has no tv4p_link data,
+	// with one link entry per crossroad (raw entries verbatim, others synthesized).
+	// Off by default: 0x8A holds placed crossroad instances, synthesized entries
+	// do not change TB's variant selection.
+	SynthLinks bool
+}
+
+// withDefaults validates the options and fills in the defaults of empty fields.
+func (o PatchOptions) withDefaults() (PatchOptions, error) {
+	switch o.Scope {
+	case "":
+		o.Scope = ScopeAll
+	case ScopeAll, ScopeRoads, ScopeCrossroad:
+	default:
+		return o, fmt.Errorf("unknown scope %q", o.Scope)
+	}
+
+	switch o.PreferShape {
+	case "":
+		o.PreferShape = ShapeT
+	case ShapeT, ShapeX:
+	default:
+		return o, fmt.Errorf("unknown crossroad shape %q", o.PreferShape)
+	}
+
+	switch o.CrossroadOrder {
+	case "":
+		o.CrossroadOrder = CrossroadOrderAuto
+	case CrossroadOrderAuto, CrossroadOrderKeep, CrossroadOrderMatchRoads:
+	default:
+		return o, fmt.Errorf("unknown crossroad order %q", o.CrossroadOrder)
+	}
+
+	if o.RoadTypeIDStride == 0 {
+		o.RoadTypeIDStride = roadTypeIDStride
+	}
+	if o.CrossroadDefIDStride == 0 {
+		o.CrossroadDefIDStride = crossroadDefIDStride
+	}
+
+	return o, nil
+}
+
+// ColorMergeFunc merges an existing and an incoming custom color.
+type ColorMergeFunc func(existing, incoming Color) Color
+
+// MergeRoadTypes appends incoming road types to existing ones, merging parts of road
+// types with the same name. Only custom colors count: when just one side is custom it
+// wins, when both are, mergeColor decides (nil keeps the incoming color).
+// Incoming non-zero IDs and types override the existing ones.
+func MergeRoadTypes(existing []RoadType, incoming []RoadType, mergeColor ColorMergeFunc) []RoadType {
+	byName := map[string]int{}
+	for i := range existing {
+		byName[existing[i].Name] = i
+	}
+
+	for _, rt := range incoming {
+		i, ok := byName[rt.Name]
+		if !ok {
+			existing = append(existing, rt)
+			continue
+		}
+
+		ex := &existing[i]
+		ex.StraightParts = append(ex.StraightParts, rt.StraightParts...)
+		ex.CornerParts = append(ex.CornerParts, rt.CornerParts...)
+		ex.TerminatorPart = append(ex.TerminatorPart, rt.TerminatorPart...)
+		ex.KeyCustom, ex.KeyColor = mergeCustomColor(mergeColor, ex.KeyCustom, ex.KeyColor, rt.KeyCustom, rt.KeyColor)
+		ex.NormalCustom, ex.NormalColor = mergeCustomColor(mergeColor, ex.NormalCustom, ex.NormalColor, rt.NormalCustom, rt.NormalColor)
+		if rt.Type != 0 {
+			ex.Type = rt.Type
+		}
+		if rt.ID != 0 {
+			ex.ID = rt.ID
+		}
+	}
+
+	return existing
+}
+
+// UpdateRoadTypes returns existing with each road type replaced by the incoming one of
+// the same name (case-insensitive), keeping the file order and the road types that
+// incoming does not name. An incoming name missing from existing is an error.
+func UpdateRoadTypes(existing []RoadType, incoming []RoadType) ([]RoadType, error) {
+	byName := map[string]int{}
+	for i, rt := range existing {
+		byName[strings.ToLower(strings.TrimSpace(rt.Name))] = i
+	}
+
+	out := append([]RoadType(nil), existing...)
+	var unknown []string
+	for _, rt := range incoming {
+		i, ok := byName[strings.ToLower(strings.TrimSpace(rt.Name))]
+		if !ok {
+			unknown = append(unknown, rt.Name)
+			continue
+		}
+		out[i] = rt
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("update-only: road type(s) not in the file: %s", strings.Join(unknown, ", "))
+	}
+
+	return out, nil
+}
+
+// mergeCustomColor merges an existing and an incoming color (see MergeRoadTypes).
+func mergeCustomColor(merge ColorMergeFunc, exCustom bool, ex Color, inCustom bool, in Color) (bool, Color) {
+	switch {
+	case !inCustom:
+		return exCustom, ex
+	case !exCustom:
+		return true, in
+	case merge == nil:
+		return true, in
+	default:
+		return true, merge(ex, in)
+	}
+}
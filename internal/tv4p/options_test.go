@@ -0,0 +1,223 @@
+package tv4p
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cespare/xxhash"
+)
+
+func TestPatchRoadToolWithOptionsDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	cfg.Types = append(cfg.Types, RoadType{
+		Name:          "asf2",
+		StraightParts: []RoadPart{{Name: "asf2_12", Path: `dz\roads\asf2_12.p3d`}},
+	})
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+
+	// Digests of the PatchRoadTool output for this fixture from before
+	// PatchRoadTool became a wrapper; defaults must keep producing them.
+	const (
+		wantAll       = "1049:e6a21a8fb2a578f9"
+		wantRoads     = "1049:a04610b52dbc5f81"
+		wantCrossroad = "870:df15c7cb7fb3f73a"
+	)
+
+	tests := []struct {
+		name string
+		want string
+		opts PatchOptions
+	}{
+		{name: "zero", want: wantAll, opts: PatchOptions{}},
+		{name: "all", want: wantAll, opts: PatchOptions{Scope: ScopeAll}},
+		{name: "roads", want: wantRoads, opts: PatchOptions{Scope: ScopeRoads}},
+		{name: "crossroads", want: wantCrossroad, opts: PatchOptions{Scope: ScopeCrossroad}},
+		{name: "explicit", want: wantAll, opts: PatchOptions{
+			Scope:                ScopeAll,
+			CrossroadOrder:       CrossroadOrderAuto,
+			PreferShape:          ShapeT,
+			RoadTypeIDStride:     roadTypeIDStride,
+			CrossroadDefIDStride: crossroadDefIDStride,
+		}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := PatchRoadToolWithOptions(data, cfg, tt.opts)
+			if err != nil {
+				t.Fatalf("PatchRoadToolWithOptions: %v", err)
+			}
+			if d := fmt.Sprintf("%d:%016x", len(got), xxhash.Sum64(got)); d != tt.want {
+				t.Fatalf("output=%s want %s", d, tt.want)
+			}
+		})
+	}
+
+	if _, err := PatchRoadToolWithOptions(data, cfg, PatchOptions{Scope: "bogus"}); err == nil {
+		t.Fatalf("unknown scope: want error")
+	}
+}
+
+func TestPatchRoadToolWithOptionsAppend(t *testing.T) {
+	t.Parallel()
+
+	base := testRoadConfig()
+	base.Types[0].NormalCustom = true
+	base.Types[0].NormalColor = Color{R: 100, A: 255}
+	data := buildTestFile(t, base, fixtureOptions{})
+
+	incoming := []RoadType{
+		{
+			Name:          "asf1",
+			NormalCustom:  true,
+			NormalColor:   Color{R: 200, A: 255},
+			StraightParts: []RoadPart{{Name: "asf1_25", Path: `dz\roads\asf1_25.p3d`}},
+		},
+		{Name: "asf2", StraightParts: []RoadPart{{Name: "asf2_12", Path: `dz\roads\asf2_12.p3d`}}},
+	}
+	keep := func(existing, _ Color) Color { return existing }
+
+	block, err := ParseRoadTypes(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	merged := MergeRoadTypes(append([]RoadType(nil), block.Types...), incoming, keep)
+	want, err := PatchRoadTool(data, RoadConfig{Types: merged}, ScopeRoads)
+	if err != nil {
+		t.Fatalf("manual merge patch: %v", err)
+	}
+
+	got, err := PatchRoadToolWithOptions(data, RoadConfig{Types: incoming}, PatchOptions{
+		Scope:      ScopeRoads,
+		Append:     true,
+		MergeColor: keep,
+	})
+	if err != nil {
+		t.Fatalf("append patch: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("append output differs from MergeRoadTypes + PatchRoadTool")
+	}
+
+	out, err := ParseRoadTypes(got)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(out.Types) != 3 {
+		t.Fatalf("types=%d want 3", len(out.Types))
+	}
+	if c := out.Types[0].NormalColor; c != (Color{R: 100, A: 255}) {
+		t.Fatalf("asf1 color=%+v want existing kept by MergeColor", c)
+	}
+	if n := len(out.Types[0].StraightParts); n != len(block.Types[0].StraightParts)+1 {
+		t.Fatalf("asf1 straight parts=%d want %d", n, len(block.Types[0].StraightParts)+1)
+	}
+}
+
+func TestPatchRoadToolWithOptionsRoadTypeStride(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	cfg := RoadConfig{Types: testRoadConfig().Types}
+	for i := range cfg.Types {
+		cfg.Types[i].ID = 0
+	}
+	cfg.Types = append(cfg.Types, RoadType{
+		Name:          "asf2",
+		StraightParts: []RoadPart{{Name: "asf2_12", Path: `dz\roads\asf2_12.p3d`}},
+	})
+
+	const stride = 0x100
+	out, err := PatchRoadToolWithOptions(data, cfg, PatchOptions{Scope: ScopeRoads, RoadTypeIDStride: stride})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	block, err := ParseRoadTypes(out)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	for i := 1; i < len(block.Types); i++ {
+		if d := block.Types[i].ID - block.Types[i-1].ID; d != stride {
+			t.Fatalf("type %d id step=0x%X want 0x%X", i, d, stride)
+		}
+	}
+}
+
+func TestPatchRoadToolWithOptionsSynthLinks(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	data := buildTestFile(t, cfg, fixtureOptions{})
+
+	countLinks := func(t *testing.T, b []byte) int {
+		t.Helper()
+		block, err := ParseRoadTypes(b)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		links, ok := findTaggedListAfter(b, block.Start+7+block.ListLen, 0x8A, validateCrossroadLinks)
+		if !ok {
+			t.Fatalf("0x8A list not found")
+		}
+		return len(links.Entries)
+	}
+
+	plain, err := PatchRoadToolWithOptions(data, cfg, PatchOptions{Scope: ScopeCrossroad})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if n := countLinks(t, plain); n != 0 {
+		t.Fatalf("links without SynthLinks=%d want 0", n)
+	}
+
+	synth, err := PatchRoadToolWithOptions(data, cfg, PatchOptions{Scope: ScopeCrossroad, SynthLinks: true})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if n := countLinks(t, synth); n != len(cfg.CrossroadTypes) {
+		t.Fatalf("links with SynthLinks=%d want %d", n, len(cfg.CrossroadTypes))
+	}
+	if _, err := ParseRoadToolConfig(synth); err != nil {
+		t.Fatalf("parse synthesized links: %v", err)
+	}
+}
+
+func TestPatchRoadToolWithOptionsUpdateOnly(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	city := testRoadConfig().Types[1]
+	city.Name = "CITY"
+	city.NormalCustom = true
+	city.NormalColor = Color{G: 200, A: 255}
+
+	out, err := PatchRoadToolWithOptions(data, RoadConfig{Types: []RoadType{city}}, PatchOptions{Scope: ScopeRoads, UpdateOnly: true})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	block, err := ParseRoadTypes(out)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(block.Types) != 2 || block.Types[0].Name != "asf1" {
+		t.Fatalf("types=%+v want asf1 kept and city updated in place", block.Types)
+	}
+	if c := block.Types[1].NormalColor; c != city.NormalColor {
+		t.Fatalf("city color=%+v want %+v", c, city.NormalColor)
+	}
+
+	stray := []RoadType{city, {Name: "stray", StraightParts: []RoadPart{{Name: "stray_12", Path: `dz\roads\stray_12.p3d`}}}}
+	for _, appendMode := range []bool{false, true} {
+		_, err := PatchRoadToolWithOptions(data, RoadConfig{Types: stray}, PatchOptions{Scope: ScopeRoads, UpdateOnly: true, Append: appendMode})
+		if err == nil || !strings.Contains(err.Error(), "stray") {
+			t.Fatalf("append=%v err=%v want unknown road type rejected", appendMode, err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package tv4p
+
+import "strings"
+
+// NormalizeSlashes rewrites forward slashes in part paths, crossroad models and
+// crossroad side ref paths to backslashes, the style TB itself writes.
+// Raw entries (tv4p_raw, tv4p_def, tv4p_link) are written verbatim and left untouched.
+// It returns the number of paths that were changed.
+func NormalizeSlashes(cfg *RoadConfig) int {
+	n := 0
+	fix := func(s *string) {
+		if strings.Contains(*s, "/") {
+			*s = strings.ReplaceAll(*s, "/", `\`)
+			n++
+		}
+	}
+
+	for i := range cfg.Types {
+		rt := &cfg.Types[i]
+		for _, parts := range [][]RoadPart{rt.StraightParts, rt.CornerParts, rt.TerminatorPart} {
+			for j := range parts {
+				fix(&parts[j].Path)
+			}
+		}
+	}
+
+	for i := range cfg.CrossroadTypes {
+		cr := &cfg.CrossroadTypes[i]
+		fix(&cr.Model)
+		for j := range cr.TV4PSideRefs {
+			fix(&cr.TV4PSideRefs[j].Path)
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,38 @@
+package tv4p
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNormalizeSlashesPatch(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+
+	cfg := testRoadConfig()
+	cfg.Types[0].StraightParts[0].Path = "dz/roads/asf1_12.p3d"
+	cfg.Types[1].TerminatorPart[0].Path = `dz\roads/city_6konec.p3d`
+	cfg.CrossroadTypes[0].Model = "P:/dz/roads/kr_t_asf1_city.p3d"
+
+	if n := NormalizeSlashes(&cfg); n != 3 {
+		t.Fatalf("normalized=%d want 3", n)
+	}
+	if n := NormalizeSlashes(&cfg); n != 0 {
+		t.Fatalf("second pass normalized=%d want 0", n)
+	}
+
+	out, err := PatchRoadTool(data, cfg, ScopeAll)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+
+	for _, want := range []string{`dz\roads\asf1_12.p3d`, `dz\roads\city_6konec.p3d`, `P:\dz\roads\kr_t_asf1_city.p3d`} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Fatalf("patched file misses %q", want)
+		}
+	}
+	if bytes.Contains(out, []byte("roads/")) {
+		t.Fatalf("patched file still contains forward slashes")
+	}
+}
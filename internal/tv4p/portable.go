@@ -1,5 +1,15 @@
 package tv4p
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cespare/xxhash"
+)
+
 // PortableConfig is a "clean export" format similar to generator output:
 // - no internal IDs/types
 // - no tv4p raw fields
@@ -7,6 +17,8 @@ package tv4p
 type PortableConfig struct {
 	Types          []PortableRoadType      `json:"road_types"`                // road types
 	CrossroadTypes []PortableCrossroadType `json:"crossroad_types,omitempty"` // crossroad types
+
+	Checksum string `json:"checksum,omitempty"` // PortableChecksum of the rest, see SetChecksum
 }
 
 // PortableRoadType is a road type in the portable config.
@@ -19,12 +31,16 @@ type PortableRoadType struct {
 	NormalColor    Color              `json:"normal_parts_color"`  // normal parts color
 	KeyCustom      bool               `json:"key_parts_custom"`    // key parts uses custom color
 	NormalCustom   bool               `json:"normal_parts_custom"` // normal parts uses custom color
+
+	World string `json:"world,omitempty"` // detected world, see RoadType.World
 }
 
 // PortableRoadPart is a road part in the portable config.
 type PortableRoadPart struct {
 	Name string `json:"name"`        // part name (e.g. asf2_7 100)
 	Path string `json:"object_file"` // Object File path from UI (p3d)
+
+	NeedsMLOD bool `json:"needs_mlod,omitempty"` // model found as ODOL, see RoadPart.NeedsMLOD
 }
 
 // PortableCrossroadType is a crossroad type in the portable config.
@@ -35,6 +51,7 @@ type PortableCrossroadType struct {
 	Default     string               `json:"default,omitempty"`      // default crossroad type for a road type name
 	Color       Color                `json:"color"`                  // color (e.g. 0x000000FF)
 	ColorCustom bool                 `json:"color_custom,omitempty"` // if false, TB uses standard color sentinel
+	Shape       CrossroadShape       `json:"shape,omitempty"`        // t or x, see CrossroadType.Shape
 }
 
 // ToPortableConfig converts a RoadConfig to a PortableConfig.
@@ -48,16 +65,17 @@ func ToPortableConfig(cfg RoadConfig) PortableConfig {
 			NormalColor:  rt.NormalColor,
 			KeyCustom:    rt.KeyCustom,
 			NormalCustom: rt.NormalCustom,
+			World:        rt.World,
 		}
 
 		for _, p := range rt.StraightParts {
-			prt.StraightParts = append(prt.StraightParts, PortableRoadPart{Name: p.Name, Path: p.Path})
+			prt.StraightParts = append(prt.StraightParts, PortableRoadPart{Name: p.Name, Path: p.Path, NeedsMLOD: p.NeedsMLOD})
 		}
 		for _, p := range rt.CornerParts {
-			prt.CornerParts = append(prt.CornerParts, PortableRoadPart{Name: p.Name, Path: p.Path})
+			prt.CornerParts = append(prt.CornerParts, PortableRoadPart{Name: p.Name, Path: p.Path, NeedsMLOD: p.NeedsMLOD})
 		}
 		for _, p := range rt.TerminatorPart {
-			prt.TerminatorPart = append(prt.TerminatorPart, PortableRoadPart{Name: p.Name, Path: p.Path})
+			prt.TerminatorPart = append(prt.TerminatorPart, PortableRoadPart{Name: p.Name, Path: p.Path, NeedsMLOD: p.NeedsMLOD})
 		}
 
 		out.Types = append(out.Types, prt)
@@ -71,8 +89,91 @@ func ToPortableConfig(cfg RoadConfig) PortableConfig {
 			ColorCustom: cr.ColorCustom,
 			Connections: cr.Connections,
 			Default:     cr.Default,
+			Shape:       cr.Shape,
 		})
 	}
 
 	return out
 }
+
+// PortableChecksum returns the xxhash64 (16 hex digits) of the canonical JSON of cfg
+// without its Checksum. Empty lists count as missing ones, so the value survives a
+// YAML/JSON round-trip; any other edit, including hand edits, changes it.
+func PortableChecksum(cfg PortableConfig) (string, error) {
+	raw, err := json.Marshal(canonicalPortable(cfg))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%016x", xxhash.Sum64(raw)), nil
+}
+
+// Fingerprint returns a hash of the semantic Road Tool config: the PortableChecksum of
+// cfg as a portable config (no IDs, offsets or raw tv4p data) with road types and
+// crossroads sorted by name. Files with the same logical road setup get the same
+// fingerprint even when their IDs or byte layout differ.
+func Fingerprint(cfg RoadConfig) (string, error) {
+	pc := ToPortableConfig(cfg)
+	slices.SortStableFunc(pc.Types, func(a, b PortableRoadType) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	slices.SortStableFunc(pc.CrossroadTypes, func(a, b PortableCrossroadType) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+
+	return PortableChecksum(pc)
+}
+
+// SetChecksum stores PortableChecksum in cfg.Checksum.
+func (cfg *PortableConfig) SetChecksum() error {
+	sum, err := PortableChecksum(*cfg)
+	if err != nil {
+		return err
+	}
+	cfg.Checksum = sum
+
+	return nil
+}
+
+// VerifyChecksum recomputes the checksum and compares it with cfg.Checksum.
+// A config without a checksum is an error.
+func (cfg PortableConfig) VerifyChecksum() error {
+	if cfg.Checksum == "" {
+		return errors.New("config has no checksum (extract --portable --with-checksum)")
+	}
+
+	sum, err := PortableChecksum(cfg)
+	if err != nil {
+		return err
+	}
+	if sum != cfg.Checksum {
+		return fmt.Errorf("checksum mismatch: config says %s, content hashes to %s (edited or corrupted)", cfg.Checksum, sum)
+	}
+
+	return nil
+}
+
+// canonicalPortable returns a copy of cfg with the checksum cleared and empty lists set to nil.
+func canonicalPortable(cfg PortableConfig) PortableConfig {
+	cfg.Checksum = ""
+	if len(cfg.CrossroadTypes) == 0 {
+		cfg.CrossroadTypes = nil
+	}
+	if len(cfg.Types) == 0 {
+		cfg.Types = nil
+		return cfg
+	}
+
+	types := make([]PortableRoadType, len(cfg.Types))
+	for i, rt := range cfg.Types {
+		for _, parts := range []*[]PortableRoadPart{&rt.StraightParts, &rt.CornerParts, &rt.TerminatorPart} {
+			if len(*parts) == 0 {
+				*parts = nil
+			}
+		}
+		types[i] = rt
+	}
+	cfg.Types = types
+
+	return cfg
+}
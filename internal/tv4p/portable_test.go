@@ -0,0 +1,164 @@
+package tv4p
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPortableChecksum(t *testing.T) {
+	t.Parallel()
+
+	cfg := ToPortableConfig(testRoadConfig())
+	if err := cfg.VerifyChecksum(); err == nil {
+		t.Fatalf("no checksum: want error")
+	}
+	if err := cfg.SetChecksum(); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if len(cfg.Checksum) != 16 {
+		t.Fatalf("checksum=%q want 16 hex digits", cfg.Checksum)
+	}
+	if err := cfg.VerifyChecksum(); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// A JSON round-trip (nil part lists come back as null) keeps the checksum valid.
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var back PortableConfig
+	if err := json.Unmarshal(raw, &back); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if err := back.VerifyChecksum(); err != nil {
+		t.Fatalf("verify after round-trip: %v", err)
+	}
+
+	// Empty lists count as missing ones.
+	empty := back
+	empty.Types = append([]PortableRoadType(nil), back.Types...)
+	empty.Types[1].CornerParts = []PortableRoadPart{}
+	if err := empty.VerifyChecksum(); err != nil {
+		t.Fatalf("verify with empty list: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(c *PortableConfig)
+	}{
+		{name: "color", edit: func(c *PortableConfig) { c.Types[0].NormalColor.R++ }},
+		{name: "part_path", edit: func(c *PortableConfig) { c.Types[1].StraightParts[0].Path = `dz\roads\city_25.p3d` }},
+		{name: "crossroad", edit: func(c *PortableConfig) { c.CrossroadTypes = c.CrossroadTypes[:1] }},
+		{name: "checksum", edit: func(c *PortableConfig) { c.Checksum = "0000000000000000" }},
+	}
+
+	for _, tt := range tests {
+		edited := ToPortableConfig(testRoadConfig())
+		edited.Checksum = cfg.Checksum
+		tt.edit(&edited)
+		if err := edited.VerifyChecksum(); err == nil {
+			t.Fatalf("%s edit: want checksum mismatch", tt.name)
+		}
+	}
+}
+
+func TestFingerprintIgnoresIDs(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	a, err := ParseRoadToolConfig(buildTestFile(t, cfg, fixtureOptions{}))
+	if err != nil {
+		t.Fatalf("parse a: %v", err)
+	}
+
+	// Same setup with other IDs (shifted by 0x1000).
+	shifted := testRoadConfig()
+	for i := range shifted.Types {
+		rt := &shifted.Types[i]
+		rt.ID = 0x1000 + uint32(i)*0x48
+		for j := range rt.StraightParts {
+			rt.StraightParts[j].ID = 0x2000 + uint32(i*16+j)*4
+		}
+	}
+	b, err := ParseRoadToolConfig(buildTestFile(t, shifted, fixtureOptions{}))
+	if err != nil {
+		t.Fatalf("parse b: %v", err)
+	}
+	if a.Types[0].ID == b.Types[0].ID {
+		t.Fatalf("fixture IDs equal (0x%X), want different", a.Types[0].ID)
+	}
+
+	fa, err := Fingerprint(a)
+	if err != nil {
+		t.Fatalf("fingerprint a: %v", err)
+	}
+	fb, err := Fingerprint(b)
+	if err != nil {
+		t.Fatalf("fingerprint b: %v", err)
+	}
+	if fa != fb || len(fa) != 16 {
+		t.Fatalf("fingerprints=%s,%s want equal 16 hex digits", fa, fb)
+	}
+
+	// Road type and crossroad order do not matter, content does.
+	b.Types[0], b.Types[1] = b.Types[1], b.Types[0]
+	b.CrossroadTypes[0], b.CrossroadTypes[1] = b.CrossroadTypes[1], b.CrossroadTypes[0]
+	if fb, _ = Fingerprint(b); fb != fa {
+		t.Fatalf("reordered fingerprint=%s want %s", fb, fa)
+	}
+	b.Types[0].NormalColor.R++
+	if fb, _ = Fingerprint(b); fb == fa {
+		t.Fatalf("color change kept fingerprint %s", fa)
+	}
+}
+
+func TestPortableShapeRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// A T-named crossroad that is really an X: only the shape field says so.
+	cfg := testRoadConfig()
+	cfg.CrossroadTypes[0].Shape = ShapeX
+	data, err := PatchRoadTool(buildTestFile(t, testRoadConfig(), fixtureOptions{}), cfg, ScopeAll)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	extracted, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	// extract --portable, then patch reads the portable file as a regular config.
+	raw, err := json.Marshal(ToPortableConfig(extracted))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var portable RoadConfig
+	if err := json.Unmarshal(raw, &portable); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if portable.CrossroadTypes[0].TV4PDef != nil || portable.CrossroadTypes[0].Shape != ShapeX {
+		t.Fatalf("portable crossroad=%+v want shape x without tv4p_def", portable.CrossroadTypes[0])
+	}
+
+	out, err := PatchRoadTool(buildTestFile(t, testRoadConfig(), fixtureOptions{}), portable, ScopeAll)
+	if err != nil {
+		t.Fatalf("patch portable: %v", err)
+	}
+	got, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got.CrossroadTypes[0].Shape != ShapeX {
+		t.Fatalf("shape=%q after portable round-trip, want x", got.CrossroadTypes[0].Shape)
+	}
+
+	// The fingerprint sees the shape too.
+	plain, err := Fingerprint(testRoadConfig())
+	if err != nil {
+		t.Fatalf("fingerprint: %v", err)
+	}
+	if shaped, _ := Fingerprint(cfg); shaped == plain {
+		t.Fatalf("shape change kept fingerprint %s", plain)
+	}
+}
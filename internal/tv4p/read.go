@@ -1,6 +1,8 @@
 package tv4p
 
 import (
+	"bytes"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
@@ -8,7 +10,16 @@ import (
 
 // ParseRoadTypes parses the road types block from a tv4p file.
 func ParseRoadTypes(data []byte) (*RoadTypesBlock, error) {
-	meta, count, entries, err := findRoadTypesList(data)
+	return ParseRoadTypesWith(data, LocateOptions{})
+}
+
+// ParseRoadTypesWith parses the road types block using explicit locate options.
+func ParseRoadTypesWith(data []byte, loc LocateOptions) (*RoadTypesBlock, error) {
+	if err := loc.validate(len(data)); err != nil {
+		return nil, err
+	}
+
+	meta, count, entries, err := findRoadTypesList(data, loc)
 	if err != nil {
 		return nil, err
 	}
@@ -19,37 +30,10 @@ func ParseRoadTypes(data []byte) (*RoadTypesBlock, error) {
 
 	var out []RoadType
 	for _, e := range entries {
-		rt := RoadType{}
-		rt.ID = e.ID
-		rt.Type = e.TypeID
-		for _, f := range e.Fields {
-			switch f.Tag {
-			case 0x33: // name
-				rt.Name = string(f.Raw)
-			case 0x71: // q
-				rt.KeyCustom = len(f.Raw) > 0 && f.Raw[0] != 0
-			case 0x72: // r
-				rt.NormalCustom = len(f.Raw) > 0 && f.Raw[0] != 0
-			case 0x73: // s
-				if len(f.Raw) >= 4 {
-					rt.NormalColor = Color{f.Raw[0], f.Raw[1], f.Raw[2], f.Raw[3]}
-				}
-			case 0x74: // t
-				if len(f.Raw) >= 4 {
-					rt.KeyColor = Color{f.Raw[0], f.Raw[1], f.Raw[2], f.Raw[3]}
-				}
-			case 0x78: // x: straight list
-				rt.StraightParts = extractParts(f.List)
-			case 0x79: // y: corner list
-				rt.CornerParts = extractParts(f.List)
-			case 0x7B: // { : terminator list
-				rt.TerminatorPart = extractParts(f.List)
-			}
-		}
-		out = append(out, rt)
+		out = append(out, decodeRoadType(e))
 	}
 
-	return &RoadTypesBlock{
+	block := &RoadTypesBlock{
 		Start:        meta.Start,
 		Count:        count,
 		ListLen:      meta.ListLen,
@@ -57,7 +41,217 @@ func ParseRoadTypes(data []byte) (*RoadTypesBlock, error) {
 		EntriesLen:   meta.EntriesLen,
 		Entries:      entries,
 		Types:        out,
-	}, nil
+	}
+	if loc.Nested {
+		block.Parent = findNestedParent(data, meta.Start, meta.Start+7+meta.ListLen)
+	}
+
+	return block, nil
+}
+
+// FindRoadType returns the road type named name (case-insensitive) from the first
+// Road Tool block in data. Only the matching entry is decoded into a RoadType.
+// The bool is false when no road type has that name.
+func FindRoadType(data []byte, name string) (RoadType, bool, error) {
+	_, count, entries, err := findRoadTypesList(data, LocateOptions{})
+	if err != nil {
+		return RoadType{}, false, err
+	}
+	if len(entries) != int(count) {
+		return RoadType{}, false, fmt.Errorf("entry count mismatch: header=%d parsed=%d", count, len(entries))
+	}
+
+	for _, e := range entries {
+		if strings.EqualFold(entryString(e, 0x33), name) {
+			return decodeRoadType(e), true, nil
+		}
+	}
+
+	return RoadType{}, false, nil
+}
+
+// decodeRoadType decodes the UI fields and part lists of one 0x88 road type entry.
+func decodeRoadType(e Entry) RoadType {
+	rt := RoadType{}
+	rt.ID = e.ID
+	rt.Type = e.TypeID
+	var normalRaw, keyRaw []byte
+	for _, f := range e.Fields {
+		switch f.Tag {
+		case 0x33: // name
+			rt.Name = string(f.Raw)
+		case 0x71: // q
+			rt.KeyCustom = len(f.Raw) > 0 && f.Raw[0] != 0
+		case 0x72: // r
+			rt.NormalCustom = len(f.Raw) > 0 && f.Raw[0] != 0
+		case 0x73: // s
+			if len(f.Raw) >= 4 {
+				rt.NormalColor = Color{f.Raw[0], f.Raw[1], f.Raw[2], f.Raw[3]}
+				normalRaw = f.Raw[:4]
+			}
+		case 0x74: // t
+			if len(f.Raw) >= 4 {
+				rt.KeyColor = Color{f.Raw[0], f.Raw[1], f.Raw[2], f.Raw[3]}
+				keyRaw = f.Raw[:4]
+			}
+		case 0x78: // x: straight list
+			rt.StraightParts = extractParts(f.List)
+		case 0x79: // y: corner list
+			rt.CornerParts = extractParts(f.List)
+		case 0x7B: // { : terminator list
+			rt.TerminatorPart = extractParts(f.List)
+		case 0x75, 0x76, 0x77: // u, v, w: not modeled, keep when non-default
+			if !isDefaultRoadTypeExtra(f) {
+				rt.TV4PExtra = append(rt.TV4PExtra, FieldRaw{Tag: f.Tag, Type: f.Type, Raw: hex.EncodeToString(f.Raw)})
+			}
+		}
+	}
+
+	// Keep TB's own bytes for "standard" colors so round-trip does not rewrite them.
+	if !rt.NormalCustom && normalRaw != nil {
+		rt.TV4PNormalColor = hex.EncodeToString(normalRaw)
+	}
+	if !rt.KeyCustom && keyRaw != nil {
+		rt.TV4PKeyColor = hex.EncodeToString(keyRaw)
+	}
+
+	return rt
+}
+
+// AttachRoadTypeRaw stores the raw 0x88 entries of block into cfg.Types[i].TV4PRaw.
+// Road types are matched by position; cfg must come from the same block.
+func AttachRoadTypeRaw(cfg *RoadConfig, block *RoadTypesBlock) error {
+	if len(cfg.Types) != len(block.Entries) {
+		return fmt.Errorf("road type count mismatch: config=%d block=%d", len(cfg.Types), len(block.Entries))
+	}
+
+	for i := range cfg.Types {
+		cfg.Types[i].TV4PRaw = entryToRaw(block.Entries[i])
+	}
+
+	return nil
+}
+
+// AttachOffsets stores the file offsets of the road type, part and crossroad def entries
+// into their TV4POffsets fields. Entries are matched by position, so cfg must be the
+// unmodified result of ParseRoadToolConfigWith(data, loc).
+func AttachOffsets(cfg *RoadConfig, data []byte, loc LocateOptions) error {
+	block, err := ParseRoadTypesWith(data, loc)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Types) != len(block.Entries) {
+		return fmt.Errorf("road type count mismatch: config=%d block=%d", len(cfg.Types), len(block.Entries))
+	}
+
+	for i, e := range block.Entries {
+		rt := &cfg.Types[i]
+		rt.TV4POffsets = entryOffsets(e)
+		for _, f := range e.Fields {
+			var parts []RoadPart
+			switch f.Tag {
+			case 0x78:
+				parts = rt.StraightParts
+			case 0x79:
+				parts = rt.CornerParts
+			case 0x7B:
+				parts = rt.TerminatorPart
+			default:
+				continue
+			}
+
+			// Same filter as extractParts: entries without name and path are not parts.
+			j := 0
+			for _, pe := range f.List {
+				if entryString(pe, 0x33) == "" && entryString(pe, 0x7C) == "" {
+					continue
+				}
+				if j >= len(parts) {
+					return fmt.Errorf("road type %q: part count mismatch in list 0x%02X", rt.Name, f.Tag)
+				}
+				parts[j].TV4POffsets = entryOffsets(pe)
+				j++
+			}
+		}
+	}
+
+	if cfg.CrossroadTypes == nil {
+		return nil
+	}
+	defs, ok := findTaggedListAfter(data, block.Start+7+block.ListLen, 0x89, validateCrossroadDefs)
+	if !ok || len(defs.Entries) != len(cfg.CrossroadTypes) {
+		return fmt.Errorf("crossroad count mismatch: config=%d file=%d", len(cfg.CrossroadTypes), len(defs.Entries))
+	}
+	for i, e := range defs.Entries {
+		cfg.CrossroadTypes[i].TV4POffsets = entryOffsets(e)
+	}
+
+	return nil
+}
+
+// entryOffsets returns the offsets of a parsed entry.
+func entryOffsets(e Entry) *EntryOffsets {
+	return &EntryOffsets{Offset: e.Offset, IDOffset: e.IDOffset}
+}
+
+// PreservePartLists keeps the given part lists of cfg road types as they are in block:
+// for every road type matched by name (case-insensitive), the original list fields are
+// stored in TV4PExtra, so a patch writes them back verbatim, and the parts are replaced
+// with the file's parts. Road types not found in block are left untouched.
+// It returns the number of matched road types.
+func PreservePartLists(cfg *RoadConfig, block *RoadTypesBlock, keep []PartList) (int, error) {
+	byName := map[string]int{}
+	for i, rt := range block.Types {
+		if _, ok := byName[strings.ToLower(rt.Name)]; !ok {
+			byName[strings.ToLower(rt.Name)] = i
+		}
+	}
+
+	matched := 0
+	for i := range cfg.Types {
+		rt := &cfg.Types[i]
+		idx, ok := byName[strings.ToLower(rt.Name)]
+		if !ok {
+			continue
+		}
+		matched++
+
+		raw := entryToRaw(block.Entries[idx])
+		old := block.Types[idx]
+		for _, l := range keep {
+			tag := l.tag()
+			if tag == 0 {
+				return 0, fmt.Errorf("unknown part list %q", l)
+			}
+
+			for _, f := range raw.Fields {
+				if f.Tag == tag && f.Type == 0x0C {
+					rt.TV4PExtra = append(rt.TV4PExtra, f)
+				}
+			}
+			switch l {
+			case ListStraight:
+				rt.StraightParts = old.StraightParts
+			case ListCorner:
+				rt.CornerParts = old.CornerParts
+			case ListTerminator:
+				rt.TerminatorPart = old.TerminatorPart
+			}
+		}
+	}
+
+	return matched, nil
+}
+
+// isDefaultRoadTypeExtra reports whether an unmodeled road type field matches
+// what buildRoadTypeEntry writes by default (0x75 byte 0, 0x76/0x77 8 zero bytes).
+func isDefaultRoadTypeExtra(f Field) bool {
+	want := defaultRoadTypeExtra(f.Tag)
+	if len(want) < 3 || want[2] != f.Type {
+		return false
+	}
+
+	return bytes.Equal(want[3:], f.Raw)
 }
 
 // extractParts extracts the parts from a list of entries.
@@ -75,6 +269,10 @@ func extractParts(list []Entry) []RoadPart {
 				p.Name = string(f.Raw)
 			case 0x7C:
 				p.Path = string(f.Raw)
+			case 0x7D:
+				if len(f.Raw) == 1 {
+					p.TV4PFlag = f.Raw[0]
+				}
 			}
 		}
 
@@ -87,14 +285,27 @@ func extractParts(list []Entry) []RoadPart {
 }
 
 // findRoadTypesList finds the road types list in a byte slice.
-func findRoadTypesList(data []byte) (roadTypesMeta, uint32, []Entry, error) {
+// When loc.NearOffset > 0 the whole file is scanned and the list closest to it wins
+// (lists with road content are still preferred over empty ones).
+func findRoadTypesList(data []byte, loc LocateOptions) (roadTypesMeta, uint32, []Entry, error) {
+	near := loc.NearOffset
 	type candidate struct {
 		entries []Entry
 		meta    roadTypesMeta
 		count   uint32
+		found   bool
+	}
+
+	distance := func(c candidate) int {
+		d := c.meta.Start - near
+		if d < 0 {
+			return -d
+		}
+		return d
 	}
 
 	var candidates []candidate
+	bestFound := -1
 	for i := 0; i+11 < len(data); i++ {
 		if data[i] != 0x88 || data[i+1] != 0x00 || data[i+2] != 0x0C {
 			continue
@@ -120,7 +331,7 @@ func findRoadTypesList(data []byte) (roadTypesMeta, uint32, []Entry, error) {
 
 		found := false
 		for _, e := range entries {
-			if entryHasRoadPath(e) || entryHasRoadLists(e) {
+			if entryHasRoadPath(e, loc.ModelExts) || entryHasRoadLists(e) {
 				found = true
 				break
 			}
@@ -136,13 +347,36 @@ func findRoadTypesList(data []byte) (roadTypesMeta, uint32, []Entry, error) {
 			meta:    meta,
 			count:   countU32,
 			entries: entries,
+			found:   found,
 		})
 
 		if found {
-			return meta, countU32, entries, nil
+			if near <= 0 {
+				return meta, countU32, entries, nil
+			}
+			last := len(candidates) - 1
+			if bestFound < 0 || distance(candidates[last]) < distance(candidates[bestFound]) {
+				bestFound = last
+			}
 		}
 	}
 
+	if bestFound >= 0 {
+		c := candidates[bestFound]
+		return c.meta, c.count, c.entries, nil
+	}
+
+	if near > 0 && len(candidates) > 0 {
+		best := 0
+		for i := range candidates {
+			if distance(candidates[i]) < distance(candidates[best]) {
+				best = i
+			}
+		}
+		c := candidates[best]
+		return c.meta, c.count, c.entries, nil
+	}
+
 	if len(candidates) == 1 {
 		c := candidates[0]
 		return c.meta, c.count, c.entries, nil
@@ -163,19 +397,23 @@ func findRoadTypesList(data []byte) (roadTypesMeta, uint32, []Entry, error) {
 		return roadTypesMeta{}, 0, nil, fmt.Errorf("road types list not found (ambiguous: %d lists)", len(candidates))
 	}
 
+	if swapped := findByteSwappedList(data, 0x88); swapped != nil {
+		return roadTypesMeta{}, 0, nil, fmt.Errorf("road types list not found: %w", swapped)
+	}
+
 	return roadTypesMeta{}, 0, nil, errors.New("road types list not found")
 }
 
-// entryHasRoadPath checks if an entry has a road path.
-func entryHasRoadPath(e Entry) bool {
+// entryHasRoadPath checks if an entry has a road path (see looksLikeRoadPath).
+func entryHasRoadPath(e Entry, exts []string) bool {
 	for _, f := range e.Fields {
-		if f.Tag == 0x7C && looksLikeRoadPath(string(f.Raw)) {
+		if f.Tag == 0x7C && looksLikeRoadPath(string(f.Raw), exts) {
 			return true
 		}
 
 		for _, sub := range f.List {
 			for _, sf := range sub.Fields {
-				if sf.Tag == 0x7C && looksLikeRoadPath(string(sf.Raw)) {
+				if sf.Tag == 0x7C && looksLikeRoadPath(string(sf.Raw), exts) {
 					return true
 				}
 			}
@@ -185,6 +423,8 @@ func entryHasRoadPath(e Entry) bool {
 	return false
 }
 
+// entryHasRoadLists checks if an entry has a road type part list field (0x78/0x79/0x7B),
+// which also marks road types without any parts (see buildRoadTypeEntry).
 func entryHasRoadLists(e Entry) bool {
 	for _, f := range e.Fields {
 		switch f.Tag {
@@ -196,10 +436,21 @@ func entryHasRoadLists(e Entry) bool {
 	return false
 }
 
-func looksLikeRoadPath(p string) bool {
+// looksLikeRoadPath reports whether p contains a model extension (case-insensitive).
+// Empty exts means DefaultModelExts.
+func looksLikeRoadPath(p string, exts []string) bool {
+	if len(exts) == 0 {
+		exts = DefaultModelExts
+	}
+
 	p = strings.ToLower(p)
-	p = strings.ReplaceAll(p, "/", "\\")
-	return strings.Contains(p, ".p3d")
+	for _, ext := range exts {
+		if ext != "" && strings.Contains(p, strings.ToLower(ext)) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // parseEntries parses a list of entries from a byte slice.
@@ -263,7 +514,7 @@ func parseEntry(body []byte, absStart int) (Entry, bool) {
 				return Entry{}, false
 			}
 
-			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+4]})
+			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+4], Offset: absStart + pos})
 			pos += 4
 
 		case 0x0B: // string
@@ -277,7 +528,7 @@ func parseEntry(body []byte, absStart int) (Entry, bool) {
 				return Entry{}, false
 			}
 
-			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+ln]})
+			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+ln], Offset: absStart + pos})
 			pos += ln
 
 		case 0x0D: // u32 (observed in crossroads/special entries)
@@ -285,7 +536,7 @@ func parseEntry(body []byte, absStart int) (Entry, bool) {
 				return Entry{}, false
 			}
 
-			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+4]})
+			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+4], Offset: absStart + pos})
 			pos += 4
 
 		case 0x09: // byte
@@ -293,7 +544,7 @@ func parseEntry(body []byte, absStart int) (Entry, bool) {
 				return Entry{}, false
 			}
 
-			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+1]})
+			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+1], Offset: absStart + pos})
 			pos++
 
 		case 0x08: // color
@@ -301,7 +552,7 @@ func parseEntry(body []byte, absStart int) (Entry, bool) {
 				return Entry{}, false
 			}
 
-			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+4]})
+			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+4], Offset: absStart + pos})
 			pos += 4
 
 		case 0x14: // bytes
@@ -309,7 +560,7 @@ func parseEntry(body []byte, absStart int) (Entry, bool) {
 				return Entry{}, false
 			}
 
-			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+8]})
+			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+8], Offset: absStart + pos})
 			pos += 8
 
 		case 0x15: // byte + N*8 bytes (observed as 0x02 + 2x f64 in crossroads/special entries)
@@ -322,7 +573,7 @@ func parseEntry(body []byte, absStart int) (Entry, bool) {
 				return Entry{}, false
 			}
 
-			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+total]})
+			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+total], Offset: absStart + pos})
 			pos += total
 
 		case 0x20: // 3 bytes (observed in crossroads/special entries)
@@ -331,7 +582,7 @@ func parseEntry(body []byte, absStart int) (Entry, bool) {
 				return Entry{}, false
 			}
 
-			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+3]})
+			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, Raw: body[pos : pos+3], Offset: absStart + pos})
 			pos += 3
 
 		case 0x0C: // list
@@ -358,7 +609,7 @@ func parseEntry(body []byte, absStart int) (Entry, bool) {
 				return Entry{}, false
 			}
 
-			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, List: listEntries})
+			ent.Fields = append(ent.Fields, Field{Tag: tag, Type: typ, List: listEntries, Offset: absStart + listStart - 8})
 			pos = listEnd
 
 		default:
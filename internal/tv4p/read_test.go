@@ -0,0 +1,330 @@
+package tv4p
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseRoadTypesNearOffset(t *testing.T) {
+	t.Parallel()
+
+	first := RoadConfig{Types: []RoadType{{Name: "decoy", StraightParts: []RoadPart{{Name: "decoy_6", Path: `x\decoy_6.p3d`}}}}}
+	data := buildTestFile(t, first, fixtureOptions{noCrossroads: true})
+	secondStart := len(data)
+	data = append(data, roadTypesField(t, RoadConfig{Types: testRoadConfig().Types})...)
+	data = append(data, 0xFE, 0xFE)
+
+	tests := []struct {
+		name string
+		near int
+		want string
+		err  bool
+	}{
+		{name: "default_first", near: 0, want: "decoy"},
+		{name: "near_second", near: secondStart + 3, want: "asf1"},
+		{name: "near_first", near: 20, want: "decoy"},
+		{name: "out_of_range", near: len(data) + 1, err: true},
+		{name: "negative", near: -1, err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			block, err := ParseRoadTypesWith(data, LocateOptions{NearOffset: tt.near})
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want err=%v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if got := block.Types[0].Name; got != tt.want {
+				t.Fatalf("first type=%q want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreservePartLists(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	orig, err := ParseRoadTypes(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	// Every list of asf1 differs from the file; city is new-only in one list.
+	edited := func() RoadConfig {
+		cfg := RoadConfig{Types: testRoadConfig().Types}
+		rt := &cfg.Types[0]
+		rt.StraightParts = []RoadPart{{Name: "asf1_25", Path: `dz\roads\asf1_25.p3d`}}
+		rt.CornerParts = []RoadPart{{Name: "asf1_10 75", Path: `dz\roads\asf1_10 75.p3d`}}
+		rt.TerminatorPart = []RoadPart{{Name: "asf1_6konec", Path: `dz\roads\asf1_6konec.p3d`}}
+		return cfg
+	}
+
+	all := []PartList{ListStraight, ListCorner, ListTerminator}
+	for mask := 0; mask < 1<<len(all); mask++ {
+		var keep []PartList
+		for i, l := range all {
+			if mask&(1<<i) != 0 {
+				keep = append(keep, l)
+			}
+		}
+
+		t.Run(fmt.Sprint(keep), func(t *testing.T) {
+			t.Parallel()
+
+			cfg := edited()
+			want := edited().Types[0]
+			n, err := PreservePartLists(&cfg, orig, keep)
+			if err != nil || n != 2 {
+				t.Fatalf("matched=%d err=%v want 2", n, err)
+			}
+
+			out, err := PatchRoadTool(data, cfg, ScopeRoads)
+			if err != nil {
+				t.Fatalf("patch: %v", err)
+			}
+			got, err := ParseRoadTypes(out)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			kept := map[PartList]bool{}
+			for _, l := range keep {
+				kept[l] = true
+			}
+			for _, c := range []struct {
+				list      PartList
+				got, orig []RoadPart
+				want      []RoadPart
+			}{
+				{ListStraight, got.Types[0].StraightParts, orig.Types[0].StraightParts, want.StraightParts},
+				{ListCorner, got.Types[0].CornerParts, orig.Types[0].CornerParts, want.CornerParts},
+				{ListTerminator, got.Types[0].TerminatorPart, orig.Types[0].TerminatorPart, want.TerminatorPart},
+			} {
+				exp := c.want
+				if kept[c.list] {
+					exp = c.orig
+				}
+				if !samePartPaths(c.got, exp) {
+					t.Fatalf("%s parts=%+v want %+v", c.list, c.got, exp)
+				}
+				if kept[c.list] && len(c.got) > 0 && c.got[0].ID != c.orig[0].ID {
+					t.Fatalf("%s part ID=0x%X want preserved 0x%X", c.list, c.got[0].ID, c.orig[0].ID)
+				}
+			}
+		})
+	}
+
+	cfg := edited()
+	if _, err := PreservePartLists(&cfg, orig, []PartList{"bogus"}); err == nil {
+		t.Fatalf("unknown list: want error")
+	}
+}
+
+// samePartPaths compares part lists by name and path.
+func samePartPaths(a, b []RoadPart) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name || a[i].Path != b[i].Path {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestFindRoadType(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	block, err := ParseRoadTypes(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		found bool
+		index int
+	}{
+		{name: "city", found: true, index: 1},
+		{name: "ASF1", found: true, index: 0},
+		{name: "asf2"},
+		{name: ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rt, found, err := FindRoadType(data, tt.name)
+			if err != nil {
+				t.Fatalf("find: %v", err)
+			}
+			if found != tt.found {
+				t.Fatalf("found=%v want %v", found, tt.found)
+			}
+			if !tt.found {
+				return
+			}
+			if want := block.Types[tt.index]; !reflect.DeepEqual(rt, want) {
+				t.Fatalf("road type=%+v want %+v", rt, want)
+			}
+		})
+	}
+
+	if _, _, err := FindRoadType([]byte{1, 2, 3}, "city"); err == nil {
+		t.Fatalf("no road types: want error")
+	}
+}
+
+// deepEntryRaw returns an entry whose 0x90 list field nests depth levels of
+// entries, each with a string and a u32 field; the innermost list is empty.
+func deepEntryRaw(depth int) EntryRaw {
+	e := EntryRaw{Type: 0x1B, ID: uint32(0x100 + depth), Fields: []FieldRaw{
+		{Tag: 0x33, Type: 0x0B, Raw: hex.EncodeToString([]byte(fmt.Sprintf("level%d", depth)))},
+		{Tag: 0x7F, Type: 0x0D, Raw: "03000000"},
+		{Tag: 0x90, Type: 0x0C},
+	}}
+	if depth > 1 {
+		e.Fields[2].List = []EntryRaw{deepEntryRaw(depth - 1), deepEntryRaw(depth - 1)}
+	}
+
+	return e
+}
+
+func TestParseEntryDeepNesting(t *testing.T) {
+	t.Parallel()
+
+	const depth = 5
+	raw := deepEntryRaw(depth)
+	entry, err := rawEntryToBytes(raw, newIDAllocator(RoadConfig{}, map[uint32]struct{}{}, 0), "deep")
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	// Place the entry at a non-zero offset so absolute offsets are checked.
+	data := append(bytes.Repeat([]byte{0xEE}, 13), entry...)
+	entries, ok := parseEntries(data, 13, len(entry), 1, 0)
+	if !ok || len(entries) != 1 {
+		t.Fatalf("parse: ok=%v entries=%d", ok, len(entries))
+	}
+
+	var walk func(e Entry, level int)
+	walk = func(e Entry, level int) {
+		if e.Offset+6 > len(data) || readU32(data[e.IDOffset:]) != e.ID || readU16(data[e.Offset:]) != e.TypeID {
+			t.Fatalf("level %d: offset=0x%X id offset=0x%X do not point at type 0x%X id 0x%X", level, e.Offset, e.IDOffset, e.TypeID, e.ID)
+		}
+		if e.ID != uint32(0x100+level) {
+			t.Fatalf("level %d: id=0x%X want 0x%X", level, e.ID, 0x100+level)
+		}
+		list := e.Fields[2].List
+		if level == 1 {
+			if len(list) != 0 {
+				t.Fatalf("level 1: list=%d want empty", len(list))
+			}
+			return
+		}
+		if len(list) != 2 {
+			t.Fatalf("level %d: list=%d want 2", level, len(list))
+		}
+		for _, sub := range list {
+			walk(sub, level-1)
+		}
+	}
+	walk(entries[0], depth)
+
+	if got := entryToRaw(entries[0]); !reflect.DeepEqual(*got, raw) {
+		t.Fatalf("entryToRaw=%+v want %+v", *got, raw)
+	}
+}
+
+func TestLooksLikeRoadPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		exts []string
+		want bool
+	}{
+		{path: `dz\roads\asf1_12.p3d`, want: true},
+		{path: `DZ\ROADS\ASF1_12.P3D`, want: true},
+		{path: `dz\roads\asf1_12.rvmat`, want: false},
+		{path: `dz\roads\asf1_12.mdl`, exts: []string{".p3d"}, want: false},
+		{path: `dz\roads\asf1_12.MDL`, exts: []string{".p3d", ".mdl"}, want: true},
+		{path: `dz\roads\asf1_12.p3d`, exts: []string{".mdl"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := looksLikeRoadPath(tt.path, tt.exts); got != tt.want {
+			t.Fatalf("looksLikeRoadPath(%q, %v)=%v want %v", tt.path, tt.exts, got, tt.want)
+		}
+	}
+}
+
+func TestAttachOffsets(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	plain, err := PatchRoadTool(data, cfg, ScopeAll)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+
+	if err := AttachOffsets(&cfg, data, LocateOptions{}); err != nil {
+		t.Fatalf("AttachOffsets: %v", err)
+	}
+
+	check := func(what string, off *EntryOffsets, id uint32) {
+		t.Helper()
+		if off == nil {
+			t.Fatalf("%s: no offsets", what)
+		}
+		if got := readU32(data[off.IDOffset:]); got != id || off.IDOffset != off.Offset+2 {
+			t.Fatalf("%s: id at 0x%X=0x%X want 0x%X", what, off.IDOffset, got, id)
+		}
+	}
+	for _, rt := range cfg.Types {
+		check(rt.Name, rt.TV4POffsets, rt.ID)
+		for _, parts := range [][]RoadPart{rt.StraightParts, rt.CornerParts, rt.TerminatorPart} {
+			for _, p := range parts {
+				check(p.Name, p.TV4POffsets, p.ID)
+			}
+		}
+	}
+	for _, cr := range cfg.CrossroadTypes {
+		if cr.TV4PDef == nil {
+			t.Fatalf("%s: no tv4p_def", cr.Name)
+		}
+		check(cr.Name, cr.TV4POffsets, cr.TV4PDef.ID)
+	}
+
+	// Read-only: patching ignores the offsets.
+	withOffsets, err := PatchRoadTool(data, cfg, ScopeAll)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if !bytes.Equal(withOffsets, plain) {
+		t.Fatalf("offsets changed the patch output")
+	}
+
+	cfg.Types = cfg.Types[:1]
+	if err := AttachOffsets(&cfg, data, LocateOptions{}); err == nil {
+		t.Fatalf("expected error for a modified config")
+	}
+}
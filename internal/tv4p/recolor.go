@@ -0,0 +1,108 @@
+package tv4p
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RecolorReport summarizes a PatchCrossroadColors call.
+type RecolorReport struct {
+	Updated   []string // crossroad names (as in the file) whose color fields were rewritten
+	Unmatched []string // config crossroads without a def in the file
+}
+
+// PatchCrossroadColors rewrites only the color (0x73) and custom flag (0x71) fields
+// of crossroad defs (0x89) already in the file, in place: the output has the same size
+// and every other byte is kept. Config crossroads are matched by name, then by model
+// (case-insensitive, slash style ignored); unmatched ones are reported, not added.
+// The bytes written match a full patch: RGBA for custom colors, otherwise a zero flag
+// and TB's standard color sentinel.
+func PatchCrossroadColors(data []byte, crossroads []CrossroadType, loc LocateOptions) ([]byte, RecolorReport, error) {
+	var report RecolorReport
+
+	rtBlock, err := ParseRoadTypesWith(data, loc)
+	if err != nil {
+		return nil, report, err
+	}
+
+	defs, ok := findTaggedListAfter(data, rtBlock.Start+7+rtBlock.ListLen, 0x89, validateCrossroadDefs)
+	if !ok {
+		return nil, report, errors.New("crossroad defs list (0x89) not found")
+	}
+
+	out := append([]byte(nil), data...)
+	for _, cr := range crossroads {
+		matches := matchCrossroadDefs(defs.Entries, cr)
+		if len(matches) == 0 {
+			report.Unmatched = append(report.Unmatched, cr.Name)
+			continue
+		}
+
+		for _, e := range matches {
+			name := entryString(e, 0x33)
+			if err := writeCrossroadColor(out, e, cr); err != nil {
+				return nil, report, fmt.Errorf("crossroad %q: %w", name, err)
+			}
+			report.Updated = append(report.Updated, name)
+		}
+	}
+
+	return out, report, nil
+}
+
+// matchCrossroadDefs returns the defs named like cr or, when none is, with the same model.
+func matchCrossroadDefs(defs []Entry, cr CrossroadType) []Entry {
+	var byName, byModel []Entry
+	model := normalizeModelKey(cr.Model)
+	for _, e := range defs {
+		switch {
+		case cr.Name != "" && strings.EqualFold(entryString(e, 0x33), cr.Name):
+			byName = append(byName, e)
+		case model != "" && normalizeModelKey(entryString(e, 0x7C)) == model:
+			byModel = append(byModel, e)
+		}
+	}
+
+	if len(byName) > 0 {
+		return byName
+	}
+
+	return byModel
+}
+
+// crossroadStandardColor is the 0x73 sentinel TB stores for a non-custom crossroad color.
+var crossroadStandardColor = []byte{0x00, 0x00, 0xFF, 0x00}
+
+// normalizeModelKey folds case and slash style of a model path for matching.
+func normalizeModelKey(p string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(p), "/", `\`))
+}
+
+// writeCrossroadColor overwrites the 0x71 flag and the 0x73 color of a def (see buildCrossroadDefEntry).
+func writeCrossroadColor(out []byte, def Entry, cr CrossroadType) error {
+	var flag, color *Field
+	for i := range def.Fields {
+		f := &def.Fields[i]
+		switch {
+		case f.Tag == 0x71 && f.Type == 0x09 && len(f.Raw) == 1:
+			flag = f
+		case f.Tag == 0x73 && f.Type == 0x08 && len(f.Raw) == 4:
+			color = f
+		}
+	}
+	if flag == nil || color == nil {
+		return errors.New("def has no 0x71/0x73 color fields to rewrite in place")
+	}
+
+	if !cr.ColorCustom {
+		out[flag.Offset] = 0
+		copy(out[color.Offset:], crossroadStandardColor)
+		return nil
+	}
+
+	out[flag.Offset] = 1
+	copy(out[color.Offset:], []byte{cr.Color.R, cr.Color.G, cr.Color.B, cr.Color.A})
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package tv4p
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPatchCrossroadColors(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	cfg.CrossroadTypes[1].ColorCustom = true
+	cfg.CrossroadTypes[1].Color = Color{R: 10, G: 20, B: 30, A: 255}
+	data := buildTestFile(t, cfg, fixtureOptions{links: true})
+
+	recolor := []CrossroadType{
+		{Name: "KR_T_ASF1_CITY", ColorCustom: true, Color: Color{R: 200, G: 100, B: 50, A: 255}},
+		{Name: "renamed", Model: "p:/dz/roads/kr_x_city_city.p3d"},
+		{Name: "kr_t_missing"},
+	}
+	out, report, err := PatchCrossroadColors(data, recolor, LocateOptions{})
+	if err != nil {
+		t.Fatalf("recolor: %v", err)
+	}
+
+	if !slices.Equal(report.Updated, []string{"kr_t_asf1_city", "kr_x_city_city"}) {
+		t.Fatalf("updated=%v", report.Updated)
+	}
+	if !slices.Equal(report.Unmatched, []string{"kr_t_missing"}) {
+		t.Fatalf("unmatched=%v", report.Unmatched)
+	}
+
+	if len(out) != len(data) {
+		t.Fatalf("size=%d want %d", len(out), len(data))
+	}
+	diff := 0
+	for i := range data {
+		if out[i] != data[i] {
+			diff++
+		}
+	}
+	// At most flag + RGBA per def.
+	if diff == 0 || diff > 10 {
+		t.Fatalf("changed bytes=%d want 1..10", diff)
+	}
+
+	got, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cr := got.CrossroadTypes[0]; !cr.ColorCustom || cr.Color != (Color{R: 200, G: 100, B: 50, A: 255}) {
+		t.Fatalf("kr_t color=%+v custom=%v", cr.Color, cr.ColorCustom)
+	}
+	if cr := got.CrossroadTypes[1]; cr.ColorCustom || cr.Color != (Color{B: 0xFF}) {
+		t.Fatalf("kr_x color=%+v custom=%v want standard sentinel", cr.Color, cr.ColorCustom)
+	}
+
+	noCrossroads := buildTestFile(t, testRoadConfig(), fixtureOptions{noCrossroads: true})
+	if _, _, err := PatchCrossroadColors(noCrossroads, recolor, LocateOptions{}); err == nil {
+		t.Fatalf("file without crossroads: want error")
+	}
+}
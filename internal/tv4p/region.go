@@ -0,0 +1,113 @@
+package tv4p
+
+import "fmt"
+
+// regionSpans holds the boundaries of the Road Tool lists inside a tv4p file.
+// Missing lists have zero length (e.g. defsEnd == roadsEnd when 0x89 is absent).
+type regionSpans struct {
+	start      int // offset of the 0x88 list header
+	roadsEnd   int // end of the 0x88 list
+	defsEnd    int // end of the 0x89 list
+	linksStart int // offset of the 0x8A list header (== end when 0x8A is absent)
+	end        int // end of the 0x8A list (including meta before it)
+}
+
+// findRegionSpans locates the Road Tool lists using the regular detection heuristics.
+func findRegionSpans(data []byte) (regionSpans, error) {
+	block, err := ParseRoadTypes(data)
+	if err != nil {
+		return regionSpans{}, err
+	}
+
+	s := regionSpans{start: block.Start}
+	s.roadsEnd = block.Start + 7 + block.ListLen
+	s.defsEnd = s.roadsEnd
+	s.end = s.roadsEnd
+	s.linksStart = s.end
+
+	crDefs, ok := findTaggedListAfter(data, s.roadsEnd, 0x89, validateCrossroadDefs)
+	if !ok {
+		return s, nil
+	}
+	s.defsEnd = crDefs.Start + crDefs.FieldLen
+	s.end = s.defsEnd
+	s.linksStart = s.end
+
+	crLinks, ok := findTaggedListAfter(data, s.defsEnd, 0x8A, validateCrossroadLinks)
+	if ok {
+		s.linksStart = crLinks.Start
+		s.end = crLinks.Start + crLinks.FieldLen
+	}
+
+	return s, nil
+}
+
+// RoadToolRegion returns the byte span of the whole Road Tool region in a tv4p file.
+//
+// The span starts at the road types list header (0x88) and ends after the
+// crossroad links list (0x8A). When links are absent it ends after the crossroad
+// defs list (0x89), and when crossroads are absent it ends after the 0x88 list.
+// The meta fields between 0x89 and 0x8A are included.
+func RoadToolRegion(data []byte) (start, end int, err error) {
+	s, err := findRegionSpans(data)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return s.start, s.end, nil
+}
+
+// CopyRoadToolRegion replaces the Road Tool region of dst with the region of src.
+//
+// Entry IDs are copied verbatim. The file offsets outside the region are shifted
+// the same way PatchRoadTool does it:
+// - tag 0x18/type 0x0D shifts by the total size delta
+// - tag 0x3E/type 0x0D shifts by the 0x88 + 0x89 size delta
+//
+// The meta between 0x89 and 0x8A is copied with the region, except for the
+// 0x3F/0x0D field: when dst has a meta of its own, its 0x3F value is kept and
+// shifted by the 0x8A size delta, as PatchRoadTool does. Without a dst meta the
+// source value is used as is. The 0x19/0x20 field follows the copied 0x8A IDs.
+// The result is re-parsed before returning.
+func CopyRoadToolRegion(src []byte, dst []byte) ([]byte, error) {
+	s, err := findRegionSpans(src)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	d, err := findRegionSpans(dst)
+	if err != nil {
+		return nil, fmt.Errorf("destination: %w", err)
+	}
+
+	deltaRoads := (s.roadsEnd - s.start) - (d.roadsEnd - d.start)
+	deltaDefs := (s.defsEnd - s.roadsEnd) - (d.defsEnd - d.roadsEnd)
+	deltaLinks := (s.end - s.defsEnd) - (d.end - d.defsEnd)
+
+	region := src[s.start:s.end]
+	out := make([]byte, 0, len(dst)-(d.end-d.start)+len(region))
+	out = append(out, dst[:d.start]...)
+	out = append(out, region...)
+	out = append(out, dst[d.end:]...)
+
+	if s.linksStart < s.end && d.linksStart < d.end {
+		dstMeta := dst[d.defsEnd:d.linksStart]
+		outMeta := out[d.start+(s.defsEnd-s.start) : d.start+(s.linksStart-s.start)]
+		deltaLinkList := (s.end - s.linksStart) - (d.end - d.linksStart)
+		if err := copyU32FieldInSlice(outMeta, dstMeta, 0x3F, 0x0D, deltaLinkList); err != nil {
+			return nil, fmt.Errorf("crossroads meta: %w", err)
+		}
+	}
+
+	if err := adjustOffsetsByTag(out, 0x18, 0x0D, deltaRoads+deltaDefs+deltaLinks, d.start); err != nil {
+		return nil, err
+	}
+	if err := adjustOffsetsByTag(out, 0x3E, 0x0D, deltaRoads+deltaDefs, d.start); err != nil {
+		return nil, err
+	}
+
+	if _, err := ParseRoadToolConfig(out); err != nil {
+		return nil, fmt.Errorf("copied region does not re-parse: %w", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,176 @@
+package tv4p
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRoadToolRegion(t *testing.T) {
+	t.Parallel()
+
+	trailer := []byte{0xFE, 0xFE, 0xFE, 0xFE}
+
+	tests := []struct {
+		name string
+		cfg  RoadConfig
+		opts fixtureOptions
+		last byte // tag of the last list inside the region
+	}{
+		{name: "with_links", cfg: testRoadConfig(), last: 0x8A},
+		{name: "no_links", cfg: testRoadConfig(), opts: fixtureOptions{noLinks: true}, last: 0x89},
+		{name: "roads_only", cfg: RoadConfig{Types: testRoadConfig().Types}, opts: fixtureOptions{noCrossroads: true}, last: 0x88},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := buildTestFile(t, tt.cfg, tt.opts)
+			start, end, err := RoadToolRegion(data)
+			if err != nil {
+				t.Fatalf("RoadToolRegion: %v", err)
+			}
+			if data[start] != 0x88 {
+				t.Fatalf("start tag=0x%02X want 0x88", data[start])
+			}
+			if end != len(data)-len(trailer) {
+				t.Fatalf("end=%d want %d", end, len(data)-len(trailer))
+			}
+			if !bytes.Equal(data[end:], trailer) {
+				t.Fatalf("region end is not followed by trailer")
+			}
+			if got := lastTopLevelTag(data[start:end]); got != tt.last {
+				t.Fatalf("last list tag=0x%02X want 0x%02X", got, tt.last)
+			}
+		})
+	}
+}
+
+// lastTopLevelTag walks top-level list fields (and 7-byte meta fields) in a region.
+func lastTopLevelTag(region []byte) byte {
+	var last byte
+	for pos := 0; pos+3 <= len(region); {
+		tag, typ := region[pos], region[pos+2]
+		switch typ {
+		case 0x0C:
+			last = tag
+			pos += 7 + int(readU32(region[pos+3:]))
+		case 0x0D:
+			pos += 7
+		case 0x20:
+			pos += 6
+		default:
+			return 0
+		}
+	}
+
+	return last
+}
+
+func TestCopyRoadToolRegion(t *testing.T) {
+	t.Parallel()
+
+	src := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	dstCfg := RoadConfig{Types: testRoadConfig().Types[:1]}
+	dst := buildTestFile(t, dstCfg, fixtureOptions{noCrossroads: true})
+
+	out, err := CopyRoadToolRegion(src, dst)
+	if err != nil {
+		t.Fatalf("CopyRoadToolRegion: %v", err)
+	}
+
+	want, err := ParseRoadToolConfig(src)
+	if err != nil {
+		t.Fatalf("parse src: %v", err)
+	}
+	got, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("parse out: %v", err)
+	}
+	if len(got.Types) != len(want.Types) || len(got.CrossroadTypes) != len(want.CrossroadTypes) {
+		t.Fatalf("got %d/%d types/crossroads want %d/%d",
+			len(got.Types), len(got.CrossroadTypes), len(want.Types), len(want.CrossroadTypes))
+	}
+	for i := range want.Types {
+		if got.Types[i].ID != want.Types[i].ID || got.Types[i].Name != want.Types[i].Name {
+			t.Fatalf("type %d: got=%s/0x%X want %s/0x%X", i, got.Types[i].Name, got.Types[i].ID, want.Types[i].Name, want.Types[i].ID)
+		}
+	}
+
+	delta := len(out) - len(dst)
+	if v := int(readU32(out[7:])); v != 0x1000+delta {
+		t.Fatalf("0x18 offset=0x%X want 0x%X", v, 0x1000+delta)
+	}
+
+	_, linksEnd, _ := RoadToolRegion(src)
+	_, defsSpan, _ := RoadToolRegion(buildTestFile(t, testRoadConfig(), fixtureOptions{noLinks: true}))
+	deltaNoLinks := delta - (linksEnd - defsSpan)
+	if v := int(readU32(out[14:])); v != 0x2000+deltaNoLinks {
+		t.Fatalf("0x3E offset=0x%X want 0x%X", v, 0x2000+deltaNoLinks)
+	}
+	if !bytes.Equal(out[len(out)-4:], []byte{0xFE, 0xFE, 0xFE, 0xFE}) {
+		t.Fatalf("destination trailer not preserved")
+	}
+}
+
+func TestCopyRoadToolRegionMeta(t *testing.T) {
+	t.Parallel()
+
+	u32At := func(t *testing.T, b []byte, tag byte) int {
+		t.Helper()
+		pos := bytes.Index(b, []byte{tag, 0x00, 0x0D})
+		if pos < 0 {
+			t.Fatalf("field 0x%02X not found", tag)
+		}
+		return int(readU32(b[pos+3:]))
+	}
+
+	src := buildTestFile(t, testRoadConfig(), fixtureOptions{links: true})
+
+	// The destination has a different layout: one road type, one crossroad
+	// and its own 0x3F value.
+	dstCfg := testRoadConfig()
+	dstCfg.Types = dstCfg.Types[1:]
+	dstCfg.CrossroadTypes = dstCfg.CrossroadTypes[1:]
+	dst := buildTestFile(t, dstCfg, fixtureOptions{})
+	metaPos := bytes.Index(dst, []byte{0x3F, 0x00, 0x0D})
+	if metaPos < 0 {
+		t.Fatalf("dst 0x3F not found")
+	}
+	if err := writeU32FromInt(dst[metaPos+3:], 0x5000); err != nil {
+		t.Fatalf("set dst 0x3F: %v", err)
+	}
+
+	out, err := CopyRoadToolRegion(src, dst)
+	if err != nil {
+		t.Fatalf("CopyRoadToolRegion: %v", err)
+	}
+
+	s, err := findRegionSpans(src)
+	if err != nil {
+		t.Fatalf("src spans: %v", err)
+	}
+	d, err := findRegionSpans(dst)
+	if err != nil {
+		t.Fatalf("dst spans: %v", err)
+	}
+	deltaLinks := (s.end - s.linksStart) - (d.end - d.linksStart)
+	if deltaLinks == 0 {
+		t.Fatalf("fixture 0x8A lists have the same size")
+	}
+
+	delta := len(out) - len(dst)
+	if v := u32At(t, out, 0x18); v != 0x1000+delta {
+		t.Fatalf("0x18=0x%X want 0x%X", v, 0x1000+delta)
+	}
+	if v := u32At(t, out, 0x3E); v != 0x2000+delta-deltaLinks {
+		t.Fatalf("0x3E=0x%X want 0x%X", v, 0x2000+delta-deltaLinks)
+	}
+	if v := u32At(t, out, 0x3F); v != 0x5000+deltaLinks {
+		t.Fatalf("0x3F=0x%X want dst value shifted to 0x%X", v, 0x5000+deltaLinks)
+	}
+	if v := u32At(t, src, 0x3F); v != 0x3000 {
+		t.Fatalf("src 0x3F=0x%X modified", v)
+	}
+}
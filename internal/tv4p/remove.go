@@ -0,0 +1,233 @@
+package tv4p
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// RemoveReport summarizes a RemoveRoadTypes call.
+type RemoveReport struct {
+	RoadTypes    []string // removed road type names
+	Dropped      []string // dropped crossroad names
+	ClearedSides int      // crossroad sides cleared (RemoveClear)
+}
+
+// crossroadSides lists the connection sides with their 0x89 index field and 0x8A side list tags.
+var crossroadSides = []struct {
+	name  string
+	index byte // 0x84..0x87 in tv4p_def
+	list  byte // 0x92..0x95 in tv4p_link
+}{
+	{"A", 0x84, 0x92},
+	{"B", 0x85, 0x93},
+	{"C", 0x86, 0x94},
+	{"D", 0x87, 0x95},
+}
+
+// RemoveRoadTypes removes the road types matching names (see MatchRoadTypeNames) from cfg
+// together with their references: crossroad sides are cleared or whole crossroads dropped
+// (see RemoveMode), defaults pointing to a removed type are reset, and the raw road type
+// indices of every remaining crossroad (tv4p_def 0x84..0x87, connection_indices) are shifted
+// to the new order. Cleared sides also empty the matching tv4p_link side list.
+// A name or pattern that matches no road type is an error.
+func RemoveRoadTypes(cfg *RoadConfig, names []string, mode RemoveMode) (RemoveReport, error) {
+	var report RemoveReport
+	if mode == "" {
+		mode = RemoveClear
+	}
+	if mode != RemoveClear && mode != RemoveDrop {
+		return report, fmt.Errorf("unknown remove mode %q", mode)
+	}
+
+	matched, err := MatchRoadTypeNames(cfg.Types, names)
+	if err != nil {
+		return report, err
+	}
+	removed := map[string]bool{}
+	for _, n := range matched {
+		removed[strings.ToLower(strings.TrimSpace(n))] = true
+	}
+
+	// remap: old road type index -> new index (-1 = removed).
+	remap := make([]int, len(cfg.Types))
+	var kept []RoadType
+	for i, rt := range cfg.Types {
+		if removed[strings.ToLower(strings.TrimSpace(rt.Name))] {
+			remap[i] = -1
+			report.RoadTypes = append(report.RoadTypes, rt.Name)
+			continue
+		}
+		remap[i] = len(kept)
+		kept = append(kept, rt)
+	}
+
+	isRemoved := func(name string) bool {
+		return name != "" && removed[strings.ToLower(strings.TrimSpace(name))]
+	}
+
+	var crossroads []CrossroadType
+	for _, cr := range cfg.CrossroadTypes {
+		sides := []*string{&cr.Connections.A, &cr.Connections.B, &cr.Connections.C, &cr.Connections.D}
+		var hit []int
+		for i, s := range sides {
+			byIndex := false
+			if ci := cr.ConnectionIndices; ci != nil {
+				v := ci.side(i)
+				byIndex = v >= 0 && remapIndex(remap, v) < 0
+			}
+			if isRemoved(*s) || byIndex {
+				hit = append(hit, i)
+			}
+		}
+
+		if len(hit) > 0 && mode == RemoveDrop {
+			report.Dropped = append(report.Dropped, cr.Name)
+			continue
+		}
+
+		for _, i := range hit {
+			*sides[i] = ""
+			report.ClearedSides++
+			cr.TV4PLink = withEmptySideList(cr.TV4PLink, crossroadSides[i].list)
+			cr.TV4PSideRefs = slices.DeleteFunc(slices.Clone(cr.TV4PSideRefs), func(r CrossroadSideRef) bool {
+				return r.Side == crossroadSides[i].name
+			})
+		}
+		if isRemoved(cr.Default) {
+			cr.Default = ""
+		}
+
+		if ci := cr.ConnectionIndices; ci != nil {
+			cr.ConnectionIndices = &CrossroadIndices{
+				A: remapIndex(remap, ci.A),
+				B: remapIndex(remap, ci.B),
+				C: remapIndex(remap, ci.C),
+				D: remapIndex(remap, ci.D),
+			}
+		}
+		if cr.TV4PDef != nil {
+			def, err := remapRawIndices(*cr.TV4PDef, remap)
+			if err != nil {
+				return report, fmt.Errorf("crossroad %q: %w", cr.Name, err)
+			}
+			cr.TV4PDef = &def
+		}
+
+		if len(hit) > 0 && !hasConnection(cr) {
+			report.Dropped = append(report.Dropped, cr.Name)
+			continue
+		}
+		crossroads = append(crossroads, cr)
+	}
+
+	cfg.Types = kept
+	if cfg.CrossroadTypes != nil {
+		cfg.CrossroadTypes = crossroads
+		if cfg.CrossroadTypes == nil {
+			cfg.CrossroadTypes = []CrossroadType{}
+		}
+	}
+
+	return report, nil
+}
+
+// MatchRoadTypeNames returns the names of the road types matching any of patterns, in
+// road type order. Patterns use path.Match syntax (`*`, `?`, `[a-z]`) against the whole
+// name, case-insensitive; a pattern without metacharacters is a literal name. A malformed
+// pattern or one that matches no road type is an error.
+func MatchRoadTypeNames(types []RoadType, patterns []string) ([]string, error) {
+	hit := make([]bool, len(types))
+	for _, p := range patterns {
+		pat := strings.ToLower(strings.TrimSpace(p))
+		if _, err := path.Match(pat, ""); err != nil {
+			return nil, fmt.Errorf("road type pattern %q: %w", p, err)
+		}
+
+		found := false
+		for i, rt := range types {
+			if ok, _ := path.Match(pat, strings.ToLower(strings.TrimSpace(rt.Name))); ok {
+				hit[i] = true
+				found = true
+			}
+		}
+		if !found {
+			if strings.ContainsAny(pat, `*?[\`) {
+				return nil, fmt.Errorf("no road type matches %q", p)
+			}
+			return nil, fmt.Errorf("road type %q not found", p)
+		}
+	}
+
+	var out []string
+	for i, rt := range types {
+		if hit[i] {
+			out = append(out, rt.Name)
+		}
+	}
+
+	return out, nil
+}
+
+// side returns the index of connection side i (0=A .. 3=D).
+func (ci CrossroadIndices) side(i int) int {
+	return [4]int{ci.A, ci.B, ci.C, ci.D}[i]
+}
+
+// remapIndex maps an old road type index to its new one.
+// Unset (-1) stays unset; indices outside the old list are kept as they are.
+func remapIndex(remap []int, v int) int {
+	if v < 0 || v >= len(remap) {
+		return v
+	}
+
+	return remap[v]
+}
+
+// remapRawIndices returns a copy of a raw crossroad def with its 0x84..0x87 indices remapped.
+func remapRawIndices(def EntryRaw, remap []int) (EntryRaw, error) {
+	idx := CrossroadIndices{A: -1, B: -1, C: -1, D: -1}
+	ptrs := []*int{&idx.A, &idx.B, &idx.C, &idx.D}
+	for i, s := range crossroadSides {
+		v, ok, err := rawFieldU32(&def, s.index)
+		if err != nil {
+			return def, err
+		}
+		if ok && v != 0xFFFFFFFF {
+			*ptrs[i] = remapIndex(remap, int(v))
+		}
+	}
+
+	return withConnectionIndices(def, idx), nil
+}
+
+// withEmptySideList returns a copy of a raw link entry with the side list tag emptied.
+func withEmptySideList(link *EntryRaw, tag byte) *EntryRaw {
+	if link == nil {
+		return nil
+	}
+
+	out := *link
+	out.Fields = slices.Clone(link.Fields)
+	for i := range out.Fields {
+		if out.Fields[i].Tag == tag && out.Fields[i].Type == 0x0C {
+			out.Fields[i].List = nil
+		}
+	}
+
+	return &out
+}
+
+// hasConnection reports whether a crossroad still has any connected side.
+func hasConnection(cr CrossroadType) bool {
+	c := cr.Connections
+	if strings.TrimSpace(c.A+c.B+c.C+c.D) != "" {
+		return true
+	}
+	if ci := cr.ConnectionIndices; ci != nil {
+		return ci.A >= 0 || ci.B >= 0 || ci.C >= 0 || ci.D >= 0
+	}
+
+	return false
+}
@@ -0,0 +1,168 @@
+package tv4p
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemoveRoadTypes(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{links: true})
+
+	tests := []struct {
+		name    string
+		remove  []string
+		mode    RemoveMode
+		types   []string
+		want    map[string]CrossroadConnections // crossroads left after patch + parse
+		dropped []string
+		cleared int
+	}{
+		{
+			name:    "clear asf1",
+			remove:  []string{"ASF1"},
+			mode:    RemoveClear,
+			types:   []string{"city"},
+			want:    map[string]CrossroadConnections{"kr_t_asf1_city": {C: "city"}, "kr_x_city_city": {A: "city", B: "city", C: "city", D: "city"}},
+			cleared: 2,
+		},
+		{
+			name:    "drop asf1",
+			remove:  []string{"asf1"},
+			mode:    RemoveDrop,
+			types:   []string{"city"},
+			want:    map[string]CrossroadConnections{"kr_x_city_city": {A: "city", B: "city", C: "city", D: "city"}},
+			dropped: []string{"kr_t_asf1_city"},
+		},
+		{
+			name:    "clear city",
+			remove:  []string{"city"},
+			mode:    RemoveClear,
+			types:   []string{"asf1"},
+			want:    map[string]CrossroadConnections{"kr_t_asf1_city": {A: "asf1", B: "asf1"}},
+			dropped: []string{"kr_x_city_city"},
+			cleared: 5,
+		},
+		{
+			name:    "drop glob",
+			remove:  []string{"C?T*"},
+			mode:    RemoveDrop,
+			types:   []string{"asf1"},
+			want:    map[string]CrossroadConnections{},
+			dropped: []string{"kr_t_asf1_city", "kr_x_city_city"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := ParseRoadToolConfig(data)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			report, err := RemoveRoadTypes(&cfg, tt.remove, tt.mode)
+			if err != nil {
+				t.Fatalf("remove: %v", err)
+			}
+			if !reflect.DeepEqual(report.Dropped, tt.dropped) || report.ClearedSides != tt.cleared {
+				t.Fatalf("report=%+v want dropped %v cleared %d", report, tt.dropped, tt.cleared)
+			}
+
+			out, err := PatchRoadTool(data, cfg, ScopeAll)
+			if err != nil {
+				t.Fatalf("patch: %v", err)
+			}
+			got, err := ParseRoadToolConfig(out)
+			if err != nil {
+				t.Fatalf("parse patched: %v", err)
+			}
+
+			var types []string
+			for _, rt := range got.Types {
+				types = append(types, rt.Name)
+			}
+			if !reflect.DeepEqual(types, tt.types) {
+				t.Fatalf("types=%v want %v", types, tt.types)
+			}
+
+			conns := map[string]CrossroadConnections{}
+			for _, cr := range got.CrossroadTypes {
+				conns[cr.Name] = cr.Connections
+				for _, ref := range cr.TV4PSideRefs {
+					if strings.Contains(ref.Path, tt.remove[0]) {
+						t.Fatalf("%s: side ref %+v still points to a removed type", cr.Name, ref)
+					}
+				}
+			}
+			if !reflect.DeepEqual(conns, tt.want) {
+				t.Fatalf("crossroads=%+v want %+v", conns, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveRoadTypesIndices(t *testing.T) {
+	t.Parallel()
+
+	cfg := RoadConfig{
+		Types: []RoadType{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		CrossroadTypes: []CrossroadType{
+			{Name: "kr_t_c_a", ConnectionIndices: &CrossroadIndices{A: 2, B: 2, C: 0, D: -1}},
+			{Name: "kr_t_b_b", ConnectionIndices: &CrossroadIndices{A: 1, B: 1, C: 1, D: -1}},
+		},
+	}
+
+	report, err := RemoveRoadTypes(&cfg, []string{"b"}, RemoveClear)
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if len(cfg.CrossroadTypes) != 1 || !reflect.DeepEqual(report.Dropped, []string{"kr_t_b_b"}) {
+		t.Fatalf("crossroads=%+v report=%+v want only kr_t_c_a", cfg.CrossroadTypes, report)
+	}
+	if got := *cfg.CrossroadTypes[0].ConnectionIndices; got != (CrossroadIndices{A: 1, B: 1, C: 0, D: -1}) {
+		t.Fatalf("indices=%+v want {1 1 0 -1}", got)
+	}
+
+	if _, err := RemoveRoadTypes(&cfg, []string{"missing"}, RemoveClear); err == nil {
+		t.Fatalf("unknown road type: want error")
+	}
+}
+
+func TestMatchRoadTypeNames(t *testing.T) {
+	t.Parallel()
+
+	var types []RoadType
+	for _, n := range []string{"asf1", "asf2", "ASF3", "city", "city_rail", "railway", "gravel"} {
+		types = append(types, RoadType{Name: n})
+	}
+
+	tests := []struct {
+		patterns []string
+		want     []string
+		err      bool
+	}{
+		{patterns: []string{"asf*"}, want: []string{"asf1", "asf2", "ASF3"}},
+		{patterns: []string{"*rail*"}, want: []string{"city_rail", "railway"}},
+		{patterns: []string{"city"}, want: []string{"city"}},
+		{patterns: []string{"CITY", "asf[12]"}, want: []string{"asf1", "asf2", "city"}},
+		{patterns: []string{"gravel", "gr*"}, want: []string{"gravel"}},
+		{patterns: []string{"asf?"}, want: []string{"asf1", "asf2", "ASF3"}},
+		{patterns: []string{"cit"}, err: true},
+		{patterns: []string{"dirt*"}, err: true},
+		{patterns: []string{"asf["}, err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := MatchRoadTypeNames(types, tt.patterns)
+		if (err != nil) != tt.err {
+			t.Fatalf("patterns=%v err=%v want err=%v", tt.patterns, err, tt.err)
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("patterns=%v got=%v want %v", tt.patterns, got, tt.want)
+		}
+	}
+}
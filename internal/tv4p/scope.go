@@ -1,5 +1,7 @@
 package tv4p
 
+import "fmt"
+
 // Scope controls which parts of the Road Tool configuration are processed.
 type Scope string
 
@@ -21,3 +23,102 @@ func (s Scope) IncludesRoads() bool {
 func (s Scope) IncludesCrossroads() bool {
 	return s == ScopeAll || s == ScopeCrossroad
 }
+
+// CrossroadOrder controls how crossroad defs are ordered before writing 0x89.
+type CrossroadOrder string
+
+const (
+	// CrossroadOrderAuto reorders defs so 0x89[i] matches road_types[i] when the config
+	// has defaults or generated entries (TB index fallback).
+	CrossroadOrderAuto CrossroadOrder = "auto"
+
+	// CrossroadOrderKeep writes defs exactly in config order.
+	CrossroadOrderKeep CrossroadOrder = "keep"
+
+	// CrossroadOrderMatchRoads places the crossroad whose default is road_types[i] at
+	// 0x89[i], strictly by default (see orderCrossroadsMatchRoads for gaps).
+	CrossroadOrderMatchRoads CrossroadOrder = "match-roads"
+)
+
+// CrossroadShape is the crossroad shape preferred when picking defaults.
+type CrossroadShape string
+
+const (
+	// ShapeT prefers T junctions (kr_t_*) over X junctions (default).
+	ShapeT CrossroadShape = "t"
+
+	// ShapeX prefers X junctions (kr_x_*) over T junctions.
+	ShapeX CrossroadShape = "x"
+)
+
+// RemoveMode controls what RemoveRoadTypes does with crossroads connected to a removed road type.
+type RemoveMode string
+
+const (
+	// RemoveClear clears the connection sides that reference a removed road type (default).
+	// Crossroads left without any connection are dropped.
+	RemoveClear RemoveMode = "clear"
+
+	// RemoveDrop drops every crossroad that references a removed road type on any side.
+	RemoveDrop RemoveMode = "drop"
+)
+
+// PartList names one of the part lists of a road type.
+type PartList string
+
+const (
+	// ListStraight is the starting parts list (0x78).
+	ListStraight PartList = "straight"
+
+	// ListCorner is the corner parts list (0x79).
+	ListCorner PartList = "corner"
+
+	// ListTerminator is the terminator parts list (0x7B).
+	ListTerminator PartList = "terminator"
+)
+
+// tag returns the road type field tag of the list (0 if unknown).
+func (l PartList) tag() byte {
+	switch l {
+	case ListStraight:
+		return 0x78
+	case ListCorner:
+		return 0x79
+	case ListTerminator:
+		return 0x7B
+	default:
+		return 0
+	}
+}
+
+// LocateOptions tunes how the Road Tool block is located inside a tv4p file.
+// The zero value uses the default heuristics (first block with road content wins).
+type LocateOptions struct {
+	// NearOffset biases detection to the road types list (0x88) closest to this
+	// byte offset. Useful when a file has several 0x88-looking sequences.
+	// Zero disables the hint.
+	NearOffset int
+
+	// Nested resolves one level of parent nesting around the road types list:
+	// the entry (06 00 0D) and list field (xx 00 0C) that enclose it. Their length
+	// fields are then kept in sync when patching. The byte scan itself already
+	// finds nested lists; without this flag their parents are not updated.
+	Nested bool
+
+	// ModelExts are the model file extensions (e.g. ".p3d", ".p3de") that mark
+	// a part path during detection, matched case-insensitively. Empty means
+	// DefaultModelExts. Entries with part lists are detected either way.
+	ModelExts []string
+}
+
+// DefaultModelExts are the model extensions used when none are configured.
+var DefaultModelExts = []string{".p3d"}
+
+// validate checks the options against the file size.
+func (o LocateOptions) validate(size int) error {
+	if o.NearOffset < 0 || (o.NearOffset > 0 && o.NearOffset >= size) {
+		return fmt.Errorf("near offset %d out of range (file size %d)", o.NearOffset, size)
+	}
+
+	return nil
+}
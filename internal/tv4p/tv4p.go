@@ -16,6 +16,23 @@ type CrossroadConnections struct {
 	D string `json:"D,omitempty"` // D road type name (optional branch road through the crossroad)
 }
 
+// CrossroadIndices stores the A/B/C/D selections as raw road type indices
+// (0x84..0x87, position in road_types). -1 means the side is not set.
+type CrossroadIndices struct {
+	A int `json:"A"` // A road type index
+	B int `json:"B"` // B road type index
+	C int `json:"C"` // C road type index
+	D int `json:"D"` // D road type index
+}
+
+// CrossroadBlobs keeps the 8-byte 0x80/0x81/0x82 fields (type 0x14) of a crossroad def as hex.
+// Their meaning is not reverse engineered yet (likely transform/bounding box data).
+type CrossroadBlobs struct {
+	F80 string `json:"f80,omitempty"` // 0x80 raw bytes
+	F81 string `json:"f81,omitempty"` // 0x81 raw bytes
+	F82 string `json:"f82,omitempty"` // 0x82 raw bytes
+}
+
 // FieldRaw is a JSON/YAML-friendly representation of a tv4p field.
 // Raw bytes are encoded as hex to allow lossless round-trip.
 type FieldRaw struct {
@@ -41,15 +58,43 @@ type CrossroadType struct {
 	TV4PLink    *EntryRaw            `json:"tv4p_link,omitempty"`   // raw entry from 0x8A list (TypeID 0x1A)
 	Connections CrossroadConnections `json:"connections,omitempty"` // A/B/C/D road type names
 
+	// ConnectionIndices, when set, is written as is instead of resolving Connections by name.
+	ConnectionIndices *CrossroadIndices `json:"connection_indices,omitempty"`
+
 	Name  string `json:"name"`  // e.g. kr_t_asf1_asf2
 	Model string `json:"model"` // e.g. P:\DZ\structures\roads\Parts\kr_t_asf1_asf2.p3d
 
+	// Shape is the junction shape (t or x) stored in the def 0x7F / link 0x90 enum.
+	// Extract reads it from the file; when set, patch writes it instead of guessing
+	// from the kr_x_ name prefix. Raw tv4p_def/tv4p_link entries are written as is.
+	Shape CrossroadShape `json:"shape,omitempty"`
+
 	// Default marks this crossroad as the default for a specific road type name.
 	// This influences patch ordering for the Terrain Builder "Create crossroad" fallback behavior.
 	Default string `json:"default,omitempty"`
 
 	Color       Color `json:"color"`                  // UI color
 	ColorCustom bool  `json:"color_custom,omitempty"` // if false, TB uses standard color sentinel
+
+	// TV4PBlobs holds the non-zero 0x80..0x82 def fields, so they survive when tv4p_def
+	// is removed to edit other fields (generated defs otherwise write zeros).
+	TV4PBlobs *CrossroadBlobs `json:"tv4p_blobs,omitempty"`
+
+	// TV4PSideRefs lists the decoded 0x1B side references of tv4p_link (read-only, for analysis).
+	// Patching ignores it; tv4p_link is written as is.
+	TV4PSideRefs []CrossroadSideRef `json:"tv4p_side_refs,omitempty"`
+
+	// TV4POffsets is where the 0x89 def was found (read-only, see AttachOffsets).
+	TV4POffsets *EntryOffsets `json:"tv4p_offsets,omitempty"`
+}
+
+// CrossroadSideRef is a decoded 0x1B reference entry from a 0x8A side list (0x92-0x95).
+type CrossroadSideRef struct {
+	Side string `json:"side"`           // A/B/C/D (list 0x92/0x93/0x94/0x95)
+	Path string `json:"path"`           // referenced road part path (0x33)
+	Kind uint32 `json:"kind"`           // 0x7F u32 (observed: 3)
+	Flag uint32 `json:"flag,omitempty"` // 0x6C u32 (observed: 1)
+	ID   uint32 `json:"id,omitempty"`   // entry ID
 }
 
 // RoadTypesBlock represents the raw road types list block inside a tv4p file.
@@ -61,6 +106,15 @@ type RoadTypesBlock struct {
 	EntriesStart int        // offset of the first entry in the list payload
 	EntriesLen   int        // byte length of entries payload (listLen - 4)
 	Count        uint32     // number of road types in this block
+
+	// Parent is set when the list is nested one level deep (LocateOptions.Nested).
+	Parent *NestedParent
+}
+
+// NestedParent locates the list field and entry that enclose a nested road types list.
+type NestedParent struct {
+	ListStart  int // offset of the enclosing list field header (tag/0x00/0x0C)
+	EntryStart int // offset of the enclosing entry header (06 00 0D)
 }
 
 // Entry is a raw tv4p entry (type/id + fields).
@@ -74,10 +128,11 @@ type Entry struct {
 
 // Field is a raw tv4p field inside an entry.
 type Field struct {
-	Raw  []byte  // raw payload for non-list fields
-	List []Entry // nested entries for list fields
-	Tag  byte    // field tag (e.g. 0x33 name, 0x7C path)
-	Type byte    // field type (0x0B string, 0x08 color, 0x0C list, etc.)
+	Raw    []byte  // raw payload for non-list fields
+	List   []Entry // nested entries for list fields
+	Offset int     // absolute offset of Raw in the tv4p file (list fields: of the list length)
+	Tag    byte    // field tag (e.g. 0x33 name, 0x7C path)
+	Type   byte    // field type (0x0B string, 0x08 color, 0x0C list, etc.)
 }
 
 // RoadType is a road type as shown in Terrain Builder Road Types window.
@@ -92,6 +147,35 @@ type RoadType struct {
 	NormalColor    Color      `json:"normal_parts_color"`  // Normal Parts Color (UI)
 	KeyCustom      bool       `json:"key_parts_custom"`    // Key Parts Color is custom (not default)
 	NormalCustom   bool       `json:"normal_parts_custom"` // Normal Parts Color is custom (not default)
+
+	// World is the world detected from the name (--group-by-world, e.g. sakhal/enoch).
+	// It is config-only and not stored in tv4p.
+	World string `json:"world,omitempty"`
+
+	// Original RGBA bytes (hex) of non-custom colors as written by TB.
+	// Written back verbatim while the matching custom flag stays false.
+	TV4PKeyColor    string `json:"tv4p_key_color,omitempty"`    // raw 0x74 when key_parts_custom=false
+	TV4PNormalColor string `json:"tv4p_normal_color,omitempty"` // raw 0x73 when normal_parts_custom=false
+
+	// TV4PExtra keeps road type fields the tool does not model (0x75/0x76/0x77)
+	// when they differ from the defaults the writer emits. Written back verbatim.
+	// A part list field (0x78/0x79/0x7B) here replaces the list built from the parts
+	// (see PreservePartLists).
+	TV4PExtra []FieldRaw `json:"tv4p_extra,omitempty"`
+
+	// TV4PRaw is the full raw 0x88 entry (extract --emit-raw).
+	// When present it is written back verbatim and the modeled fields above are ignored.
+	TV4PRaw *EntryRaw `json:"tv4p_raw,omitempty"`
+
+	// TV4POffsets is where the entry was found (extract --emit-offsets, see AttachOffsets).
+	// Read-only metadata: patching ignores it.
+	TV4POffsets *EntryOffsets `json:"tv4p_offsets,omitempty"`
+}
+
+// EntryOffsets are the absolute file offsets of a parsed entry, for hex-editor lookups.
+type EntryOffsets struct {
+	Offset   int `json:"offset"`    // entry body (after the 06 00 0D <u32 len> header)
+	IDOffset int `json:"id_offset"` // 4-byte entry ID
 }
 
 // Color is an RGBA color used for road parts UI.
@@ -108,6 +192,18 @@ type RoadPart struct {
 	Path string `json:"object_file"`  // Object File path from UI (p3d)
 	ID   uint32 `json:"id,omitempty"` // internal ID for this part
 	Type uint16 `json:"type"`         // entry type (0x13 straight, 0x14 corner, 0x16 terminator)
+
+	// NeedsMLOD marks a part whose model was found as ODOL (generate --include-odol).
+	// It is config-only: patch writes the part as usual, the flag is not stored in tv4p.
+	// extract --game-root sets it again from the model headers.
+	NeedsMLOD bool `json:"needs_mlod,omitempty"`
+
+	// TV4PFlag is the 0x7D byte of straight parts (meaning unknown, usually 0).
+	// It is written back for straight parts only.
+	TV4PFlag byte `json:"tv4p_flag,omitempty"`
+
+	// TV4POffsets is where the entry was found (read-only, see AttachOffsets).
+	TV4POffsets *EntryOffsets `json:"tv4p_offsets,omitempty"`
 }
 
 // roadTypesMeta represents the internal offsets of the road types list.
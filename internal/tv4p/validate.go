@@ -1,6 +1,7 @@
 package tv4p
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -19,6 +20,12 @@ func ValidateCrossroads(crossroads []CrossroadType, roadTypes []RoadType) error
 	seenDefault := map[string]string{} // roadTypeLower -> crossroadName
 
 	for _, cr := range crossroads {
+		switch cr.Shape {
+		case "", ShapeT, ShapeX:
+		default:
+			return fmt.Errorf("crossroad %q: unknown shape %q (want t or x)", cr.Name, cr.Shape)
+		}
+
 		// Validate connection names.
 		for side, v := range map[string]string{
 			"A": cr.Connections.A,
@@ -53,3 +60,159 @@ func ValidateCrossroads(crossroads []CrossroadType, roadTypes []RoadType) error
 
 	return nil
 }
+
+// ValidateUniqueIDs checks that the explicitly set entry IDs (non-zero id of road
+// types and parts, tv4p_def/tv4p_link IDs of crossroads) are unique across the config.
+// The ID allocator treats them as distinct entries, so a copy-pasted ID would end up
+// twice in the file. Every duplicate is reported with the entries sharing it.
+func ValidateUniqueIDs(cfg RoadConfig) error {
+	owners := map[uint32][]string{}
+	var order []uint32
+	add := func(id uint32, owner string) {
+		if id == 0 {
+			return
+		}
+		if _, ok := owners[id]; !ok {
+			order = append(order, id)
+		}
+		owners[id] = append(owners[id], owner)
+	}
+
+	for _, rt := range cfg.Types {
+		add(rt.ID, fmt.Sprintf("road type %q", rt.Name))
+		for _, list := range []struct {
+			kind  string
+			parts []RoadPart
+		}{
+			{"straight part", rt.StraightParts},
+			{"corner part", rt.CornerParts},
+			{"terminator part", rt.TerminatorPart},
+		} {
+			for _, p := range list.parts {
+				add(p.ID, fmt.Sprintf("%s %q of %q", list.kind, p.Name, rt.Name))
+			}
+		}
+	}
+	for _, cr := range cfg.CrossroadTypes {
+		if cr.TV4PDef != nil {
+			add(cr.TV4PDef.ID, fmt.Sprintf("crossroad %q tv4p_def", cr.Name))
+		}
+		if cr.TV4PLink != nil {
+			add(cr.TV4PLink.ID, fmt.Sprintf("crossroad %q tv4p_link", cr.Name))
+		}
+	}
+
+	var errs []error
+	for _, id := range order {
+		if len(owners[id]) > 1 {
+			errs = append(errs, fmt.Errorf("duplicate id 0x%X (%d): %s", id, id, strings.Join(owners[id], ", ")))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CrossroadRefError is a crossroad connection that does not resolve to a road type.
+type CrossroadRefError struct {
+	Crossroad string // crossroad name
+	Side      string // A/B/C/D
+	RoadType  string // referenced road type name
+	CaseMatch string // road type that matches only case-insensitively, if any
+}
+
+// Error implements error.
+func (e CrossroadRefError) Error() string {
+	msg := fmt.Sprintf("crossroad %q: unknown road type for %s: %q", e.Crossroad, e.Side, e.RoadType)
+	if e.CaseMatch != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", e.CaseMatch)
+	}
+
+	return msg
+}
+
+// ValidateCrossroadRefs checks that every non-empty A/B/C/D connection of every crossroad
+// names a road type exactly as the writer resolves it (case-sensitive).
+// Unlike ValidateCrossroads it does not stop at the first problem: all offenders
+// are returned joined (errors.Join of CrossroadRefError values), nil when all resolve.
+func ValidateCrossroadRefs(crossroads []CrossroadType, roadTypes []RoadType) error {
+	exact := map[string]struct{}{}
+	folded := map[string]string{}
+	for _, rt := range roadTypes {
+		exact[rt.Name] = struct{}{}
+		if _, ok := folded[strings.ToLower(rt.Name)]; !ok {
+			folded[strings.ToLower(rt.Name)] = rt.Name
+		}
+	}
+
+	var errs []error
+	for _, cr := range crossroads {
+		for _, side := range []struct {
+			name string
+			v    string
+		}{
+			{name: "A", v: cr.Connections.A},
+			{name: "B", v: cr.Connections.B},
+			{name: "C", v: cr.Connections.C},
+			{name: "D", v: cr.Connections.D},
+		} {
+			if side.v == "" {
+				continue
+			}
+			if _, ok := exact[side.v]; ok {
+				continue
+			}
+
+			errs = append(errs, CrossroadRefError{
+				Crossroad: cr.Name,
+				Side:      side.name,
+				RoadType:  side.v,
+				CaseMatch: folded[strings.ToLower(strings.TrimSpace(side.v))],
+			})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RoadTypesWithoutDefault returns the names of road types, in road type order,
+// that no crossroad marks as its default (case-insensitive, like ValidateCrossroads).
+// Greedy default selection can leave a road type without a default when every
+// crossroad connected to it was already taken as the default of another type.
+func RoadTypesWithoutDefault(roadTypes []RoadType, crossroads []CrossroadType) []string {
+	hasDefault := map[string]struct{}{}
+	for _, cr := range crossroads {
+		if d := strings.TrimSpace(cr.Default); d != "" {
+			hasDefault[strings.ToLower(d)] = struct{}{}
+		}
+	}
+
+	var missing []string
+	for _, rt := range roadTypes {
+		name := strings.TrimSpace(rt.Name)
+		if name == "" {
+			continue
+		}
+		if _, ok := hasDefault[strings.ToLower(name)]; !ok {
+			missing = append(missing, rt.Name)
+		}
+	}
+
+	return missing
+}
+
+// RoadTypesWithCrossroads returns the road types, in road type order, that appear in
+// the connections of at least one crossroad (case-insensitive). Crossroads connected
+// only through connection_indices are not considered.
+func RoadTypesWithCrossroads(roadTypes []RoadType, crossroads []CrossroadType) []RoadType {
+	var out []RoadType
+	for _, rt := range roadTypes {
+		for _, cr := range crossroads {
+			if crossroadHasRoadType(cr, strings.TrimSpace(rt.Name)) {
+				out = append(out, rt)
+				break
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,124 @@
+package tv4p
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateCrossroadRefs(t *testing.T) {
+	t.Parallel()
+
+	roadTypes := []RoadType{{Name: "asf1"}, {Name: "city"}}
+
+	if err := ValidateCrossroadRefs(testRoadConfig().CrossroadTypes, roadTypes); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	crossroads := []CrossroadType{
+		{Name: "kr_t_asf2_city", Connections: CrossroadConnections{A: "asf2", B: "asf2", C: "city"}},
+		{Name: "kr_t_asf1_city", Connections: CrossroadConnections{A: "asf1", B: "asf1", C: "City"}},
+		{Name: "kr_x_city_dirt", Connections: CrossroadConnections{A: "city", B: "city", C: "dirt", D: "dirt"}},
+	}
+
+	err := ValidateCrossroadRefs(crossroads, roadTypes)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("error is not joined: %T", err)
+	}
+	want := []CrossroadRefError{
+		{Crossroad: "kr_t_asf2_city", Side: "A", RoadType: "asf2"},
+		{Crossroad: "kr_t_asf2_city", Side: "B", RoadType: "asf2"},
+		{Crossroad: "kr_t_asf1_city", Side: "C", RoadType: "City", CaseMatch: "city"},
+		{Crossroad: "kr_x_city_dirt", Side: "C", RoadType: "dirt"},
+		{Crossroad: "kr_x_city_dirt", Side: "D", RoadType: "dirt"},
+	}
+	got := joined.Unwrap()
+	if len(got) != len(want) {
+		t.Fatalf("got %d errors want %d: %v", len(got), len(want), err)
+	}
+	for i := range want {
+		var ref CrossroadRefError
+		if !errors.As(got[i], &ref) || ref != want[i] {
+			t.Fatalf("error %d=%+v want %+v", i, got[i], want[i])
+		}
+	}
+	if !strings.Contains(err.Error(), `did you mean "city"?`) {
+		t.Fatalf("missing case hint: %v", err)
+	}
+}
+
+func TestRoadTypesWithoutDefault(t *testing.T) {
+	t.Parallel()
+
+	roadTypes := []RoadType{{Name: "asf1"}, {Name: "city"}, {Name: "dirt"}}
+	crossroads := []CrossroadType{
+		{Name: "kr_t_asf1_city", Default: "ASF1"},
+		{Name: "kr_x_city_city"},
+	}
+
+	got := RoadTypesWithoutDefault(roadTypes, crossroads)
+	if want := []string{"city", "dirt"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("missing=%v want %v", got, want)
+	}
+
+	crossroads[1].Default = "city"
+	crossroads = append(crossroads, CrossroadType{Name: "kr_t_dirt_dirt", Default: "dirt"})
+	if got := RoadTypesWithoutDefault(roadTypes, crossroads); len(got) != 0 {
+		t.Fatalf("missing=%v want none", got)
+	}
+}
+
+func TestRoadTypesWithCrossroads(t *testing.T) {
+	t.Parallel()
+
+	roadTypes := []RoadType{{Name: "asf1"}, {Name: "isolated"}, {Name: "city"}, {Name: "dirt"}}
+	crossroads := []CrossroadType{
+		{Name: "kr_t_asf1_city", Connections: CrossroadConnections{A: "ASF1", B: "asf1", C: "city"}},
+		{Name: "kr_t_dirt_dirt", ConnectionIndices: &CrossroadIndices{A: 3, B: 3, C: 3, D: -1}},
+	}
+
+	var got []string
+	for _, rt := range RoadTypesWithCrossroads(roadTypes, crossroads) {
+		got = append(got, rt.Name)
+	}
+	if want := []string{"asf1", "city"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("connected=%v want %v", got, want)
+	}
+
+	if got := RoadTypesWithCrossroads(roadTypes, nil); len(got) != 0 {
+		t.Fatalf("no crossroads: connected=%v want none", got)
+	}
+}
+
+func TestValidateUniqueIDs(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	data := buildTestFile(t, cfg, fixtureOptions{})
+	if err := ValidateUniqueIDs(cfg); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	// Copy-paste slip: the city straight part reuses the ID of the asf1 one.
+	cfg.Types[0].StraightParts[0].ID = 0x40
+	cfg.Types[1].StraightParts[0].ID = 0x40
+	err := ValidateUniqueIDs(cfg)
+	if err == nil || !strings.Contains(err.Error(), "duplicate id 0x40") ||
+		!strings.Contains(err.Error(), cfg.Types[0].StraightParts[0].Name) ||
+		!strings.Contains(err.Error(), cfg.Types[1].StraightParts[0].Name) {
+		t.Fatalf("err=%v want duplicate id 0x40 naming both parts", err)
+	}
+
+	if _, err := PatchRoadTool(data, cfg, ScopeAll); err == nil || !strings.Contains(err.Error(), "duplicate id") {
+		t.Fatalf("patch err=%v want duplicate id", err)
+	}
+	// Crossroads-only patches do not write road type IDs.
+	if _, err := PatchRoadTool(data, RoadConfig{Types: cfg.Types, CrossroadTypes: cfg.CrossroadTypes}, ScopeCrossroad); err != nil {
+		t.Fatalf("crossroad scope: %v", err)
+	}
+}
@@ -11,6 +11,16 @@ import (
 	"strings"
 )
 
+const (
+	// roadTypeIDStride is the observed step between road type entry IDs (0x88 entries).
+	roadTypeIDStride = uint32(0x48)
+	// crossroadDefIDStride is the observed step between crossroad def entry IDs (0x89 entries).
+	crossroadDefIDStride = uint32(0x178)
+)
+
+// maxStringLen is the largest string payload a 0x0B field can hold (u16 length).
+const maxStringLen = 0xFFFF
+
 // replacement represents a replacement operation in the tv4p file.
 type replacement struct {
 	blob  []byte // replacement bytes
@@ -31,15 +41,57 @@ func PatchRoadTypes(data []byte, cfg RoadConfig) ([]byte, error) {
 // PatchRoadTool rewrites parts of the Road Tool region according to scope.
 //
 // Scope behavior:
-// - roads: patch only 0x88 (road types), preserve crossroads
+// - roads: patch only 0x88 (road types), preserve crossroads (their road type indices follow the new order)
 // - crossroads: patch only 0x89 (crossroad defs) (and 0x8A only when raw link data is present), preserve road types
 // - all: patch roads and crossroads
 func PatchRoadTool(data []byte, cfg RoadConfig, scope Scope) ([]byte, error) {
-	block, err := ParseRoadTypes(data)
+	return PatchRoadToolWithOptions(data, cfg, PatchOptions{Scope: scope})
+}
+
+// scopedIDConfig keeps the parts of cfg whose IDs the scope writes.
+func scopedIDConfig(cfg RoadConfig, scope Scope) RoadConfig {
+	out := RoadConfig{}
+	if scope.IncludesRoads() {
+		out.Types = cfg.Types
+	}
+	if scope.IncludesCrossroads() {
+		out.CrossroadTypes = cfg.CrossroadTypes
+	}
+
+	return out
+}
+
+// PatchRoadToolWithOptions is PatchRoadTool with explicit options (see PatchOptions).
+func PatchRoadToolWithOptions(data []byte, cfg RoadConfig, opts PatchOptions) ([]byte, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	scope := opts.Scope
+
+	if err := ValidateUniqueIDs(scopedIDConfig(cfg, scope)); err != nil {
+		return nil, err
+	}
+
+	block, err := ParseRoadTypesWith(data, opts.Locate)
 	if err != nil {
 		return nil, err
 	}
 
+	if opts.UpdateOnly && scope.IncludesRoads() && len(cfg.Types) > 0 {
+		updated, err := UpdateRoadTypes(block.Types, cfg.Types)
+		if err != nil {
+			return nil, err
+		}
+		if !opts.Append {
+			cfg.Types = updated
+		}
+	}
+	if opts.Append && scope.IncludesRoads() && len(cfg.Types) > 0 {
+		existing := append([]RoadType(nil), block.Types...)
+		cfg = RoadConfig{Types: MergeRoadTypes(existing, cfg.Types, opts.MergeColor)}
+	}
+
 	existingIDs := collectEntryIDs(data)
 	repls := []replacement{}
 
@@ -60,9 +112,9 @@ func PatchRoadTool(data []byte, cfg RoadConfig, scope Scope) ([]byte, error) {
 		if len(cfg.Types) == len(block.Types) {
 			inheritExistingRoadTypeIDs(&cfg, block.Types)
 		}
-		applySequentialRoadTypeIDs(&cfg, block.Types, existingIDs)
+		applySequentialRoadTypeIDs(&cfg, block.Types, existingIDs, opts.RoadTypeIDStride, opts.IDBase)
 
-		roadTypeEntries, err := buildRoadTypesEntries(cfg, existingIDs)
+		roadTypeEntries, err := buildRoadTypesEntries(cfg, existingIDs, opts.IDBase)
 		if err != nil {
 			return nil, err
 		}
@@ -89,12 +141,25 @@ func PatchRoadTool(data []byte, cfg RoadConfig, scope Scope) ([]byte, error) {
 	crDefs, _ := findTaggedListAfter(data, afterRoadTypes, 0x89, validateCrossroadDefs)
 	crLinks, _ := findTaggedListAfter(data, afterRoadTypes, 0x8A, validateCrossroadLinks)
 
+	// Preserved crossroads reference road types by index (0x84..0x87): follow the new order.
+	preserveCrossroads := !scope.IncludesCrossroads() || cfg.CrossroadTypes == nil
+	if scope.IncludesRoads() && preserveCrossroads && crDefs.Found {
+		reindex, err := reindexPreservedCrossroads(crDefs.Entries, block.Types, cfg.Types)
+		if err != nil {
+			return nil, err
+		}
+		repls = append(repls, reindex...)
+	}
+
 	// Only touch crossroads when config explicitly contains the key
 	// (nil slice means "preserve whatever is in the file").
 	if scope.IncludesCrossroads() && cfg.CrossroadTypes != nil {
-		if !crDefs.Found || !crLinks.Found {
+		if !crDefs.Found {
 			return nil, errors.New("crossroad lists not found near Road Tool block")
 		}
+		if err := checkListCounts(crDefs, crLinks); err != nil {
+			return nil, err
+		}
 
 		// Crossroads-only patch: if road types are not provided in config, use the file's list
 		// for index mapping and validation.
@@ -108,8 +173,13 @@ func PatchRoadTool(data []byte, cfg RoadConfig, scope Scope) ([]byte, error) {
 
 		// TB Create fallback appears to use 0x89[roadTypeIndex] when variant selection is unreliable.
 		// We reorder defs for generated configs and/or when explicit defaults are present.
-		if shouldReorderCrossroads(cfg) {
-			reorderCrossroadsByRoadTypeIndex(&cfg)
+		switch {
+		case opts.CrossroadOrder == CrossroadOrderMatchRoads:
+			if err := orderCrossroadsMatchRoads(&cfg); err != nil {
+				return nil, err
+			}
+		case opts.CrossroadOrder != CrossroadOrderKeep && shouldReorderCrossroads(cfg):
+			reorderCrossroadsByRoadTypeIndex(&cfg, opts.PreferShape)
 		}
 
 		// If the config does not contain raw tv4p_link data, do NOT attempt to
@@ -124,9 +194,15 @@ func PatchRoadTool(data []byte, cfg RoadConfig, scope Scope) ([]byte, error) {
 				break
 			}
 		}
-		writeLinks := hasRawLink
+		writeLinks := hasRawLink || opts.SynthLinks
+
+		// Some files legitimately have 0x89 defs but no 0x8A list at all.
+		// Defs can still be patched alone as long as there is no link data to write.
+		if writeLinks && !crLinks.Found {
+			return nil, errors.New("crossroad links list (0x8A) not found: cannot write tv4p_link data")
+		}
 
-		crossDefsField, crossLinksField, err := buildCrossroadFields(cfg, existingIDs, writeLinks)
+		crossDefsField, crossLinksField, err := buildCrossroadFields(cfg, existingIDs, writeLinks, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -137,33 +213,21 @@ func PatchRoadTool(data []byte, cfg RoadConfig, scope Scope) ([]byte, error) {
 		delta89 = new89EntriesLen - old89EntriesLen
 		delta8A = 0
 
-		metaStart := crDefs.Start + crDefs.FieldLen
-		metaEnd := crLinks.Start
-		if metaStart < 0 || metaEnd < metaStart || metaEnd > len(data) {
-			return nil, errors.New("invalid crossroads meta range")
-		}
-
 		repls = append(repls,
 			replacement{start: crDefs.Start, end: crDefs.Start + crDefs.FieldLen, blob: crossDefsField},
 		)
 
 		if writeLinks {
+			metaStart := crDefs.Start + crDefs.FieldLen
+			metaEnd := crLinks.Start
+			if metaStart < 0 || metaEnd < metaStart || metaEnd > len(data) {
+				return nil, errors.New("invalid crossroads meta range")
+			}
+
 			// Rewrite meta + 0x8A only when we are writing back real instance state from TB.
-			new8AListLen := int(readU32(crossLinksField[3:]))
-			old8AEntriesLen := crLinks.ListLen - 4
-			new8AEntriesLen := new8AListLen - 4
-			delta8A = new8AEntriesLen - old8AEntriesLen
-
-			// There is a small metadata region between 0x89 and 0x8A in real files that contains
-			// at least one u32 offset-like field (tag 0x3F/type 0x0D). Its value changes when
-			// the 0x8A list payload size changes, so we must adjust it to keep the file consistent.
 			metaBytes := append([]byte(nil), data[metaStart:metaEnd]...)
-			if delta8A != 0 {
-				if err := adjustU32FieldInSlice(metaBytes, 0x3F, 0x0D, delta8A); err != nil {
-					return nil, err
-				}
-			}
-			if err := setMetaLinkIDTail(metaBytes, crossLinksField); err != nil {
+			delta8A, err = updateCrossroadMeta(metaBytes, crLinks.ListLen, crossLinksField)
+			if err != nil {
 				return nil, err
 			}
 
@@ -174,14 +238,18 @@ func PatchRoadTool(data []byte, cfg RoadConfig, scope Scope) ([]byte, error) {
 		}
 	}
 
+	if replsHook != nil {
+		repls = replsHook(repls)
+	}
+
 	// Apply replacements from end to start so earlier offsets remain valid.
 	sortReplsDesc(repls)
+	if err := checkRepls(repls, len(data)); err != nil {
+		return nil, err
+	}
 	out := data
 	totalDelta := 0
 	for _, r := range repls {
-		if r.start < 0 || r.end < r.start || r.end > len(out) {
-			return nil, errors.New("invalid replacement range")
-		}
 		oldLen := r.end - r.start
 		totalDelta += len(r.blob) - oldLen
 
@@ -192,16 +260,22 @@ func PatchRoadTool(data []byte, cfg RoadConfig, scope Scope) ([]byte, error) {
 		out = tmp
 	}
 
+	if block.Parent != nil {
+		if err := adjustNestedParent(out, data, block.Parent, repls); err != nil {
+			return nil, err
+		}
+	}
+
 	if totalDelta != 0 {
 		// Observed behavior (from real files):
 		// - tag 0x18/type 0x0D shifts by delta88 + delta89 + delta8A
 		// - tag 0x3E/type 0x0D shifts by delta88 + delta89
 		//
 		// (delta8A does not affect 0x3E)
-		if err := adjustOffsetsByTag(out, 0x18, 0x0D, delta88+delta89+delta8A); err != nil {
+		if err := adjustOffsetsByTag(out, 0x18, 0x0D, delta88+delta89+delta8A, block.Start); err != nil {
 			return nil, err
 		}
-		if err := adjustOffsetsByTag(out, 0x3E, 0x0D, delta88+delta89); err != nil {
+		if err := adjustOffsetsByTag(out, 0x3E, 0x0D, delta88+delta89, block.Start); err != nil {
 			return nil, err
 		}
 	}
@@ -223,7 +297,9 @@ func shouldReorderCrossroads(cfg RoadConfig) bool {
 
 	for i := range cfg.CrossroadTypes {
 		// If we have any raw data, preserve stable order from TB/extract.
-		if cfg.CrossroadTypes[i].TV4PDef != nil || cfg.CrossroadTypes[i].TV4PLink != nil {
+		// Raw connection indices cannot be matched to road types by name either.
+		cr := cfg.CrossroadTypes[i]
+		if cr.TV4PDef != nil || cr.TV4PLink != nil || cr.ConnectionIndices != nil {
 			continue
 		}
 		// At least one generated entry => reorder to make defaults stable.
@@ -233,21 +309,76 @@ func shouldReorderCrossroads(cfg RoadConfig) bool {
 	return false
 }
 
+// orderCrossroadsMatchRoads orders crossroads so that 0x89[i] is the crossroad whose
+// default is road_types[i] (case-insensitive), followed by the other crossroads in
+// config order. A road type without a default gets a placeholder to keep later slots
+// aligned: the first unplaced crossroad connected to it, else the first unplaced one.
+// Trailing road types without a default and without crossroads left get no slot; a gap
+// that cannot be filled before a placed default is an error.
+func orderCrossroadsMatchRoads(cfg *RoadConfig) error {
+	used := make([]bool, len(cfg.CrossroadTypes))
+	slots := make([]int, len(cfg.Types))
+	last := -1
+	for i, rt := range cfg.Types {
+		slots[i] = -1
+		want := strings.TrimSpace(rt.Name)
+		for j, cr := range cfg.CrossroadTypes {
+			if !used[j] && want != "" && strings.EqualFold(strings.TrimSpace(cr.Default), want) {
+				slots[i] = j
+				used[j] = true
+				last = i
+				break
+			}
+		}
+	}
+
+	for i := 0; i <= last; i++ {
+		if slots[i] >= 0 {
+			continue
+		}
+
+		filler := -1
+		for j, cr := range cfg.CrossroadTypes {
+			if used[j] {
+				continue
+			}
+			if crossroadHasRoadType(cr, strings.TrimSpace(cfg.Types[i].Name)) {
+				filler = j
+				break
+			}
+			if filler < 0 {
+				filler = j
+			}
+		}
+		if filler < 0 {
+			return fmt.Errorf("crossroad order match-roads: road type %q has no default crossroad and no other crossroad is left to keep later defaults aligned", cfg.Types[i].Name)
+		}
+		slots[i] = filler
+		used[filler] = true
+	}
+
+	ordered := make([]CrossroadType, 0, len(cfg.CrossroadTypes))
+	for i := 0; i <= last; i++ {
+		ordered = append(ordered, cfg.CrossroadTypes[slots[i]])
+	}
+	for j, cr := range cfg.CrossroadTypes {
+		if !used[j] {
+			ordered = append(ordered, cr)
+		}
+	}
+	cfg.CrossroadTypes = ordered
+
+	return nil
+}
+
 // reorderCrossroadsByRoadTypeIndex reorders crossroads by road type index.
-func reorderCrossroadsByRoadTypeIndex(cfg *RoadConfig) {
+func reorderCrossroadsByRoadTypeIndex(cfg *RoadConfig, prefer CrossroadShape) {
 	if cfg == nil || len(cfg.Types) == 0 || len(cfg.CrossroadTypes) == 0 {
 		return
 	}
 
 	shapeScore := func(cr CrossroadType) int {
-		// Prefer T over X (arbitrary but stable).
-		if strings.HasPrefix(cr.Name, "kr_t_") {
-			return 2
-		}
-		if strings.HasPrefix(cr.Name, "kr_x_") {
-			return 1
-		}
-		return 0
+		return CrossroadShapeScore(cr, prefer)
 	}
 
 	matchScore := func(cr CrossroadType, want string) int {
@@ -324,6 +455,58 @@ func reorderCrossroadsByRoadTypeIndex(cfg *RoadConfig) {
 	}
 }
 
+// reindexPreservedCrossroads returns in-place replacements that rewrite the road type
+// indices (0x84..0x87) of crossroad defs kept from the file when road types are matched
+// by name (case-insensitive) at another position. A def referencing a road type that
+// is not in the new list is an error, since its connection would silently change.
+func reindexPreservedCrossroads(defs []Entry, oldTypes []RoadType, newTypes []RoadType) ([]replacement, error) {
+	newIdx := map[string]int{}
+	for i, rt := range newTypes {
+		key := strings.ToLower(rt.Name)
+		if _, ok := newIdx[key]; !ok {
+			newIdx[key] = i
+		}
+	}
+
+	// remap: old road type index -> new index (-1 = removed).
+	remap := make([]int, len(oldTypes))
+	for i, rt := range oldTypes {
+		j, ok := newIdx[strings.ToLower(rt.Name)]
+		if !ok {
+			j = -1
+		}
+		remap[i] = j
+	}
+
+	var repls []replacement
+	for _, e := range defs {
+		for _, f := range e.Fields {
+			if f.Tag < 0x84 || f.Tag > 0x87 || (f.Type != 0x05 && f.Type != 0x0D) || len(f.Raw) != 4 {
+				continue
+			}
+			v := readU32(f.Raw)
+			if v == 0xFFFFFFFF || uint64(v) >= uint64(len(remap)) {
+				continue
+			}
+
+			j := remap[v]
+			if j < 0 {
+				return nil, fmt.Errorf("crossroad %q: side %c references road type %q which is not in the config (patch crossroads too or remove the road type)",
+					entryString(e, 0x33), 'A'+rune(f.Tag-0x84), oldTypes[v].Name)
+			}
+			if uint32(j) == v {
+				continue
+			}
+
+			blob := make([]byte, 4)
+			writeU32(blob, uint32(j))
+			repls = append(repls, replacement{start: f.Offset, end: f.Offset + 4, blob: blob})
+		}
+	}
+
+	return repls, nil
+}
+
 // inheritExistingRoadTypeIDs inherits existing road type IDs from the existing types.
 func inheritExistingRoadTypeIDs(cfg *RoadConfig, existingTypes []RoadType) {
 	byName := map[string]RoadType{}
@@ -390,8 +573,12 @@ func inheritPartListIDs(dst []RoadPart, src []RoadPart) {
 }
 
 // applySequentialRoadTypeIDs applies sequential road type IDs to the configuration.
-func applySequentialRoadTypeIDs(cfg *RoadConfig, existingTypes []RoadType, existingIDs map[uint32]struct{}) {
-	const stride = uint32(0x48)
+// New IDs start after the largest road type ID, or at base (aligned up) when non-zero.
+// They are spaced by stride (0: roadTypeIDStride).
+func applySequentialRoadTypeIDs(cfg *RoadConfig, existingTypes []RoadType, existingIDs map[uint32]struct{}, stride uint32, base uint32) {
+	if stride == 0 {
+		stride = roadTypeIDStride
+	}
 
 	// Determine the per-file remainder and current max ID from the existing file.
 	var rem uint32
@@ -430,8 +617,11 @@ func applySequentialRoadTypeIDs(cfg *RoadConfig, existingTypes []RoadType, exist
 		rem = 0x0C
 	}
 
-	// Allocate new IDs starting after the max ID, keeping the remainder.
+	// Allocate new IDs starting after the max ID (or at base), keeping the remainder.
 	next := maxID + stride
+	if base != 0 {
+		next = base
+	}
 	off := next % stride
 	if off != rem {
 		if off < rem {
@@ -469,27 +659,83 @@ func adjustU32FieldInSlice(b []byte, tag byte, typ byte, delta int) error {
 		return nil
 	}
 
+	pos, err := findU32FieldInSlice(b, tag, typ)
+	if err != nil {
+		return err
+	}
+
+	return addU32(b[pos+3:], delta)
+}
+
+// copyU32FieldInSlice sets the u32 field in dst to its value in src plus delta.
+func copyU32FieldInSlice(dst, src []byte, tag byte, typ byte, delta int) error {
+	srcPos, err := findU32FieldInSlice(src, tag, typ)
+	if err != nil {
+		return err
+	}
+	dstPos, err := findU32FieldInSlice(dst, tag, typ)
+	if err != nil {
+		return err
+	}
+
+	copy(dst[dstPos+3:dstPos+7], src[srcPos+3:srcPos+7])
+	return addU32(dst[dstPos+3:], delta)
+}
+
+// findU32FieldInSlice returns the position of the only u32 field with the given tag
+// and type in a slice.
+func findU32FieldInSlice(b []byte, tag byte, typ byte) (int, error) {
 	pat := []byte{tag, 0x00, typ}
 	pos := bytes.Index(b, pat)
 	if pos < 0 || pos+7 > len(b) {
-		return errors.New("u32 field not found for adjustment")
+		return 0, errors.New("u32 field not found for adjustment")
 	}
 
 	// Make sure it's unique within this slice (sanity).
 	if bytes.Contains(b[pos+1:], pat) {
-		return errors.New("u32 field ambiguous for adjustment")
+		return 0, errors.New("u32 field ambiguous for adjustment")
 	}
 
-	cur := int(readU32(b[pos+3:]))
+	return pos, nil
+}
+
+// addU32 adds delta to the u32 at the start of b, clamping at zero.
+func addU32(b []byte, delta int) error {
+	cur := int(readU32(b))
 	cur += delta
 	if cur < 0 {
 		cur = 0
 	}
 
-	return writeU32FromInt(b[pos+3:], cur)
+	return writeU32FromInt(b, cur)
+}
+
+// updateCrossroadMeta updates the metadata region between 0x89 and 0x8A for a new
+// 0x8A field and returns the 0x8A payload size delta.
+//
+// The meta holds at least one u32 offset-like field (tag 0x3F/type 0x0D) that shifts
+// with the 0x8A payload size, and a 0x19/0x20 field with the upper bytes of the first
+// link entry ID. Both are derived from the built field bytes only, so this works the
+// same for link entries written back from tv4p_link and for synthesized ones.
+func updateCrossroadMeta(meta []byte, oldLinksListLen int, crossLinksField []byte) (int, error) {
+	if len(crossLinksField) < 11 {
+		return 0, errors.New("invalid 0x8A field bytes")
+	}
+
+	newLinksListLen := int(readU32(crossLinksField[3:]))
+	delta := (newLinksListLen - 4) - (oldLinksListLen - 4)
+	if err := adjustU32FieldInSlice(meta, 0x3F, 0x0D, delta); err != nil {
+		return 0, fmt.Errorf("crossroads meta: %w", err)
+	}
+	if err := setMetaLinkIDTail(meta, crossLinksField); err != nil {
+		return 0, err
+	}
+
+	return delta, nil
 }
 
-// setMetaLinkIDTail sets the link ID tail in the meta.
+// setMetaLinkIDTail sets the 0x19/0x20 meta field to the upper 3 bytes of the first
+// 0x8A entry ID. An empty 0x8A list keeps the meta as is (matches empty-list files).
 func setMetaLinkIDTail(meta []byte, crossLinksField []byte) error {
 	// Find 19 00 20 within meta.
 	pat := []byte{0x19, 0x00, 0x20}
@@ -502,42 +748,47 @@ func setMetaLinkIDTail(meta []byte, crossLinksField []byte) error {
 		return errors.New("crossroads meta 0x19/0x20 field ambiguous")
 	}
 
-	// crossLinksField is: 8A 00 0C <u32 listLen> <u32 count> <entries...>
-	if len(crossLinksField) < 11 {
-		return errors.New("invalid 0x8A field bytes")
-	}
-	count := int(readU32(crossLinksField[7:]))
-	if count <= 0 {
-		// No entries: keep whatever is already in meta (matches empty-list files).
-		return nil
+	id, ok, err := firstListEntryID(crossLinksField)
+	if err != nil || !ok {
+		return err
 	}
 
-	entriesStart := 11
-	if len(crossLinksField) < entriesStart+7+6 {
-		return errors.New("invalid 0x8A entries payload")
+	// meta expects the upper 3 bytes of the entry ID (skip the low byte).
+	meta[pos+3] = byte(id >> 8)
+	meta[pos+4] = byte(id >> 16)
+	meta[pos+5] = byte(id >> 24)
+	return nil
+}
+
+// firstListEntryID returns the ID of the first entry of a list field
+// (tag 00 0C <u32 listLen> <u32 count> <entries...>); ok is false for an empty list.
+func firstListEntryID(listField []byte) (uint32, bool, error) {
+	if len(listField) < 11 {
+		return 0, false, errors.New("invalid list field bytes")
+	}
+	if readU32(listField[7:]) == 0 {
+		return 0, false, nil
 	}
 
 	// First entry is 06 00 0D <u32 bodyLen> <u16 type> <u32 id> ...
-	if crossLinksField[entriesStart] != 0x06 || crossLinksField[entriesStart+1] != 0x00 || crossLinksField[entriesStart+2] != 0x0D {
-		return errors.New("invalid 0x8A first entry header")
+	const entriesStart = 11
+	if len(listField) < entriesStart+7+6 {
+		return 0, false, fmt.Errorf("list 0x%02X: invalid entries payload", listField[0])
+	}
+	if listField[entriesStart] != 0x06 || listField[entriesStart+1] != 0x00 || listField[entriesStart+2] != 0x0D {
+		return 0, false, fmt.Errorf("list 0x%02X: invalid first entry header", listField[0])
 	}
-	bodyLen := int(readU32(crossLinksField[entriesStart+3:]))
 	bodyStart := entriesStart + 7
-	if bodyLen < 6 || len(crossLinksField) < bodyStart+6 {
-		return errors.New("invalid 0x8A first entry body")
+	if readU32(listField[entriesStart+3:]) < 6 {
+		return 0, false, fmt.Errorf("list 0x%02X: invalid first entry body", listField[0])
 	}
-	// typeID := readU16(crossLinksField[bodyStart:]) // not used, expected 0x1A
-	idBytes := crossLinksField[bodyStart+2 : bodyStart+6]
-	// meta expects the upper 3 bytes of the entry ID (skip the low byte).
-	meta[pos+3] = idBytes[1]
-	meta[pos+4] = idBytes[2]
-	meta[pos+5] = idBytes[3]
-	return nil
+
+	return readU32(listField[bodyStart+2:]), true, nil
 }
 
 // buildRoadTypesEntries builds the road types list entries from the configuration.
-func buildRoadTypesEntries(cfg RoadConfig, existingIDs map[uint32]struct{}) ([][]byte, error) {
-	alloc := newIDAllocator(cfg, existingIDs)
+func buildRoadTypesEntries(cfg RoadConfig, existingIDs map[uint32]struct{}, idBase uint32) ([][]byte, error) {
+	alloc := newIDAllocator(cfg, existingIDs, idBase)
 	var entries [][]byte
 	for _, rt := range cfg.Types {
 		entry, err := buildRoadTypeEntry(rt, alloc)
@@ -553,55 +804,64 @@ func buildRoadTypesEntries(cfg RoadConfig, existingIDs map[uint32]struct{}) ([][
 
 // buildRoadTypeEntry builds a single road type entry from the configuration.
 func buildRoadTypeEntry(rt RoadType, alloc *idAllocator) ([]byte, error) {
+	// Full raw entry from extract --emit-raw: lossless, modeled fields are ignored.
+	if rt.TV4PRaw != nil && rt.TV4PRaw.Type == 0x12 {
+		entry, err := rawEntryToBytes(*rt.TV4PRaw, alloc, "rt|"+strings.ToLower(rt.Name))
+		if err != nil {
+			return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
+		}
+		return entry, nil
+	}
+
 	var fields [][]byte
 	nameField, err := fieldString(0x33, rt.Name)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
 	}
 
 	fields = append(fields, nameField)
 	fields = append(fields, fieldByte(0x71, boolByte(rt.KeyCustom)))
 	fields = append(fields, fieldByte(0x72, boolByte(rt.NormalCustom)))
-	fields = append(fields, fieldColor(0x73, rt.NormalColor, rt.NormalCustom))
-	fields = append(fields, fieldColor(0x74, rt.KeyColor, rt.KeyCustom))
-	fields = append(fields, fieldByte(0x75, 0))
-	fields = append(fields, fieldBytes(0x76, make([]byte, 8)))
-	fields = append(fields, fieldBytes(0x77, make([]byte, 8)))
-	straight, err := buildPartsList(rt.StraightParts, 0x13, true, alloc)
+	normalField, err := fieldColorRaw(0x73, rt.NormalColor, rt.NormalCustom, rt.TV4PNormalColor)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
 	}
-
-	straightField, err := fieldList(0x78, straight)
+	keyField, err := fieldColorRaw(0x74, rt.KeyColor, rt.KeyCustom, rt.TV4PKeyColor)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
 	}
-	fields = append(fields, straightField)
-	corners, err := buildPartsList(rt.CornerParts, 0x14, false, alloc)
+
+	fields = append(fields, normalField, keyField)
+	for _, tag := range []byte{0x75, 0x76, 0x77} {
+		extra, err := roadTypeExtraField(rt, tag, alloc)
+		if err != nil {
+			return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
+		}
+		fields = append(fields, extra)
+	}
+	// The part lists are written even when empty: a road type without parts has no
+	// model path, and ParseRoadTypes then recognizes it by these tags (entryHasRoadLists).
+	straightField, err := roadTypePartsField(rt, 0x78, rt.StraightParts, 0x13, true, alloc)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
 	}
+	fields = append(fields, straightField)
 
-	cornerField, err := fieldList(0x79, corners)
+	cornerField, err := roadTypePartsField(rt, 0x79, rt.CornerParts, 0x14, false, alloc)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
 	}
 
 	fields = append(fields, cornerField)
 	emptyField, err := fieldList(0x7A, nil)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
 	}
 
 	fields = append(fields, emptyField)
-	terminators, err := buildPartsList(rt.TerminatorPart, 0x16, false, alloc)
+	terminatorField, err := roadTypePartsField(rt, 0x7B, rt.TerminatorPart, 0x16, false, alloc)
 	if err != nil {
-		return nil, err
-	}
-
-	terminatorField, err := fieldList(0x7B, terminators)
-	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
 	}
 
 	fields = append(fields, terminatorField)
@@ -612,7 +872,52 @@ func buildRoadTypeEntry(rt RoadType, alloc *idAllocator) ([]byte, error) {
 	}
 	entryID := alloc.useOrDeterministic(rt.ID, "rt|"+strings.ToLower(rt.Name))
 
-	return buildEntry(entryType, entryID, fields)
+	entry, err := buildEntry(entryType, entryID, fields)
+	if err != nil {
+		return nil, fmt.Errorf("road type %q: %w", rt.Name, err)
+	}
+
+	return entry, nil
+}
+
+// roadTypeExtraField returns the preserved raw field for tag, or the default encoding.
+func roadTypeExtraField(rt RoadType, tag byte, alloc *idAllocator) ([]byte, error) {
+	for _, f := range rt.TV4PExtra {
+		if f.Tag == tag {
+			return rawFieldToBytes(f, alloc, "rtextra|"+strings.ToLower(rt.Name))
+		}
+	}
+
+	return defaultRoadTypeExtra(tag), nil
+}
+
+// roadTypePartsField builds a part list field of a road type, or writes the
+// preserved raw list from TV4PExtra (PreservePartLists) verbatim.
+func roadTypePartsField(rt RoadType, tag byte, parts []RoadPart, defaultType uint16, includeFlag bool, alloc *idAllocator) ([]byte, error) {
+	for _, f := range rt.TV4PExtra {
+		if f.Tag == tag && f.Type == 0x0C {
+			return rawFieldToBytes(f, alloc, "rtlist|"+strings.ToLower(rt.Name))
+		}
+	}
+
+	entries, err := buildPartsList(parts, defaultType, includeFlag, alloc)
+	if err != nil {
+		return nil, err
+	}
+
+	return fieldList(tag, entries)
+}
+
+// defaultRoadTypeExtra returns the default encoding of unmodeled road type fields.
+func defaultRoadTypeExtra(tag byte) []byte {
+	switch tag {
+	case 0x75:
+		return fieldByte(0x75, 0)
+	case 0x76, 0x77:
+		return fieldBytes(tag, make([]byte, 8))
+	default:
+		return nil
+	}
 }
 
 // buildPartsList builds the parts list from the configuration.
@@ -622,17 +927,17 @@ func buildPartsList(parts []RoadPart, defaultType uint16, includeFlag bool, allo
 		var fields [][]byte
 		nameField, err := fieldString(0x33, p.Name)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("part %q: %w", p.Name, err)
 		}
 
 		pathField, err := fieldString(0x7C, p.Path)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("part %q: %w", p.Name, err)
 		}
 
 		fields = append(fields, nameField, pathField)
 		if includeFlag {
-			fields = append(fields, fieldByte(0x7D, 0))
+			fields = append(fields, fieldByte(0x7D, p.TV4PFlag))
 		}
 
 		fields = append(fields, fieldBytes(0x7E, make([]byte, 8)))
@@ -645,7 +950,7 @@ func buildPartsList(parts []RoadPart, defaultType uint16, includeFlag bool, allo
 		entryID := alloc.useOrDeterministic(p.ID, seed)
 		entry, err := buildEntry(typ, entryID, fields)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("part %q: %w", p.Name, err)
 		}
 
 		entries = append(entries, entry)
@@ -672,7 +977,7 @@ func buildEntry(typeID uint16, id uint32, fields [][]byte) ([]byte, error) {
 	out = append(out, 0x06, 0x00, 0x0D)
 
 	if err := writeU32FromInt(tmp4, len(body)); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("entry type 0x%X: body size %d exceeds uint32: %w", typeID, len(body), err)
 	}
 
 	out = append(out, tmp4...)
@@ -702,8 +1007,9 @@ type idAllocator struct {
 	next uint32
 }
 
-// newIDAllocator creates a new ID allocator.
-func newIDAllocator(cfg RoadConfig, existing map[uint32]struct{}) *idAllocator {
+// newIDAllocator creates a new ID allocator. New IDs start after the largest used ID,
+// or at base when non-zero.
+func newIDAllocator(cfg RoadConfig, existing map[uint32]struct{}, base uint32) *idAllocator {
 	used := map[uint32]struct{}{}
 	var maxUsed uint32
 	for id := range existing {
@@ -752,6 +1058,9 @@ func newIDAllocator(cfg RoadConfig, existing map[uint32]struct{}) *idAllocator {
 	// For generated configs, deterministic hash-based IDs can confuse TB's internal lookup.
 	// We therefore allocate new IDs sequentially from the current file's ID space.
 	next := maxUsed + 1
+	if base != 0 {
+		next = base
+	}
 	// Keep basic alignment (most observed IDs are at least 4-byte aligned).
 	if rem := next % 4; rem != 0 {
 		next += 4 - rem
@@ -809,12 +1118,22 @@ func buildPartSeed(typ uint16, name string, path string) string {
 	return b.String()
 }
 
+// entryHeader is the tag/type prefix of every entry (`06 00 0D <u32 bodyLen>`).
+var entryHeader = []byte{0x06, 0x00, 0x0D}
+
 // collectEntryIDs collects the IDs of all entries in the data.
+// Any byte run that looks like an entry header with an in-range body counts.
 func collectEntryIDs(data []byte) map[uint32]struct{} {
 	used := map[uint32]struct{}{}
-	for i := 0; i+7 < len(data); i++ {
-		if data[i] != 0x06 || data[i+1] != 0x00 || data[i+2] != 0x0D {
-			continue
+	for i := 0; ; i++ {
+		// Jump to the next candidate header instead of testing every byte.
+		idx := bytes.Index(data[i:], entryHeader)
+		if idx < 0 {
+			break
+		}
+		i += idx
+		if i+7 >= len(data) {
+			break
 		}
 
 		bodyLen := int(readU32(data[i+3:]))
@@ -837,8 +1156,18 @@ func collectEntryIDs(data []byte) map[uint32]struct{} {
 	return used
 }
 
-// adjustOffsetsByTag adjusts the offsets of all entries with the given tag and type.
-func adjustOffsetsByTag(data []byte, tag byte, typ byte, delta int) error {
+// offsetTagWindow bounds how far before the Road Tool block (0x88) an offset field
+// is looked up when its tag pattern occurs more than once in the file.
+const offsetTagWindow = 64 << 10
+
+// adjustOffsetsByTag adjusts the u32 offset field with the given tag and type by delta.
+//
+// Only an occurrence whose current value points at or past blockStart (the 0x88 list)
+// is a candidate: an offset before the block does not move with its size. A file-wide
+// unique occurrence is used as is. Larger projects may contain the pattern more than
+// once, so otherwise the candidate closest before blockStart within offsetTagWindow is
+// used, and unrelated occurrences elsewhere are left alone.
+func adjustOffsetsByTag(data []byte, tag byte, typ byte, delta int, blockStart int) error {
 	if delta == 0 {
 		return nil
 	}
@@ -848,31 +1177,36 @@ func adjustOffsetsByTag(data []byte, tag byte, typ byte, delta int) error {
 	}
 
 	pattern := []byte{tag, 0x00, typ}
-	off := 0
-	count := 0
-	for {
+	var found []int
+	for off := 0; ; {
 		idx := bytes.Index(data[off:], pattern)
-		if idx < 0 {
+		if idx < 0 || off+idx+7 > len(data) {
 			break
 		}
-
-		pos := off + idx
-		if pos+7 <= len(data) {
-			count++
-			off = pos + 1
-			continue
-		}
-
-		break
+		found = append(found, off+idx)
+		off += idx + 1
 	}
 
-	if count != 1 {
-		return errors.New("offset tag count mismatch")
+	pointsAtBlock := func(p int) bool {
+		return int(readU32(data[p+3:])) >= blockStart
 	}
 
-	pos := bytes.Index(data, pattern)
-	if pos < 0 || pos+7 > len(data) {
-		return errors.New("offset tag not found")
+	pos := -1
+	switch {
+	case len(found) == 1:
+		if pointsAtBlock(found[0]) {
+			pos = found[0]
+		}
+	case len(found) > 1:
+		for _, p := range found {
+			if p < blockStart && blockStart-p <= offsetTagWindow && pointsAtBlock(p) {
+				pos = p
+			}
+		}
+	}
+	if pos < 0 {
+		return fmt.Errorf("offset tag 0x%02X/0x%02X: %d occurrence(s), none unique or within 0x%X bytes before the Road Tool block and pointing at it",
+			tag, typ, len(found), offsetTagWindow)
 	}
 
 	valPos := pos + 3
@@ -891,6 +1225,10 @@ func adjustOffsetsByTag(data []byte, tag byte, typ byte, delta int) error {
 // fieldString builds a string field from the configuration.
 func fieldString(tag byte, s string) ([]byte, error) {
 	b := []byte(s)
+	if len(b) > maxStringLen {
+		return nil, fmt.Errorf("string field 0x%02X: length %d exceeds %d bytes", tag, len(b), maxStringLen)
+	}
+
 	out := make([]byte, 0, len(b)+5)
 	out = append(out, tag, 0x00, 0x0B)
 	tmp := make([]byte, 2)
@@ -920,6 +1258,23 @@ func fieldColor(tag byte, c Color, custom bool) []byte {
 	return []byte{tag, 0x00, 0x08, c.R, c.G, c.B, 0xFF}
 }
 
+// fieldColorRaw builds a color field, preferring the original raw bytes for non-custom colors.
+func fieldColorRaw(tag byte, c Color, custom bool, raw string) ([]byte, error) {
+	if custom || strings.TrimSpace(raw) == "" {
+		return fieldColor(tag, c, custom), nil
+	}
+
+	b, err := decodeHex(raw)
+	if err != nil {
+		return nil, fmt.Errorf("color 0x%02X: %w", tag, err)
+	}
+	if len(b) != 4 {
+		return nil, fmt.Errorf("color 0x%02X: raw must be 4 bytes, got %d", tag, len(b))
+	}
+
+	return []byte{tag, 0x00, 0x08, b[0], b[1], b[2], b[3]}, nil
+}
+
 // fieldBytes builds a bytes field from the configuration.
 func fieldBytes(tag byte, b []byte) []byte {
 	out := make([]byte, 0, len(b)+3)
@@ -937,12 +1292,12 @@ func fieldList(tag byte, entries [][]byte) ([]byte, error) {
 	out = append(out, tag, 0x00, 0x0C)
 	tmp := make([]byte, 4)
 	if err := writeU32FromInt(tmp, listLen); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("list 0x%02X: size %d exceeds uint32: %w", tag, listLen, err)
 	}
 
 	out = append(out, tmp...)
 	if err := writeU32FromInt(tmp, len(entries)); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("list 0x%02X: count %d exceeds uint32: %w", tag, len(entries), err)
 	}
 
 	out = append(out, tmp...)
@@ -951,6 +1306,28 @@ func fieldList(tag byte, entries [][]byte) ([]byte, error) {
 	return out, nil
 }
 
+// replsHook, when set by tests, edits the replacements before they are checked and applied.
+var replsHook func([]replacement) []replacement
+
+// checkRepls verifies that replacements sorted by sortReplsDesc lie within size bytes and
+// do not overlap. Two replacements starting at the same offset are rejected too, as
+// their order (e.g. an insertion and a rewrite) would be ambiguous.
+func checkRepls(repls []replacement, size int) error {
+	for i, r := range repls {
+		if r.start < 0 || r.end < r.start || r.end > size {
+			return fmt.Errorf("invalid replacement range [0x%X, 0x%X) in %d bytes", r.start, r.end, size)
+		}
+		if i == 0 {
+			continue
+		}
+		if prev := repls[i-1]; r.end > prev.start || r.start == prev.start {
+			return fmt.Errorf("overlapping replacement ranges [0x%X, 0x%X) and [0x%X, 0x%X)", r.start, r.end, prev.start, prev.end)
+		}
+	}
+
+	return nil
+}
+
 // sortReplsDesc sorts replacements by start index in descending order.
 func sortReplsDesc(repls []replacement) {
 	for i := 0; i < len(repls); i++ {
@@ -963,8 +1340,8 @@ func sortReplsDesc(repls []replacement) {
 }
 
 // buildCrossroadFields builds the crossroad fields from the configuration.
-func buildCrossroadFields(cfg RoadConfig, existingIDs map[uint32]struct{}, includeLinks bool) ([]byte, []byte, error) {
-	alloc := newIDAllocator(cfg, existingIDs)
+func buildCrossroadFields(cfg RoadConfig, existingIDs map[uint32]struct{}, includeLinks bool, opts PatchOptions) ([]byte, []byte, error) {
+	alloc := newIDAllocator(cfg, existingIDs, opts.IDBase)
 
 	nameToIdx := map[string]uint32{}
 	for idx := uint32(0); uint64(idx) < uint64(len(cfg.Types)); idx++ {
@@ -972,10 +1349,14 @@ func buildCrossroadFields(cfg RoadConfig, existingIDs map[uint32]struct{}, inclu
 		nameToIdx[cfg.Types[i].Name] = idx
 	}
 
+	if err := validateConnectionIndices(cfg.CrossroadTypes, len(cfg.Types)); err != nil {
+		return nil, nil, err
+	}
+
 	// Allocate crossroad definition IDs in a TB-like pattern.
 	// In TB-made files crossroad def entry IDs (TypeID 0x17) often increment by 0x178.
 	// Random-looking IDs appear to confuse TB when selecting a specific crossroad variant.
-	defIDs := allocateCrossroadDefIDs(cfg.CrossroadTypes, alloc)
+	defIDs := allocateCrossroadDefIDs(cfg.CrossroadTypes, alloc, opts.CrossroadDefIDStride)
 
 	// Build 0x89 entries
 	var defEntries [][]byte
@@ -989,7 +1370,7 @@ func buildCrossroadFields(cfg RoadConfig, existingIDs map[uint32]struct{}, inclu
 
 	defField, err := fieldList(0x89, defEntries)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, fmt.Errorf("crossroad defs: %w", err)
 	}
 
 	if !includeLinks {
@@ -1003,7 +1384,16 @@ func buildCrossroadFields(cfg RoadConfig, existingIDs map[uint32]struct{}, inclu
 	// Example: `utesplus_cross2.tv4p` has 2 entries in `0x89`, but still only 1 entry in `0x8A`.
 	// This list appears to be editor state / metadata, not per-crossroad definition.
 	var linkEntries [][]byte
-	{
+	if opts.SynthLinks {
+		// One entry per crossroad: raw link entries verbatim, the others synthesized.
+		for _, cr := range cfg.CrossroadTypes {
+			e, err := buildCrossroadLinkEntry(cr, alloc, cfg.Types)
+			if err != nil {
+				return nil, nil, err
+			}
+			linkEntries = append(linkEntries, e)
+		}
+	} else {
 		// If we have any raw link entry from extract, write back one verbatim (TB state).
 		var picked *CrossroadType
 		for i := range cfg.CrossroadTypes {
@@ -1023,7 +1413,7 @@ func buildCrossroadFields(cfg RoadConfig, existingIDs map[uint32]struct{}, inclu
 
 	linkField, err := fieldList(0x8A, linkEntries)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, fmt.Errorf("crossroad links: %w", err)
 	}
 
 	return defField, linkField, nil
@@ -1035,15 +1425,20 @@ func buildCrossroadDefEntry(cr CrossroadType, alloc *idAllocator, nameToIdx map[
 
 	// If we have a raw entry from extract, write it back verbatim.
 	// This is the safest option and enables true round-trip.
+	// Raw connection indices, when given, replace the 0x84..0x87 fields.
 	if cr.TV4PDef != nil && cr.TV4PDef.Type == 0x17 {
-		return rawEntryToBytes(*cr.TV4PDef, alloc, seed)
+		def := *cr.TV4PDef
+		if cr.ConnectionIndices != nil {
+			def = withConnectionIndices(def, *cr.ConnectionIndices)
+		}
+		entry, err := rawEntryToBytes(def, alloc, seed)
+		if err != nil {
+			return nil, fmt.Errorf("crossroad %q: %w", cr.Name, err)
+		}
+		return entry, nil
 	}
 
-	// Shape enum (observed): 2 for T, 3 for X.
-	shapeU32 := uint32(2)
-	if strings.HasPrefix(cr.Name, "kr_x_") {
-		shapeU32 = 3
-	}
+	shapeU32 := crossroadShapeEnum(cr)
 
 	a := uint32(0xFFFFFFFF)
 	b := uint32(0xFFFFFFFF)
@@ -1079,6 +1474,15 @@ func buildCrossroadDefEntry(cr CrossroadType, alloc *idAllocator, nameToIdx map[
 		return nil, fmt.Errorf("crossroad %q: unknown road type for D: %q", cr.Name, cr.Connections.D)
 	}
 
+	if ci := cr.ConnectionIndices; ci != nil {
+		a, b, c, d = indexU32(ci.A), indexU32(ci.B), indexU32(ci.C), indexU32(ci.D)
+	}
+
+	blobs, err := crossroadBlobFields(cr.TV4PBlobs)
+	if err != nil {
+		return nil, fmt.Errorf("crossroad %q: %w", cr.Name, err)
+	}
+
 	raw := EntryRaw{
 		Type: 0x17,
 		ID:   forcedID,
@@ -1103,9 +1507,9 @@ func buildCrossroadDefEntry(cr CrossroadType, alloc *idAllocator, nameToIdx map[
 				return "0000ff00"
 			}()},
 			{Tag: 0x75, Type: 0x09, Raw: "00"},
-			{Tag: 0x80, Type: 0x14, Raw: "0000000000000000"},
-			{Tag: 0x81, Type: 0x14, Raw: "0000000000000000"},
-			{Tag: 0x82, Type: 0x14, Raw: "0000000000000000"},
+			{Tag: 0x80, Type: 0x14, Raw: blobs[0]},
+			{Tag: 0x81, Type: 0x14, Raw: blobs[1]},
+			{Tag: 0x82, Type: 0x14, Raw: blobs[2]},
 			{Tag: 0x83, Type: 0x09, Raw: "00"},
 			{Tag: 0x84, Type: 0x05, Raw: u32Hex(a)},
 			{Tag: 0x85, Type: 0x05, Raw: u32Hex(b)},
@@ -1114,19 +1518,92 @@ func buildCrossroadDefEntry(cr CrossroadType, alloc *idAllocator, nameToIdx map[
 		},
 	}
 
-	return rawEntryToBytes(raw, alloc, seed)
+	entry, err := rawEntryToBytes(raw, alloc, seed)
+	if err != nil {
+		return nil, fmt.Errorf("crossroad %q: %w", cr.Name, err)
+	}
+
+	return entry, nil
 }
 
-// allocateCrossroadDefIDs allocates crossroad definition IDs.
-func allocateCrossroadDefIDs(crossroads []CrossroadType, alloc *idAllocator) []uint32 {
+// crossroadBlobFields returns the hex values for the 0x80..0x82 def fields (zeros when unset).
+func crossroadBlobFields(b *CrossroadBlobs) ([3]string, error) {
+	const zero = "0000000000000000"
+	out := [3]string{zero, zero, zero}
+	if b == nil {
+		return out, nil
+	}
+
+	for i, v := range []string{b.F80, b.F81, b.F82} {
+		if v == "" {
+			continue
+		}
+		raw, err := decodeHex(v)
+		if err != nil {
+			return out, fmt.Errorf("tv4p_blobs 0x%02X: %w", 0x80+i, err)
+		}
+		if len(raw) != 8 {
+			return out, fmt.Errorf("tv4p_blobs 0x%02X: want 8 bytes, got %d", 0x80+i, len(raw))
+		}
+		out[i] = hex.EncodeToString(raw)
+	}
+
+	return out, nil
+}
+
+// withConnectionIndices returns a copy of a raw crossroad def with its
+// 0x84..0x87 index fields set to ci.
+func withConnectionIndices(def EntryRaw, ci CrossroadIndices) EntryRaw {
+	idx := map[uint8]int{0x84: ci.A, 0x85: ci.B, 0x86: ci.C, 0x87: ci.D}
+	def.Fields = append([]FieldRaw(nil), def.Fields...)
+	for i := range def.Fields {
+		f := &def.Fields[i]
+		if v, ok := idx[f.Tag]; ok && (f.Type == 0x05 || f.Type == 0x0D) {
+			f.Raw = u32Hex(indexU32(v))
+		}
+	}
+
+	return def
+}
+
+// indexU32 converts a connection index to its u32 form (negative: 0xFFFFFFFF, unset).
+func indexU32(v int) uint32 {
+	if v < 0 || uint64(v) > 0xFFFFFFFF {
+		return 0xFFFFFFFF
+	}
+
+	return uint32(v)
+}
+
+// validateConnectionIndices checks raw connection indices against the road types count.
+func validateConnectionIndices(crossroads []CrossroadType, roadTypes int) error {
+	for _, cr := range crossroads {
+		ci := cr.ConnectionIndices
+		if ci == nil {
+			continue
+		}
+		for i, v := range []int{ci.A, ci.B, ci.C, ci.D} {
+			if v >= roadTypes {
+				return fmt.Errorf("crossroad %q: road type index for %c out of range: %d (have %d road types)", cr.Name, 'A'+i, v, roadTypes)
+			}
+		}
+	}
+
+	return nil
+}
+
+// allocateCrossroadDefIDs allocates crossroad definition IDs spaced by stride (0: crossroadDefIDStride).
+func allocateCrossroadDefIDs(crossroads []CrossroadType, alloc *idAllocator, stride uint32) []uint32 {
+	if stride == 0 {
+		stride = crossroadDefIDStride
+	}
+
 	// Keep stable mapping by position.
 	out := make([]uint32, len(crossroads))
 	if len(crossroads) == 0 {
 		return out
 	}
 
-	const stride = 0x178
-
 	// If any crossroad already has a raw ID (from extract), we keep zero here (unused).
 	// For generated ones, we allocate sequential IDs with a fixed stride and avoid collisions.
 	need := 0
@@ -1212,13 +1689,14 @@ func buildCrossroadLinkEntry(cr CrossroadType, alloc *idAllocator, roadTypes []R
 	// This is required for stable behavior in Terrain Builder; the semantics of
 	// the nested lists and vector fields are not fully reverse engineered yet.
 	if cr.TV4PLink != nil && cr.TV4PLink.Type == 0x1A {
-		return rawEntryToBytes(*cr.TV4PLink, alloc, seed)
+		entry, err := rawEntryToBytes(*cr.TV4PLink, alloc, seed)
+		if err != nil {
+			return nil, fmt.Errorf("crossroad %q link: %w", cr.Name, err)
+		}
+		return entry, nil
 	}
 
-	shapeU32 := uint32(2)
-	if strings.HasPrefix(cr.Name, "kr_x_") {
-		shapeU32 = 3
-	}
+	shapeU32 := crossroadShapeEnum(cr)
 
 	// Minimal template (best-effort) when raw is unavailable.
 	// We mimic the observed field ordering from real files.
@@ -1392,6 +1870,10 @@ func rawFieldToBytes(f FieldRaw, alloc *idAllocator, seed string) ([]byte, error
 			return nil, err
 		}
 
+		if len(raw) > maxStringLen {
+			return nil, fmt.Errorf("string field 0x%02X: length %d exceeds %d bytes", tag, len(raw), maxStringLen)
+		}
+
 		out := make([]byte, 0, len(raw)+5)
 		out = append(out, tag, 0x00, typ)
 		tmp := make([]byte, 2)
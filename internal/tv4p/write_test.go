@@ -0,0 +1,860 @@
+package tv4p
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPatchPreservesStandardColorSentinel(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	// Observed TB "standard" sentinel (not a real RGBA color).
+	cfg.Types[1].TV4PNormalColor = "0000ff00"
+	cfg.Types[1].TV4PKeyColor = "0000ff00"
+	data := buildTestFile(t, cfg, fixtureOptions{})
+
+	parsed, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := parsed.Types[1].TV4PNormalColor; got != "0000ff00" {
+		t.Fatalf("tv4p_normal_color=%q want %q", got, "0000ff00")
+	}
+	if got := parsed.Types[0].TV4PNormalColor; got != "" {
+		t.Fatalf("custom color captured raw: %q", got)
+	}
+
+	out, err := PatchRoadTool(data, parsed, ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if !bytes.Equal(out, data) {
+		t.Fatalf("round-trip changed bytes")
+	}
+	if !bytes.Contains(out, []byte{0x73, 0x00, 0x08, 0x00, 0x00, 0xFF, 0x00}) {
+		t.Fatalf("sentinel not written back")
+	}
+}
+
+func TestFieldColorRaw(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		c      Color
+		custom bool
+		raw    string
+		want   []byte
+		err    bool
+	}{
+		{name: "custom_ignores_raw", c: Color{R: 1, G: 2, B: 3}, custom: true, raw: "0000ff00", want: []byte{0x73, 0, 0x08, 1, 2, 3, 0xFF}},
+		{name: "standard_default", want: []byte{0x73, 0, 0x08, 0, 0, 0, 0xFF}},
+		{name: "standard_raw", raw: "0000ff00", want: []byte{0x73, 0, 0x08, 0, 0, 0xFF, 0}},
+		{name: "bad_len", raw: "00ff", err: true},
+		{name: "bad_hex", raw: "zz00ff00", err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := fieldColorRaw(0x73, tt.c, tt.custom, tt.raw)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want err=%v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got=% x want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchCrossroadsWithoutLinksList(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{noLinks: true})
+
+	cfg := testRoadConfig()
+	cfg.CrossroadTypes = cfg.CrossroadTypes[:1]
+	out, err := PatchRoadTool(data, cfg, ScopeCrossroad)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+
+	got, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("re-parse: %v", err)
+	}
+	if len(got.CrossroadTypes) != 1 || got.CrossroadTypes[0].Name != "kr_t_asf1_city" {
+		t.Fatalf("crossroads=%+v", got.CrossroadTypes)
+	}
+	if got.CrossroadTypes[0].TV4PLink != nil {
+		t.Fatalf("unexpected link entry")
+	}
+
+	// Raw link data cannot be written without a 0x8A list.
+	cfg.CrossroadTypes[0].TV4PLink = &EntryRaw{Type: 0x1A}
+	if _, err := PatchRoadTool(data, cfg, ScopeCrossroad); err == nil {
+		t.Fatalf("expected error when writing links without 0x8A list")
+	}
+}
+
+func TestPatchPreservesRoadTypeExtraFields(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	cfg.Types[0].ID = 0x54
+	cfg.Types[1].ID = 0x9C
+	cfg.Types[0].TV4PExtra = []FieldRaw{{Tag: 0x76, Type: 0x14, Raw: "0000000000002440"}}
+	data := buildTestFile(t, cfg, fixtureOptions{})
+
+	parsed, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	extra := parsed.Types[0].TV4PExtra
+	if len(extra) != 1 || extra[0].Tag != 0x76 || extra[0].Raw != "0000000000002440" {
+		t.Fatalf("tv4p_extra=%+v", extra)
+	}
+	if len(parsed.Types[1].TV4PExtra) != 0 {
+		t.Fatalf("default fields captured: %+v", parsed.Types[1].TV4PExtra)
+	}
+
+	// Edit an unrelated field: the extra field must survive the rebuild.
+	parsed.Types[0].NormalColor = Color{R: 1, G: 2, B: 3, A: 255}
+	out, err := PatchRoadTool(data, parsed, ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	again, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("re-parse: %v", err)
+	}
+	if got := again.Types[0].TV4PExtra; len(got) != 1 || got[0].Raw != "0000000000002440" {
+		t.Fatalf("after patch tv4p_extra=%+v", got)
+	}
+	if !bytes.Contains(out, []byte{0x76, 0x00, 0x14, 0, 0, 0, 0, 0, 0, 0x24, 0x40}) {
+		t.Fatalf("0x76 bytes not written back")
+	}
+}
+
+func TestPatchPreservesStraightPartFlag(t *testing.T) {
+	t.Parallel()
+
+	cfg := testRoadConfig()
+	cfg.Types[0].StraightParts[0].TV4PFlag = 0x05
+	data := buildTestFile(t, cfg, fixtureOptions{})
+
+	parsed, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := parsed.Types[0].StraightParts[0].TV4PFlag; got != 0x05 {
+		t.Fatalf("tv4p_flag=%#x want 0x05", got)
+	}
+	for _, p := range parsed.Types[0].CornerParts {
+		if p.TV4PFlag != 0 {
+			t.Fatalf("corner part %s tv4p_flag=%#x want 0", p.Name, p.TV4PFlag)
+		}
+	}
+
+	// Edit an unrelated field: the flag must survive the rebuild.
+	parsed.Types[0].NormalColor = Color{R: 1, G: 2, B: 3, A: 255}
+	out, err := PatchRoadTool(data, parsed, ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	again, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("re-parse: %v", err)
+	}
+	if got := again.Types[0].StraightParts[0].TV4PFlag; got != 0x05 {
+		t.Fatalf("after patch tv4p_flag=%#x want 0x05", got)
+	}
+	if !bytes.Contains(out, []byte{0x7D, 0x00, 0x09, 0x05}) {
+		t.Fatalf("0x7D byte not written back")
+	}
+}
+
+func TestPatchRoadTypeRawRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+
+	// Add an unmodeled field to the first road type entry and a nested part field.
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	block, err := ParseRoadTypes(data)
+	if err != nil {
+		t.Fatalf("parse road types: %v", err)
+	}
+	if err := AttachRoadTypeRaw(&cfg, block); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	cfg.Types[0].TV4PRaw.Fields = append(cfg.Types[0].TV4PRaw.Fields, FieldRaw{Tag: 0x7F, Type: 0x0D, Raw: "2a000000"})
+
+	withUnknown, err := PatchRoadTool(data, cfg, ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch unknown field: %v", err)
+	}
+
+	// Extract again with raw and patch back: bytes must be identical.
+	again, err := ParseRoadToolConfig(withUnknown)
+	if err != nil {
+		t.Fatalf("re-parse: %v", err)
+	}
+	block, err = ParseRoadTypes(withUnknown)
+	if err != nil {
+		t.Fatalf("re-parse road types: %v", err)
+	}
+	if err := AttachRoadTypeRaw(&again, block); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	// Modeled edits are ignored while tv4p_raw is set.
+	again.Types[0].NormalColor = Color{R: 1, G: 2, B: 3, A: 255}
+
+	out, err := PatchRoadTool(withUnknown, again, ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if !bytes.Equal(out, withUnknown) {
+		t.Fatalf("raw round-trip changed bytes")
+	}
+	if !bytes.Contains(out, []byte{0x7F, 0x00, 0x0D, 0x2A, 0, 0, 0}) {
+		t.Fatalf("unmodeled field not preserved")
+	}
+}
+
+func TestPatchCrossroadOrder(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+
+	tests := []struct {
+		order CrossroadOrder
+		first string
+	}{
+		{order: CrossroadOrderAuto, first: "kr_t_asf1_city"},
+		{order: CrossroadOrderKeep, first: "kr_x_city_city"},
+		{order: CrossroadOrderMatchRoads, first: "kr_t_asf1_city"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.order), func(t *testing.T) {
+			t.Parallel()
+
+			cfg := testRoadConfig()
+			cfg.CrossroadTypes[0].Default = "asf1"
+			cfg.CrossroadTypes[1].Default = "city"
+			cfg.CrossroadTypes[0], cfg.CrossroadTypes[1] = cfg.CrossroadTypes[1], cfg.CrossroadTypes[0]
+			out, err := PatchRoadToolWithOptions(data, cfg, PatchOptions{Scope: ScopeCrossroad, CrossroadOrder: tt.order})
+			if err != nil {
+				t.Fatalf("patch: %v", err)
+			}
+			got, err := ParseRoadToolConfig(out)
+			if err != nil {
+				t.Fatalf("re-parse: %v", err)
+			}
+			if got.CrossroadTypes[0].Name != tt.first {
+				t.Fatalf("first crossroad=%q want %q", got.CrossroadTypes[0].Name, tt.first)
+			}
+		})
+	}
+
+	if _, err := PatchRoadToolWithOptions(data, testRoadConfig(), PatchOptions{CrossroadOrder: "bogus"}); err == nil {
+		t.Fatalf("expected error for unknown order")
+	}
+}
+
+func TestOrderCrossroadsMatchRoads(t *testing.T) {
+	t.Parallel()
+
+	types := []RoadType{{Name: "asf1"}, {Name: "asf2"}, {Name: "city"}, {Name: "grav"}}
+	tests := []struct {
+		name       string
+		crossroads []CrossroadType
+		want       []string
+		err        bool
+	}{
+		{
+			name: "gap_filled_by_connected",
+			crossroads: []CrossroadType{
+				{Name: "kr_city", Default: "city", Connections: CrossroadConnections{A: "city"}},
+				{Name: "kr_misc", Connections: CrossroadConnections{A: "city"}},
+				{Name: "kr_asf2", Connections: CrossroadConnections{A: "asf2"}},
+				{Name: "kr_asf1", Default: "ASF1", Connections: CrossroadConnections{A: "asf1"}},
+			},
+			want: []string{"kr_asf1", "kr_asf2", "kr_city", "kr_misc"},
+		},
+		{
+			name: "gap_filled_by_placeholder",
+			crossroads: []CrossroadType{
+				{Name: "kr_city", Default: "city", Connections: CrossroadConnections{A: "city"}},
+				{Name: "kr_misc", Connections: CrossroadConnections{A: "city"}},
+				{Name: "kr_asf1", Default: "asf1", Connections: CrossroadConnections{A: "asf1"}},
+			},
+			want: []string{"kr_asf1", "kr_misc", "kr_city"},
+		},
+		{
+			name: "gap_unfillable",
+			crossroads: []CrossroadType{
+				{Name: "kr_city", Default: "city", Connections: CrossroadConnections{A: "city"}},
+				{Name: "kr_asf1", Default: "asf1", Connections: CrossroadConnections{A: "asf1"}},
+			},
+			err: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := RoadConfig{Types: types, CrossroadTypes: tt.crossroads}
+			err := orderCrossroadsMatchRoads(&cfg)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want err=%v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			var got []string
+			for _, cr := range cfg.CrossroadTypes {
+				got = append(got, cr.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("order=%v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReorderCrossroadsPreferShape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prefer CrossroadShape
+		first  string
+	}{
+		{prefer: ShapeT, first: "kr_t_asf1_asf1"},
+		{prefer: ShapeX, first: "kr_x_asf1_asf1"},
+	}
+
+	for _, tt := range tests {
+		cfg := RoadConfig{
+			Types: []RoadType{{Name: "asf1"}},
+			CrossroadTypes: []CrossroadType{
+				{Name: "kr_x_asf1_asf1", Connections: CrossroadConnections{A: "asf1", B: "asf1", C: "asf1", D: "asf1"}},
+				{Name: "kr_t_asf1_asf1", Connections: CrossroadConnections{A: "asf1", B: "asf1", C: "asf1"}},
+			},
+		}
+		if tt.prefer == ShapeX {
+			cfg.CrossroadTypes[0], cfg.CrossroadTypes[1] = cfg.CrossroadTypes[1], cfg.CrossroadTypes[0]
+		}
+
+		reorderCrossroadsByRoadTypeIndex(&cfg, tt.prefer)
+		if cfg.CrossroadTypes[0].Name != tt.first {
+			t.Fatalf("prefer=%q first=%q want %q", tt.prefer, cfg.CrossroadTypes[0].Name, tt.first)
+		}
+	}
+
+	if _, err := PatchRoadToolWithOptions(nil, RoadConfig{}, PatchOptions{PreferShape: "y"}); err == nil {
+		t.Fatalf("expected error for unknown shape")
+	}
+}
+
+func TestUpdateCrossroadMetaEmptyToOne(t *testing.T) {
+	t.Parallel()
+
+	// 0x3F offset 0x30, 0x19/0x20 tail zero; old 0x8A list is empty (listLen 4).
+	meta := []byte{
+		0x3F, 0x00, 0x0D, 0x30, 0x00, 0x00, 0x00,
+		0x19, 0x00, 0x20, 0x00, 0x00, 0x00,
+	}
+
+	entry, err := buildEntry(0x1A, 0x00ABCD12, [][]byte{})
+	if err != nil {
+		t.Fatalf("buildEntry: %v", err)
+	}
+	links, err := fieldList(0x8A, [][]byte{entry})
+	if err != nil {
+		t.Fatalf("fieldList: %v", err)
+	}
+
+	delta, err := updateCrossroadMeta(meta, 4, links)
+	if err != nil {
+		t.Fatalf("updateCrossroadMeta: %v", err)
+	}
+	if delta != len(entry) {
+		t.Fatalf("delta=%d want %d", delta, len(entry))
+	}
+	if got := int(readU32(meta[3:])); got != 0x30+len(entry) {
+		t.Fatalf("0x3F=0x%X want 0x%X", got, 0x30+len(entry))
+	}
+	if got := meta[10:13]; got[0] != 0xCD || got[1] != 0xAB || got[2] != 0x00 {
+		t.Fatalf("link ID tail=% X want CD AB 00", got)
+	}
+
+	// Back to empty: offset shrinks again, tail is kept.
+	empty, err := fieldList(0x8A, nil)
+	if err != nil {
+		t.Fatalf("fieldList: %v", err)
+	}
+	if _, err := updateCrossroadMeta(meta, 4+len(entry), empty); err != nil {
+		t.Fatalf("updateCrossroadMeta: %v", err)
+	}
+	if got := int(readU32(meta[3:])); got != 0x30 {
+		t.Fatalf("0x3F=0x%X want 0x30", got)
+	}
+	if meta[10] != 0xCD {
+		t.Fatalf("tail changed on empty list")
+	}
+}
+
+func TestBuildErrorsNameOffendingEntry(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", maxStringLen+1)
+
+	tests := []struct {
+		name string
+		edit func(cfg *RoadConfig)
+		want []string
+	}{
+		{
+			name: "part_path",
+			edit: func(cfg *RoadConfig) { cfg.Types[1].TerminatorPart[0].Path = long },
+			want: []string{`road type "city"`, `part "city_6konec"`, "string field 0x7C", "exceeds 65535 bytes"},
+		},
+		{
+			name: "part_name",
+			edit: func(cfg *RoadConfig) { cfg.Types[0].StraightParts[0].Name = long },
+			want: []string{`road type "asf1"`, "string field 0x33"},
+		},
+		{
+			name: "crossroad_model",
+			edit: func(cfg *RoadConfig) { cfg.CrossroadTypes[1].Model = long },
+			want: []string{`crossroad "kr_x_city_city"`, "string field 0x7C"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := testRoadConfig()
+			tt.edit(&cfg)
+
+			_, err := buildRoadTypesEntries(cfg, map[uint32]struct{}{}, 0)
+			if err == nil {
+				_, _, err = buildCrossroadFields(cfg, map[uint32]struct{}{}, false, PatchOptions{})
+			}
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Fatalf("error %q does not mention %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestPatchIgnoresOffsetTagDecoys(t *testing.T) {
+	t.Parallel()
+
+	base := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	decoy := []byte{0x18, 0x00, 0x0D, 0x44, 0x33, 0x22, 0x11}
+
+	// A field right before the block whose value (0x08) points before it.
+	near := []byte{0x18, 0x00, 0x0D, 0x08, 0x00, 0x00, 0x00}
+	block, err := ParseRoadTypes(base)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	prefix := append(append([]byte(nil), decoy...), make([]byte, offsetTagWindow)...)
+	far := append(append([]byte(nil), prefix...), base...)
+	// Behind a padding prefix the real offsets still have to point past the block.
+	for i, v := range []int{0x1000, 0x2000} {
+		if err := writeU32FromInt(far[len(prefix)+4+7*i+3:], len(prefix)+v); err != nil {
+			t.Fatalf("shift far offset: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		real   int // offset of the Road Tool 0x18 field
+		decoy  []byte
+		at     int  // offset of the decoy
+		moving bool // decoy lies after the region and moves by the size delta
+	}{
+		{
+			name:   "after region",
+			data:   append(append([]byte(nil), base...), decoy...),
+			real:   4,
+			decoy:  decoy,
+			at:     len(base),
+			moving: true,
+		},
+		{
+			name:  "far before block",
+			data:  far,
+			real:  len(prefix) + 4,
+			decoy: decoy,
+			at:    0,
+		},
+		{
+			name:  "next to field",
+			data:  append(append(append([]byte(nil), base[:block.Start]...), near...), base[block.Start:]...),
+			real:  4,
+			decoy: near,
+			at:    block.Start,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := testRoadConfig()
+			cfg.Types[0].StraightParts = append(cfg.Types[0].StraightParts, RoadPart{Name: "asf1_25", Path: `dz\roads\asf1_25.p3d`})
+			out, err := PatchRoadTool(tt.data, cfg, ScopeRoads)
+			if err != nil {
+				t.Fatalf("patch: %v", err)
+			}
+
+			delta := len(out) - len(tt.data)
+			if got, want := readU32(out[tt.real+3:]), readU32(tt.data[tt.real+3:])+uint32(delta); got != want {
+				t.Fatalf("0x18 offset=0x%X want 0x%X", got, want)
+			}
+			decoyAt := tt.at
+			if tt.moving {
+				decoyAt += delta
+			}
+			if !bytes.Equal(out[decoyAt:decoyAt+len(tt.decoy)], tt.decoy) {
+				t.Fatalf("decoy changed: % X", out[decoyAt:decoyAt+len(tt.decoy)])
+			}
+		})
+	}
+}
+
+func TestPatchRoadTypeRawDeepNesting(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	block, err := ParseRoadTypes(data)
+	if err != nil {
+		t.Fatalf("parse road types: %v", err)
+	}
+	if err := AttachRoadTypeRaw(&cfg, block); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	cfg.Types[1].TV4PRaw.Fields = append(cfg.Types[1].TV4PRaw.Fields, FieldRaw{
+		Tag: 0x91, Type: 0x0C, List: []EntryRaw{deepEntryRaw(4)},
+	})
+
+	deep, err := PatchRoadTool(data, cfg, ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch deep field: %v", err)
+	}
+
+	// extract --emit-raw -> JSON -> patch must keep every byte.
+	again, err := ParseRoadToolConfig(deep)
+	if err != nil {
+		t.Fatalf("re-parse: %v", err)
+	}
+	block, err = ParseRoadTypes(deep)
+	if err != nil {
+		t.Fatalf("re-parse road types: %v", err)
+	}
+	if err := AttachRoadTypeRaw(&again, block); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	encoded, err := json.Marshal(again)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded RoadConfig
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	out, err := PatchRoadTool(deep, decoded, ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if !bytes.Equal(out, deep) {
+		t.Fatalf("deep raw round-trip changed bytes")
+	}
+}
+
+func TestPatchIDBase(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	maxID := MaxEntryID(data)
+	if maxID == 0 {
+		t.Fatalf("fixture has no IDs")
+	}
+
+	cfg, err := ParseRoadToolConfig(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	rem := cfg.Types[0].ID % roadTypeIDStride
+	cfg.Types = append(cfg.Types, RoadType{
+		Name:          "asf2",
+		Type:          0x12,
+		StraightParts: []RoadPart{{Name: "asf2_12", Path: `dz\roads\asf2_12.p3d`, Type: 0x13}},
+	})
+
+	const base = 0x10000
+	out, err := PatchRoadToolWithOptions(data, cfg, PatchOptions{Scope: ScopeRoads, IDBase: base})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	got, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("re-parse: %v", err)
+	}
+
+	rt := got.Types[2]
+	if rt.ID < base || rt.ID >= base+roadTypeIDStride || rt.ID%roadTypeIDStride != rem {
+		t.Fatalf("road type id=0x%X want first ID >= 0x%X with remainder 0x%X", rt.ID, base, rem)
+	}
+	if id := rt.StraightParts[0].ID; id < base {
+		t.Fatalf("part id=0x%X want >= 0x%X", id, base)
+	}
+	for i := range 2 {
+		if got.Types[i].ID != cfg.Types[i].ID {
+			t.Fatalf("existing road type %d id=0x%X want 0x%X kept", i, got.Types[i].ID, cfg.Types[i].ID)
+		}
+	}
+	if MaxEntryID(out) <= maxID {
+		t.Fatalf("max id=0x%X want above 0x%X", MaxEntryID(out), maxID)
+	}
+}
+
+func TestPatchRoadsReindexesPreservedCrossroads(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+	asf2 := RoadType{Name: "asf2", StraightParts: []RoadPart{{Name: "asf2_12", Path: `dz\roads\asf2_12.p3d`}}}
+	orig := testRoadConfig().Types
+
+	tests := []struct {
+		name  string
+		types []RoadType
+		err   bool
+	}{
+		{name: "append", types: []RoadType{orig[0], orig[1], asf2}},
+		{name: "prepend", types: []RoadType{asf2, orig[0], orig[1]}},
+		{name: "swap", types: []RoadType{orig[1], orig[0]}},
+		{name: "renamed_case", types: []RoadType{asf2, {Name: "CITY", StraightParts: orig[1].StraightParts}, orig[0]}},
+		{name: "removed", types: []RoadType{orig[0], asf2}, err: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := testRoadConfig()
+			cfg.Types = tt.types
+			out, err := PatchRoadTool(data, cfg, ScopeRoads)
+			if (err != nil) != tt.err {
+				t.Fatalf("err=%v want err=%v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+
+			got, err := ParseRoadToolConfig(out)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			want := testRoadConfig().CrossroadTypes
+			for i, cr := range got.CrossroadTypes {
+				w := want[i].Connections
+				c := cr.Connections
+				if !strings.EqualFold(c.A, w.A) || !strings.EqualFold(c.B, w.B) || !strings.EqualFold(c.C, w.C) || !strings.EqualFold(c.D, w.D) {
+					t.Fatalf("%s connections=%+v want %+v", cr.Name, c, w)
+				}
+			}
+		})
+	}
+}
+
+// collectEntryIDsNaive is the byte-by-byte reference for collectEntryIDs.
+func collectEntryIDsNaive(data []byte) map[uint32]struct{} {
+	used := map[uint32]struct{}{}
+	for i := 0; i+7 < len(data); i++ {
+		if data[i] != 0x06 || data[i+1] != 0x00 || data[i+2] != 0x0D {
+			continue
+		}
+		bodyLen := int(readU32(data[i+3:]))
+		if bodyLen < 6 || i+7+bodyLen > len(data) {
+			continue
+		}
+		if id := readU32(data[i+9:]); id != 0 {
+			used[id] = struct{}{}
+		}
+	}
+
+	return used
+}
+
+func TestCollectEntryIDsMatchesNaive(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	// Bytes biased towards header and small length values so candidates are frequent.
+	alphabet := []byte{0x00, 0x00, 0x06, 0x0D, 0x06, 0x01, 0x07, 0xFF}
+	inputs := [][]byte{nil, {0x06, 0x00, 0x0D}, buildTestFile(t, testRoadConfig(), fixtureOptions{links: true})}
+	for n := 0; n < 200; n++ {
+		data := make([]byte, rng.IntN(512))
+		for i := range data {
+			if rng.IntN(4) == 0 {
+				data[i] = byte(rng.Uint32())
+			} else {
+				data[i] = alphabet[rng.IntN(len(alphabet))]
+			}
+		}
+		inputs = append(inputs, data)
+	}
+
+	for i, data := range inputs {
+		if got, want := collectEntryIDs(data), collectEntryIDsNaive(data); !reflect.DeepEqual(got, want) {
+			t.Fatalf("input %d (%d bytes): ids=%v want %v", i, len(data), got, want)
+		}
+	}
+}
+
+func BenchmarkCollectEntryIDs(b *testing.B) {
+	// A real-sized project: the Road Tool block embedded in a few MB of unrelated data.
+	rng := rand.New(rand.NewPCG(3, 4))
+	data := make([]byte, 8<<20)
+	for i := range data {
+		data[i] = byte(rng.Uint32())
+	}
+	copy(data[len(data)/2:], buildTestFile(b, testRoadConfig(), fixtureOptions{links: true}))
+
+	b.Run("optimized", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for b.Loop() {
+			collectEntryIDs(data)
+		}
+	})
+	b.Run("naive", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		for b.Loop() {
+			collectEntryIDsNaive(data)
+		}
+	})
+}
+
+func TestPatchZeroPartRoadType(t *testing.T) {
+	t.Parallel()
+
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{noCrossroads: true})
+	cfg := RoadConfig{Types: []RoadType{{Name: "bare"}}}
+	out, err := PatchRoadTool(data, cfg, ScopeRoads)
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+
+	// An empty decoy 0x88 list makes detection depend on the road type itself:
+	// without a part path, only its (empty) 0x78/0x79/0x7B lists mark it.
+	decoy := []byte{0x88, 0x00, 0x0C, 4, 0, 0, 0, 0, 0, 0, 0}
+	out = append(out, decoy...)
+
+	block, err := ParseRoadTypes(out)
+	if err != nil {
+		t.Fatalf("re-read: %v", err)
+	}
+	if len(block.Types) != 1 || block.Types[0].Name != "bare" {
+		t.Fatalf("types=%+v want the bare road type", block.Types)
+	}
+	if rt := block.Types[0]; len(rt.StraightParts)+len(rt.CornerParts)+len(rt.TerminatorPart) != 0 {
+		t.Fatalf("parts=%+v want none", rt)
+	}
+	if !entryHasRoadLists(block.Entries[0]) {
+		t.Fatalf("bare road type entry has no 0x78/0x79/0x7B list fields")
+	}
+
+	extracted, err := ParseRoadToolConfig(out)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	again, err := PatchRoadTool(out, extracted, ScopeRoads)
+	if err != nil {
+		t.Fatalf("re-patch extracted: %v", err)
+	}
+	if !bytes.Equal(again, out) {
+		t.Fatalf("re-patching the extracted config changed the file")
+	}
+}
+
+func TestCheckRepls(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		repls []replacement
+		err   bool
+	}{
+		{name: "disjoint", repls: []replacement{{start: 10, end: 20}, {start: 0, end: 5}}},
+		{name: "adjacent", repls: []replacement{{start: 10, end: 20}, {start: 5, end: 10}}},
+		{name: "insertion", repls: []replacement{{start: 10, end: 10}, {start: 0, end: 4}}},
+		{name: "overlap", repls: []replacement{{start: 10, end: 20}, {start: 5, end: 11}}, err: true},
+		{name: "same_start", repls: []replacement{{start: 10, end: 10}, {start: 10, end: 12}}, err: true},
+		{name: "out_of_bounds", repls: []replacement{{start: 90, end: 101}}, err: true},
+		{name: "negative", repls: []replacement{{start: -1, end: 2}}, err: true},
+		{name: "reversed", repls: []replacement{{start: 8, end: 4}}, err: true},
+	}
+
+	for _, tt := range tests {
+		repls := slices.Clone(tt.repls)
+		sortReplsDesc(repls)
+		if err := checkRepls(repls, 100); (err != nil) != tt.err {
+			t.Fatalf("%s: err=%v want err=%v", tt.name, err, tt.err)
+		}
+	}
+}
+
+// TestPatchRejectsOverlappingRepls sets the package-level replsHook, so it must not run in parallel.
+func TestPatchRejectsOverlappingRepls(t *testing.T) {
+	data := buildTestFile(t, testRoadConfig(), fixtureOptions{})
+
+	replsHook = func(repls []replacement) []replacement {
+		r := repls[0]
+		return append(repls, replacement{start: r.start + 1, end: r.start + 2, blob: []byte{0}})
+	}
+	defer func() { replsHook = nil }()
+
+	_, err := PatchRoadTool(data, testRoadConfig(), ScopeAll)
+	if err == nil || !strings.Contains(err.Error(), "overlapping") {
+		t.Fatalf("err=%v want overlapping replacement error", err)
+	}
+}